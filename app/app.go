@@ -33,6 +33,8 @@ func Start() {
 
 	connection.InitConnection(*cfg)
 	router.InitFactory(cfg, connection.Db, connection.Storage, connection.Redis, connection.Mq)
+	router.InitConsumer(cfg, connection.Mq, connection.Redis)
+	router.InitJob(connection.Redis)
 
 	host := cfg.Listener.Host
 	port := cfg.Listener.Port
@@ -59,16 +61,30 @@ func Start() {
 	public := e.Group("api")
 	api := public.Group("/service")
 
-	api.Use(echojwt.WithConfig(auth))
-	api.Use(utils.IsAuthorized())
+	jwtMiddleware := echojwt.WithConfig(auth)
+
+	api.Use(jwtMiddleware)
+	api.Use(utils.IsAuthorized(connection.Redis, router.MenuMappingResolver()))
+
+	callback := public.Group("/callback")
+	callback.Use(utils.IsService(cfg.Auth.ServiceKey))
 
 	e.Use(middleware.Logger())
-	router.InitPublicRoute("", public)
+	router.InitPublicRoute("", public, jwtMiddleware)
+	router.InitCallbackRoute("/model", callback)
 	router.InitUserRoute("/user", api)
 	router.InitRoleRoute("/role", api)
 	router.InitParamRoute("/param", api)
 	router.InitAttendanceRoute("/attendance", api)
 	router.InitInstitutionRoute("/institution", api)
+	router.InitDatasetRoute("/dataset", api)
+	router.InitTrainingRoute("/model", api)
+	router.InitRFIDRoute("/rfid", api)
+	router.InitScheduleRoute("/schedule", api)
+	router.InitCalendarRoute("/calendar", api)
+	router.InitLeaveRoute("/leave", api)
+	router.InitOvertimeRoute("/overtime", api)
+	router.InitExportRoute("/export", api)
 
 	e.Logger.Fatal(e.Start(host + ":" + strconv.Itoa(port)))
 }
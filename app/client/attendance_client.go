@@ -4,19 +4,36 @@ import (
 	"bpkp-svc-portal/app/model"
 	"bpkp-svc-portal/app/utils"
 	"context"
-	"fmt"
 	"strings"
 
 	"gorm.io/gorm"
 )
 
 type InterfaceAttendanceClient interface {
-	GetUserAttendances(ctx context.Context, request *model.RequestUserAttendances) ([]*model.UserAttendance, error)
+	GetUserAttendances(ctx context.Context, request *model.RequestUserAttendances) ([]*model.UserAttendance, *model.ListPage, error)
 	GetTodayAttendances(ctx context.Context, username string) (*model.UserAttendance, error)
 	CheckIn(ctx context.Context, request *model.Attendance) error
 	CheckOut(ctx context.Context, request *model.Attendance) error
+	GetAttendanceByWorkDate(ctx context.Context, username string, workDate string) (*model.Attendance, error)
+
+	GetUnrecordedUsernames(ctx context.Context, workDate string) ([]string, error)
+	GetOpenAttendances(ctx context.Context, workDate string) ([]*model.Attendance, error)
+	MarkAbsent(ctx context.Context, request *model.Attendance) error
+	MarkMissingCheckout(ctx context.Context, request *model.Attendance) error
+
+	GetAttendanceRecap(ctx context.Context, request *model.RequestAttendanceRecap, from string, to string) ([]*model.UserRecap, error)
 }
 
+// workDateColumn is the work date of an attendance row. Rows written before
+// work dates were stored fall back to the check-in date.
+const workDateColumn = "COALESCE(work_date, DATE(check_in))"
+
+const attendanceColumns = "a.id, a.username, a.check_in, a.check_out, a.status_in, a.status_out, a.remark_in, a.remark_out, a.source_in, a.source_out, DATE_FORMAT(COALESCE(a.work_date, a.check_in), '%Y-%m-%d') AS work_date, a.shift_id, COALESCE(a.minutes_late, 0) AS minutes_late, COALESCE(a.minutes_early, 0) AS minutes_early, COALESCE(a.worked_minutes, 0) AS worked_minutes, COALESCE(a.overtime_minutes, 0) AS overtime_minutes, COALESCE(a.corrected_by, '') AS corrected_by, a.corrected_at, a.latitude_in, a.longitude_in, a.accuracy_in, COALESCE(a.geofence_in, '') AS geofence_in, a.latitude_out, a.longitude_out, a.accuracy_out, COALESCE(a.geofence_out, '') AS geofence_out, COALESCE(a.selfie_in, '') AS selfie_in, a.face_score_in"
+
+// userAttendanceHoliday labels a user attendance row with the calendar day it
+// fell on, if any.
+var userAttendanceHoliday = holidayOf("type", "COALESCE(a.work_date, DATE(a.check_in))", "u.institution_id") + " AS day_type, " + holidayOf("name", "COALESCE(a.work_date, DATE(a.check_in))", "u.institution_id") + " AS holiday_name"
+
 type AttendanceClient struct {
 	db *gorm.DB
 }
@@ -25,42 +42,63 @@ func NewAttendanceClient(db *gorm.DB) *AttendanceClient {
 	return &AttendanceClient{db: db}
 }
 
-func (c *AttendanceClient) GetUserAttendances(ctx context.Context, request *model.RequestUserAttendances) ([]*model.UserAttendance, error) {
-	span, _ := utils.SpanFromContext(ctx, "Client: GetUserAttendances")
+// attendanceList is what attendance lists can be sorted and filtered by.
+var attendanceList = &listSpec{
+	sorts: map[string]string{
+		"id":        "a.id",
+		"work_date": "COALESCE(a.work_date, DATE(a.check_in))",
+		"username":  "a.username",
+	},
+	filters: map[string]string{
+		"username":       "a.username",
+		"institution_id": "u.institution_id",
+		"status_in":      "a.status_in",
+		"status_out":     "a.status_out",
+		"source_in":      "a.source_in",
+		"shift_id":       "a.shift_id",
+	},
+	dateColumn:  "COALESCE(a.work_date, DATE(a.check_in))",
+	defaultSort: "-work_date",
+	key:         "id",
+}
+
+func (c *AttendanceClient) GetUserAttendances(ctx context.Context, request *model.RequestUserAttendances) ([]*model.UserAttendance, *model.ListPage, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetUserAttendances")
 	defer span.Finish()
 
 	var response []*model.UserAttendance
 
-	sb := strings.Builder{}
-
-	if request.RoleLevel == 3 {
-		sb.WriteString(fmt.Sprintf(" AND u.username = %s", request.Username))
+	list, err := newListQuery(attendanceList, &request.ListQuery)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
 	}
 
-	if request.RoleLevel == 2 {
-		sb.WriteString(fmt.Sprintf(" AND u.institution_id = '%s'", request.InstitutionID))
-	}
+	list.where(scopeCondition(request.Scope, "u.username", "u.institution_id"))
 
-	if request.Filter.Limit > 0 {
-		sb.WriteString(fmt.Sprintf(" LIMIT %d", request.Filter.Limit))
-	}
+	from := " FROM attendance AS a INNER JOIN users AS u ON a.username = u.username"
 
-	if request.Filter.SortType != "" {
-		sb.WriteString(fmt.Sprintf(" %s", request.Filter.SortType))
-	}
+	var total int64
 
-	query := "SELECT a.*, u.fullname, u.shortname, u.email, u.gender  FROM attendance AS a INNER JOIN users AS u ON a.username = u.username"
+	where, args := list.countSQL()
+	err = c.db.Debug().WithContext(ctx).Raw("SELECT COUNT(1)"+from+where, args...).Scan(&total).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
 
-	utils.LogEvent(span, "Query", query+sb.String())
+	page, args := list.pageSQL()
+	query := "SELECT " + attendanceColumns + ", u.fullname, u.shortname, u.email, u.gender, " + userAttendanceHoliday + from + page
 
-	err := c.db.Debug().Raw(query + sb.String()).Scan(&response).Error
+	utils.LogEvent(span, "Query", query)
 
+	err = c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
 	if err != nil {
 		utils.LogEventError(span, err)
-		return nil, err
+		return nil, nil, err
 	}
 
-	return response, nil
+	return response, list.page(total, &response), nil
 }
 
 func (c *AttendanceClient) GetTodayAttendances(ctx context.Context, username string) (*model.UserAttendance, error) {
@@ -71,7 +109,7 @@ func (c *AttendanceClient) GetTodayAttendances(ctx context.Context, username str
 
 	var response *model.UserAttendance
 
-	query := "SELECT a.*, u.fullname, u.shortname, u.email, u.gender  FROM attendance AS a INNER JOIN users AS u ON a.username = u.username WHERE a.username = ? AND DATE(a.check_in) = CURDATE()"
+	query := "SELECT " + attendanceColumns + ", u.fullname, u.shortname, u.email, u.gender, " + userAttendanceHoliday + " FROM attendance AS a INNER JOIN users AS u ON a.username = u.username WHERE a.username = ? AND DATE(a.check_in) = CURDATE()"
 	utils.LogEvent(span, "Query", query)
 
 	err := c.db.Debug().Raw(query, username).Scan(&response).Error
@@ -92,8 +130,8 @@ func (c *AttendanceClient) CheckIn(ctx context.Context, request *model.Attendanc
 
 	var args []interface{}
 
-	args = append(args, request.Username, request.CheckIn, request.StatusIn, request.RemarkIn, request.SourceIn, request.Username)
-	query := "INSERT INTO attendance (username, check_in, status_in, remark_in, source_in) SELECT ?, ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM attendance WHERE username = ? AND DATE(check_in) = CURDATE())"
+	args = append(args, request.Username, request.CheckIn, request.StatusIn, request.RemarkIn, request.SourceIn, request.WorkDate, request.ShiftID, request.MinutesLate, request.LatitudeIn, request.LongitudeIn, request.AccuracyIn, request.GeofenceIn, request.SelfieIn, request.FaceScoreIn, request.Username, request.WorkDate)
+	query := "INSERT INTO attendance (username, check_in, status_in, remark_in, source_in, work_date, shift_id, minutes_late, latitude_in, longitude_in, accuracy_in, geofence_in, selfie_in, face_score_in) SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM attendance WHERE username = ? AND " + workDateColumn + " = ?)"
 
 	err := c.db.Debug().Exec(query, args...)
 
@@ -117,8 +155,8 @@ func (c *AttendanceClient) CheckOut(ctx context.Context, request *model.Attendan
 
 	var args []interface{}
 
-	args = append(args, request.CheckOut, request.StatusOut, request.RemarkOut, request.SourceOut, request.Username)
-	query := "UPDATE attendance SET check_out = ?, status_out = ?, remark_out = ?, source_out = ? WHERE username = ? AND DATE(check_in) = CURDATE() AND check_out IS NULL"
+	args = append(args, request.CheckOut, request.StatusOut, request.RemarkOut, request.SourceOut, request.MinutesEarly, request.WorkedMinutes, request.OvertimeMinutes, request.LatitudeOut, request.LongitudeOut, request.AccuracyOut, request.GeofenceOut, request.Username, request.WorkDate)
+	query := "UPDATE attendance SET check_out = ?, status_out = ?, remark_out = ?, source_out = ?, minutes_early = ?, worked_minutes = ?, overtime_minutes = ?, latitude_out = ?, longitude_out = ?, accuracy_out = ?, geofence_out = ? WHERE username = ? AND " + workDateColumn + " = ? AND check_out IS NULL"
 
 	err := c.db.Debug().Exec(query, args...)
 
@@ -134,3 +172,181 @@ func (c *AttendanceClient) CheckOut(ctx context.Context, request *model.Attendan
 
 	return nil
 }
+
+// GetAttendanceByWorkDate returns the user's attendance row for a work date,
+// or nil if the user has not checked in.
+func (c *AttendanceClient) GetAttendanceByWorkDate(ctx context.Context, username string, workDate string) (*model.Attendance, error) {
+	span, _ := utils.SpanFromContext(ctx, "Client: GetAttendanceByWorkDate")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username+" "+workDate)
+
+	var response model.Attendance
+
+	query := "SELECT " + attendanceColumns + " FROM attendance AS a WHERE a.username = ? AND COALESCE(a.work_date, DATE(a.check_in)) = ?"
+	result := c.db.Debug().Raw(query, username, workDate).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+// GetUnrecordedUsernames returns the users without an attendance row on a
+// work date.
+func (c *AttendanceClient) GetUnrecordedUsernames(ctx context.Context, workDate string) ([]string, error) {
+	span, _ := utils.SpanFromContext(ctx, "Client: GetUnrecordedUsernames")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", workDate)
+
+	var response []string
+
+	query := "SELECT u.username FROM users AS u WHERE " + approvedUsers + " AND NOT EXISTS (SELECT 1 FROM attendance AS a WHERE a.username = u.username AND COALESCE(a.work_date, DATE(a.check_in)) = ?) ORDER BY u.username"
+	err := c.db.Debug().Raw(query, workDate).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+// GetOpenAttendances returns the rows of a work date that were checked in but
+// neither checked out nor marked as a missing check-out yet.
+func (c *AttendanceClient) GetOpenAttendances(ctx context.Context, workDate string) ([]*model.Attendance, error) {
+	span, _ := utils.SpanFromContext(ctx, "Client: GetOpenAttendances")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", workDate)
+
+	var response []*model.Attendance
+
+	query := "SELECT " + attendanceColumns + " FROM attendance AS a WHERE COALESCE(a.work_date, DATE(a.check_in)) = ? AND a.check_in IS NOT NULL AND a.check_out IS NULL AND (a.status_out IS NULL OR a.status_out = '')"
+	err := c.db.Debug().Raw(query, workDate).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+// MarkAbsent records a work date the user has no attendance on. It does
+// nothing if a row appeared in the meantime.
+func (c *AttendanceClient) MarkAbsent(ctx context.Context, request *model.Attendance) error {
+	span, _ := utils.SpanFromContext(ctx, "Client: MarkAbsent")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	var args []interface{}
+	args = append(args, request.Username, request.StatusIn, request.StatusOut, request.SourceIn, request.SourceOut, request.WorkDate, request.ShiftID, request.Username, request.WorkDate)
+
+	query := "INSERT INTO attendance (username, status_in, status_out, source_in, source_out, work_date, shift_id) SELECT ?, ?, ?, ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM attendance WHERE username = ? AND " + workDateColumn + " = ?)"
+	err := c.db.Debug().Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// MarkMissingCheckout sets the check-out status of a row still open on its
+// work date. A later check-out replaces it.
+func (c *AttendanceClient) MarkMissingCheckout(ctx context.Context, request *model.Attendance) error {
+	span, _ := utils.SpanFromContext(ctx, "Client: MarkMissingCheckout")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	var args []interface{}
+	args = append(args, request.StatusOut, request.SourceOut, request.Username, request.WorkDate)
+
+	query := "UPDATE attendance SET status_out = ?, source_out = ? WHERE username = ? AND " + workDateColumn + " = ? AND check_out IS NULL"
+	err := c.db.Debug().Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// recapColumns total a user's attendance rows by the statuses written at
+// check-in and check-out. Rows checked out before worked time was stored count
+// the time between check-in and check-out. no_record counts only the Absent
+// rows the absence job wrote; workdays it hasn't run for yet (today, or days
+// before it was deployed) have no row and are not counted.
+const recapColumns = "u.username, u.fullname, u.institution_id, COALESCE(i.name, '') AS institution_name, " +
+	"COALESCE(SUM(a.status_in = '" + model.StatusOnTime + "'), 0) AS on_time, " +
+	"COALESCE(SUM(a.status_in = '" + model.StatusLate + "'), 0) AS late, " +
+	"COALESCE(SUM(a.status_out = '" + model.StatusEarly + "'), 0) AS early_leave, " +
+	"COALESCE(SUM(a.status_out = '" + model.StatusMissingCheckout + "'), 0) AS missing_checkout, " +
+	"COALESCE(SUM(a.status_in = '" + model.StatusAbsent + "'), 0) AS no_record, " +
+	"COALESCE(SUM(a.minutes_late), 0) AS minutes_late, " +
+	"COALESCE(SUM(CASE WHEN COALESCE(a.worked_minutes, 0) = 0 AND a.check_in IS NOT NULL AND a.check_out IS NOT NULL THEN TIMESTAMPDIFF(MINUTE, a.check_in, a.check_out) ELSE COALESCE(a.worked_minutes, 0) END), 0) AS worked_minutes"
+
+// GetAttendanceRecap totals the attendance of every user within the request's
+// scope between the work dates from and to (inclusive).
+func (c *AttendanceClient) GetAttendanceRecap(ctx context.Context, request *model.RequestAttendanceRecap, from string, to string) ([]*model.UserRecap, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAttendanceRecap")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	var response []*model.UserRecap
+
+	args := []interface{}{from, to}
+	conditions := []string{approvedUsers}
+
+	condition, scopeArgs := scopeCondition(request.Scope, "u.username", "u.institution_id")
+	if condition != "" {
+		conditions = append(conditions, condition)
+		args = append(args, scopeArgs...)
+	}
+
+	if request.InstitutionID != "" {
+		conditions = append(conditions, "u.institution_id = ?")
+		args = append(args, request.InstitutionID)
+	}
+
+	if request.Username != "" {
+		conditions = append(conditions, "u.username = ?")
+		args = append(args, request.Username)
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("SELECT " + recapColumns + " FROM users AS u LEFT JOIN institutions AS i ON i.id = u.institution_id LEFT JOIN attendance AS a ON a.username = u.username AND COALESCE(a.work_date, DATE(a.check_in)) BETWEEN ? AND ?")
+
+	if len(conditions) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	}
+
+	sb.WriteString(" GROUP BY u.username, u.fullname, u.institution_id, i.name ORDER BY u.institution_id, u.fullname")
+
+	utils.LogEvent(span, "Query", sb.String())
+
+	err := c.db.Debug().WithContext(ctx).Raw(sb.String(), args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
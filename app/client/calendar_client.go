@@ -0,0 +1,205 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+type InterfaceCalendarClient interface {
+	GetAllCalendarDays(ctx context.Context, year int, institutionID string) ([]*model.CalendarDay, error)
+	GetCalendarDayByID(ctx context.Context, id string) (*model.CalendarDay, error)
+	CreateNewCalendarDay(ctx context.Context, day *model.CalendarDay) error
+	UpdateCalendarDay(ctx context.Context, day *model.CalendarDay) error
+	DeleteCalendarDay(ctx context.Context, id string) error
+
+	GetUserHoliday(ctx context.Context, username string, date string) (*model.CalendarDay, error)
+}
+
+type CalendarClient struct {
+	db *gorm.DB
+}
+
+func NewCalendarClient(db *gorm.DB) *CalendarClient {
+	return &CalendarClient{db: db}
+}
+
+const calendarColumns = "id, DATE_FORMAT(date, '%Y-%m-%d') AS date, name, type, institution_id, created_at, created_by, updated_at, updated_by"
+
+// holidayOf returns a subquery selecting column of the calendar day falling on
+// dateExpr for institutionExpr, preferring the institution's own entries over
+// shared ones.
+func holidayOf(column string, dateExpr string, institutionExpr string) string {
+	return "(SELECT c." + column + " FROM calendar_days AS c WHERE c.date = " + dateExpr + " AND (c.institution_id = '' OR c.institution_id IS NULL OR c.institution_id = " + institutionExpr + ") ORDER BY c.institution_id DESC LIMIT 1)"
+}
+
+// GetAllCalendarDays lists calendar days, optionally of one year. When
+// institutionID is set, only shared days and that institution's own are
+// returned.
+func (c *CalendarClient) GetAllCalendarDays(ctx context.Context, year int, institutionID string) ([]*model.CalendarDay, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllCalendarDays")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", institutionID)
+
+	var response []*model.CalendarDay
+
+	var conditions []string
+	var args []interface{}
+	if year > 0 {
+		conditions = append(conditions, "YEAR(date) = ?")
+		args = append(args, year)
+	}
+
+	if institutionID != "" {
+		conditions = append(conditions, "(institution_id = ? OR institution_id = '' OR institution_id IS NULL)")
+		args = append(args, institutionID)
+	}
+
+	query := "SELECT " + calendarColumns + " FROM calendar_days"
+	for i, v := range conditions {
+		if i == 0 {
+			query += " WHERE " + v
+		} else {
+			query += " AND " + v
+		}
+	}
+	query += " ORDER BY date"
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+func (c *CalendarClient) GetCalendarDayByID(ctx context.Context, id string) (*model.CalendarDay, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetCalendarDayByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	var response model.CalendarDay
+
+	query := "SELECT " + calendarColumns + " FROM calendar_days WHERE id = ?"
+	result := c.db.Debug().WithContext(ctx).Raw(query, id).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("calendar day not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("calendar day not found"))
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+func (c *CalendarClient) CreateNewCalendarDay(ctx context.Context, day *model.CalendarDay) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateNewCalendarDay")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", day)
+
+	var args []interface{}
+	args = append(args, day.ID, day.Date, day.Name, day.Type, day.InstitutionID, day.CreatedAt, day.CreatedBy, day.UpdatedAt, day.UpdatedBy)
+
+	query := "INSERT INTO calendar_days (id, date, name, type, institution_id, created_at, created_by, updated_at, updated_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create New Calendar Day")
+
+	return nil
+}
+
+func (c *CalendarClient) UpdateCalendarDay(ctx context.Context, day *model.CalendarDay) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: UpdateCalendarDay")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", day)
+
+	var args []interface{}
+	args = append(args, day.Date, day.Name, day.Type, day.InstitutionID, day.UpdatedAt, day.UpdatedBy, day.ID)
+
+	query := "UPDATE calendar_days SET date = ?, name = ?, type = ?, institution_id = ?, updated_at = ?, updated_by = ? WHERE id = ?"
+	result := c.db.Debug().WithContext(ctx).Exec(query, args...)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("calendar day not found"))
+		return model.ThrowError(http.StatusNotFound, errors.New("calendar day not found"))
+	}
+
+	utils.LogEvent(span, "Response", "Success Update Calendar Day")
+
+	return nil
+}
+
+func (c *CalendarClient) DeleteCalendarDay(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: DeleteCalendarDay")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	result := c.db.Debug().WithContext(ctx).Exec("DELETE FROM calendar_days WHERE id = ?", id)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("calendar day not found"))
+		return model.ThrowError(http.StatusNotFound, errors.New("calendar day not found"))
+	}
+
+	utils.LogEvent(span, "Response", "Success Delete Calendar Day")
+
+	return nil
+}
+
+// GetUserHoliday returns the calendar day falling on date (YYYY-MM-DD) for the
+// user's institution, or nil if it is not a holiday.
+func (c *CalendarClient) GetUserHoliday(ctx context.Context, username string, date string) (*model.CalendarDay, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetUserHoliday")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username+" "+date)
+
+	var response model.CalendarDay
+
+	query := "SELECT " + calendarColumns + " FROM calendar_days WHERE id = " + holidayOf("id", "?", "(SELECT institution_id FROM users WHERE username = ?)")
+	result := c.db.Debug().WithContext(ctx).Raw(query, date, username).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEvent(span, "Response", "No Holiday")
+		return nil, nil
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
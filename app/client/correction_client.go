@@ -0,0 +1,246 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type InterfaceCorrectionClient interface {
+	GetAllCorrections(ctx context.Context, scope *model.DataScope, status string) ([]*model.AttendanceCorrection, error)
+	GetCorrectionByID(ctx context.Context, id string) (*model.AttendanceCorrection, error)
+	CreateNewCorrection(ctx context.Context, correction *model.AttendanceCorrection) error
+	HasPendingCorrection(ctx context.Context, username string, workDate string) (bool, error)
+	ApproveCorrection(ctx context.Context, correction *model.AttendanceCorrection, attendance *model.Attendance) error
+	CloseCorrection(ctx context.Context, correction *model.AttendanceCorrection) error
+}
+
+type CorrectionClient struct {
+	db *gorm.DB
+}
+
+func NewCorrectionClient(db *gorm.DB) *CorrectionClient {
+	return &CorrectionClient{db: db}
+}
+
+const correctionColumns = "c.id, c.username, u.fullname, u.institution_id, DATE_FORMAT(c.work_date, '%Y-%m-%d') AS work_date, c.check_in, c.check_out, c.reason, c.status, c.decided_by, c.decided_at, c.decision_note, c.created_at"
+
+// GetAllCorrections lists the corrections within scope, newest first,
+// optionally only those in one status.
+func (c *CorrectionClient) GetAllCorrections(ctx context.Context, scope *model.DataScope, status string) ([]*model.AttendanceCorrection, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllCorrections")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", status)
+
+	var response []*model.AttendanceCorrection
+
+	condition, args := scopeCondition(scope, "c.username", "u.institution_id")
+	if status != "" {
+		if condition != "" {
+			condition += " AND "
+		}
+		condition += "c.status = ?"
+		args = append(args, status)
+	}
+
+	query := "SELECT " + correctionColumns + " FROM attendance_corrections AS c INNER JOIN users AS u ON c.username = u.username"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY c.created_at DESC"
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+// GetCorrectionByID returns a correction with, once approved, the attendance
+// row it replaced.
+func (c *CorrectionClient) GetCorrectionByID(ctx context.Context, id string) (*model.AttendanceCorrection, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetCorrectionByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	var response model.AttendanceCorrection
+
+	query := "SELECT " + correctionColumns + " FROM attendance_corrections AS c INNER JOIN users AS u ON c.username = u.username WHERE c.id = ?"
+	result := c.db.Debug().WithContext(ctx).Raw(query, id).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("correction not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("correction not found"))
+	}
+
+	var history model.AttendanceHistory
+
+	query = "SELECT id, correction_id, username, DATE_FORMAT(work_date, '%Y-%m-%d') AS work_date, check_in, check_out, status_in, status_out, minutes_late, minutes_early, worked_minutes, changed_at, changed_by FROM attendance_history WHERE correction_id = ?"
+	result = c.db.Debug().WithContext(ctx).Raw(query, id).Scan(&history)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		response.Original = &history
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+func (c *CorrectionClient) CreateNewCorrection(ctx context.Context, correction *model.AttendanceCorrection) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateNewCorrection")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", correction)
+
+	var args []interface{}
+	args = append(args, correction.ID, correction.Username, correction.WorkDate, correction.CheckIn, correction.CheckOut, correction.Reason, correction.Status, correction.CreatedAt)
+
+	query := "INSERT INTO attendance_corrections (id, username, work_date, check_in, check_out, reason, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create New Correction")
+
+	return nil
+}
+
+func (c *CorrectionClient) HasPendingCorrection(ctx context.Context, username string, workDate string) (bool, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: HasPendingCorrection")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username+" "+workDate)
+
+	var count int64
+
+	query := "SELECT COUNT(1) FROM attendance_corrections WHERE username = ? AND work_date = ? AND status = ?"
+	err := c.db.Debug().WithContext(ctx).Raw(query, username, workDate, model.CorrectionPending).Scan(&count).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ApproveCorrection approves a pending correction, copies the attendance row
+// of its work date to attendance_history and replaces it with attendance. A
+// work date without a row gets a new one.
+func (c *CorrectionClient) ApproveCorrection(ctx context.Context, correction *model.AttendanceCorrection, attendance *model.Attendance) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: ApproveCorrection")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", correction)
+
+	err := c.db.Debug().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := closeCorrection(tx, correction); err != nil {
+			return err
+		}
+
+		var args []interface{}
+		args = append(args, uuid.New().String(), correction.ID, attendance.WorkDate, attendance.CorrectedAt, attendance.CorrectedBy, attendance.Username, attendance.WorkDate)
+
+		query := "INSERT INTO attendance_history (id, correction_id, username, work_date, check_in, check_out, status_in, status_out, minutes_late, minutes_early, worked_minutes, changed_at, changed_by) SELECT ?, ?, username, ?, check_in, check_out, status_in, status_out, COALESCE(minutes_late, 0), COALESCE(minutes_early, 0), COALESCE(worked_minutes, 0), ?, ? FROM attendance WHERE username = ? AND " + workDateColumn + " = ?"
+		if err := tx.Exec(query, args...).Error; err != nil {
+			return err
+		}
+
+		args = nil
+		args = append(args, attendance.CheckIn, nullTime(attendance.CheckOut), attendance.StatusIn, attendance.StatusOut, attendance.MinutesLate, attendance.MinutesEarly, attendance.WorkedMinutes, attendance.OvertimeMinutes, attendance.WorkDate, attendance.ShiftID, attendance.CorrectedBy, attendance.CorrectedAt, attendance.Username, attendance.WorkDate)
+
+		query = "UPDATE attendance SET check_in = ?, check_out = ?, status_in = ?, status_out = ?, minutes_late = ?, minutes_early = ?, worked_minutes = ?, overtime_minutes = ?, work_date = ?, shift_id = ?, corrected_by = ?, corrected_at = ? WHERE username = ? AND " + workDateColumn + " = ?"
+		result := tx.Exec(query, args...)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected > 0 {
+			return nil
+		}
+
+		source := fmt.Sprintf("correction:%s", correction.ID)
+
+		args = nil
+		args = append(args, attendance.Username, attendance.CheckIn, nullTime(attendance.CheckOut), attendance.StatusIn, attendance.StatusOut, source, source, attendance.MinutesLate, attendance.MinutesEarly, attendance.WorkedMinutes, attendance.OvertimeMinutes, attendance.WorkDate, attendance.ShiftID, attendance.CorrectedBy, attendance.CorrectedAt)
+
+		query = "INSERT INTO attendance (username, check_in, check_out, status_in, status_out, source_in, source_out, minutes_late, minutes_early, worked_minutes, overtime_minutes, work_date, shift_id, corrected_by, corrected_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		return tx.Exec(query, args...).Error
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Approve Correction")
+
+	return nil
+}
+
+// CloseCorrection moves a pending correction to its new status.
+func (c *CorrectionClient) CloseCorrection(ctx context.Context, correction *model.AttendanceCorrection) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CloseCorrection")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", correction)
+
+	if err := closeCorrection(c.db.Debug().WithContext(ctx), correction); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Close Correction")
+
+	return nil
+}
+
+func closeCorrection(db *gorm.DB, correction *model.AttendanceCorrection) error {
+	var args []interface{}
+	args = append(args, correction.Status, correction.DecidedBy, correction.DecidedAt, correction.DecisionNote, correction.ID, model.CorrectionPending)
+
+	query := "UPDATE attendance_corrections SET status = ?, decided_by = ?, decided_at = ?, decision_note = ? WHERE id = ? AND status = ?"
+	result := db.Exec(query, args...)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ThrowError(http.StatusBadRequest, errors.New("correction is no longer pending"))
+	}
+
+	return nil
+}
+
+// nullTime stores a zero time as NULL.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t
+}
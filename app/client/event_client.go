@@ -0,0 +1,86 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/config"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+)
+
+type InterfaceEventClient interface {
+	Publish(ctx context.Context, eventType string, institutionID string, payload interface{}) error
+}
+
+type EventClient struct {
+	mq       *amqp.Channel
+	exchange string
+}
+
+func NewEventClient(mq *amqp.Channel, cfg *config.Config) *EventClient {
+	exchange := cfg.RabbitMQ.Exchange
+	if exchange == "" {
+		exchange = "bpkp.events"
+	}
+
+	err := mq.ExchangeDeclare(exchange, amqp.ExchangeTopic, true, false, false, false, nil)
+	if err != nil {
+		logrus.Panicf("Failed to declare exchange %s: %v", exchange, err)
+	}
+
+	return &EventClient{
+		mq:       mq,
+		exchange: exchange,
+	}
+}
+
+// Publish emits a persistent JSON event routed by its type. The actor is taken
+// from the request metadata and left empty for unauthenticated callers.
+func (c *EventClient) Publish(ctx context.Context, eventType string, institutionID string, payload interface{}) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: Publish")
+	defer span.Finish()
+
+	event := &model.Event{
+		Type:          eventType,
+		ID:            uuid.New().String(),
+		OccurredAt:    utils.LocalTime(),
+		InstitutionID: institutionID,
+		Payload:       payload,
+	}
+
+	if session, err := utils.GetMetadata(ctx); err == nil {
+		event.Actor = session.Username
+		if event.InstitutionID == "" {
+			event.InstitutionID = session.InstitutionID
+		}
+	}
+
+	utils.LogEvent(span, "Request", event)
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err = c.mq.PublishWithContext(ctx, c.exchange, eventType, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.ID,
+		Timestamp:    event.OccurredAt,
+		Type:         eventType,
+		Body:         body,
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Publish Event")
+
+	return nil
+}
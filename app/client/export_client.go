@@ -0,0 +1,114 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InterfaceExportClient interface {
+	CreateExportJob(ctx context.Context, job *model.ExportJob) error
+	FinishExportJob(ctx context.Context, job *model.ExportJob) error
+	FailStaleExportJobs(ctx context.Context, startedBefore time.Time) error
+	GetExportJob(ctx context.Context, id string) (*model.ExportJob, error)
+}
+
+type ExportClient struct {
+	db *gorm.DB
+}
+
+func NewExportClient(db *gorm.DB) *ExportClient {
+	return &ExportClient{db: db}
+}
+
+func (c *ExportClient) CreateExportJob(ctx context.Context, job *model.ExportJob) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateExportJob")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", job)
+
+	var args []interface{}
+	args = append(args, job.ID, job.Username, job.Kind, job.Format, job.Status, job.Rows, job.CreatedAt)
+
+	query := "INSERT INTO export_jobs (id, username, kind, format, status, `rows`, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// FinishExportJob stores the final status of a running job with its object
+// key or error. Jobs already failed as stale stay failed.
+func (c *ExportClient) FinishExportJob(ctx context.Context, job *model.ExportJob) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: FinishExportJob")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", job)
+
+	var args []interface{}
+	args = append(args, job.Status, job.ObjectKey, job.Error, job.FinishedAt, job.ID, model.ExportStatusRunning)
+
+	query := "UPDATE export_jobs SET status = ?, object_key = ?, error = ?, finished_at = ? WHERE id = ? AND status = ?"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// FailStaleExportJobs fails the jobs still running that started before
+// startedBefore, e.g. because the replica running them stopped.
+func (c *ExportClient) FailStaleExportJobs(ctx context.Context, startedBefore time.Time) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: FailStaleExportJobs")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", startedBefore)
+
+	var args []interface{}
+	args = append(args, model.ExportStatusFailed, "export timed out", utils.LocalTime(), model.ExportStatusRunning, startedBefore)
+
+	query := "UPDATE export_jobs SET status = ?, error = ?, finished_at = ? WHERE status = ? AND created_at < ?"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *ExportClient) GetExportJob(ctx context.Context, id string) (*model.ExportJob, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetExportJob")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	var response model.ExportJob
+
+	query := "SELECT id, username, kind, format, status, `rows`, COALESCE(object_key, '') AS object_key, COALESCE(error, '') AS error, created_at, finished_at FROM export_jobs WHERE id = ?"
+	result := c.db.Debug().WithContext(ctx).Raw(query, id).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("export not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("export not found"))
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+type InterfaceGeofenceClient interface {
+	GetUserLocations(ctx context.Context, username string) ([]*model.InstitutionLocation, error)
+	GetActiveExemption(ctx context.Context, username string, date string) (*model.GeofenceExemption, error)
+
+	GetAllExemptions(ctx context.Context, institutionID string) ([]*model.GeofenceExemption, error)
+	SaveExemption(ctx context.Context, exemption *model.GeofenceExemption) error
+	DeleteExemption(ctx context.Context, username string) error
+}
+
+type GeofenceClient struct {
+	db *gorm.DB
+}
+
+func NewGeofenceClient(db *gorm.DB) *GeofenceClient {
+	return &GeofenceClient{db: db}
+}
+
+const exemptionColumns = "e.username, u.fullname, u.institution_id, e.reason, DATE_FORMAT(e.valid_until, '%Y-%m-%d') AS valid_until, e.created_at, e.created_by"
+
+// GetUserLocations returns the office locations of the user's institution.
+func (c *GeofenceClient) GetUserLocations(ctx context.Context, username string) ([]*model.InstitutionLocation, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetUserLocations")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username)
+
+	var response []*model.InstitutionLocation
+
+	query := "SELECT l.* FROM institution_locations AS l INNER JOIN users AS u ON l.institution_id = u.institution_id WHERE u.username = ?"
+	err := c.db.Debug().WithContext(ctx).Raw(query, username).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+// GetActiveExemption returns the user's exemption if it is still valid on
+// date (YYYY-MM-DD), or nil.
+func (c *GeofenceClient) GetActiveExemption(ctx context.Context, username string, date string) (*model.GeofenceExemption, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetActiveExemption")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username+" "+date)
+
+	var response model.GeofenceExemption
+
+	query := "SELECT " + exemptionColumns + " FROM geofence_exemptions AS e INNER JOIN users AS u ON e.username = u.username WHERE e.username = ? AND (e.valid_until IS NULL OR e.valid_until >= ?)"
+	result := c.db.Debug().WithContext(ctx).Raw(query, username, date).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+// GetAllExemptions lists exemptions, only those of institutionID's users when
+// it is set.
+func (c *GeofenceClient) GetAllExemptions(ctx context.Context, institutionID string) ([]*model.GeofenceExemption, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllExemptions")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", institutionID)
+
+	var response []*model.GeofenceExemption
+
+	var args []interface{}
+	query := "SELECT " + exemptionColumns + " FROM geofence_exemptions AS e INNER JOIN users AS u ON e.username = u.username"
+	if institutionID != "" {
+		query += " WHERE u.institution_id = ?"
+		args = append(args, institutionID)
+	}
+	query += " ORDER BY u.fullname"
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+// SaveExemption creates the user's exemption or replaces the existing one.
+func (c *GeofenceClient) SaveExemption(ctx context.Context, exemption *model.GeofenceExemption) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: SaveExemption")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", exemption)
+
+	var args []interface{}
+	args = append(args, exemption.Username, exemption.Reason, exemption.ValidUntil, exemption.CreatedAt, exemption.CreatedBy)
+
+	query := "REPLACE INTO geofence_exemptions (username, reason, valid_until, created_at, created_by) VALUES (?, ?, ?, ?, ?)"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Save Exemption")
+
+	return nil
+}
+
+func (c *GeofenceClient) DeleteExemption(ctx context.Context, username string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: DeleteExemption")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username)
+
+	result := c.db.Debug().WithContext(ctx).Exec("DELETE FROM geofence_exemptions WHERE username = ?", username)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("exemption not found"))
+		return model.ThrowError(http.StatusNotFound, errors.New("exemption not found"))
+	}
+
+	utils.LogEvent(span, "Response", "Success Delete Exemption")
+
+	return nil
+}
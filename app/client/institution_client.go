@@ -4,14 +4,12 @@ import (
 	"bpkp-svc-portal/app/model"
 	"bpkp-svc-portal/app/utils"
 	"context"
-	"errors"
-	"net/http"
 
 	"gorm.io/gorm"
 )
 
 type InterfaceInstitutionClient interface {
-	GetAllInstitutions(ctx context.Context) ([]*model.Institution, error)
+	GetAllInstitutions(ctx context.Context, institutionID string, query *model.ListQuery) ([]*model.Institution, *model.ListPage, error)
 	GetInstitutionByID(ctx context.Context, id string) (*model.Institution, error)
 	CreateNewInstitution(ctx context.Context, institution *model.Institution) error
 	UpdateInstitution(ctx context.Context, institution *model.Institution) error
@@ -26,28 +24,65 @@ func NewInstitutionClient(db *gorm.DB) InterfaceInstitutionClient {
 	return &InstitutionClient{db: db}
 }
 
-func (c *InstitutionClient) GetAllInstitutions(ctx context.Context) ([]*model.Institution, error) {
+// institutionList is what institution lists can be sorted and filtered by.
+var institutionList = &listSpec{
+	sorts: map[string]string{
+		"id":   "id",
+		"name": "name",
+	},
+	filters: map[string]string{
+		"id":    "id",
+		"email": "email",
+	},
+	defaultSort: "name",
+	key:         "id",
+}
+
+// GetAllInstitutions lists a page of institutions, only institutionID's when
+// set.
+func (c *InstitutionClient) GetAllInstitutions(ctx context.Context, institutionID string, query *model.ListQuery) ([]*model.Institution, *model.ListPage, error) {
 	span, _ := utils.SpanFromContext(ctx, "Client: GetAllInstitutions")
 	defer span.Finish()
 
-	utils.LogEvent(span, "Request", "All")
+	utils.LogEvent(span, "Request", institutionID)
 	var response []*model.Institution
 
-	query := "SELECT * FROM institutions"
-	utils.LogEvent(span, "Query", query)
+	list, err := newListQuery(institutionList, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
 
-	err := c.db.Debug().Raw(query).Scan(&response).Error
+	if institutionID != "" {
+		list.where("id = ?", institutionID)
+	}
+
+	var total int64
+
+	where, args := list.countSQL()
+	err = c.db.Debug().Raw("SELECT COUNT(1) FROM institutions"+where, args...).Scan(&total).Error
 	if err != nil {
 		utils.LogEventError(span, err)
-		return nil, err
+		return nil, nil, err
 	}
 
-	if response == nil {
-		return nil, model.ThrowError(http.StatusInternalServerError, errors.New("Data Not Found"))
+	page, args := list.pageSQL()
+	sql := "SELECT * FROM institutions" + page
+	utils.LogEvent(span, "Query", sql)
+
+	err = c.db.Debug().Raw(sql, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	if err := c.loadLocations(ctx, response...); err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
 	}
 
 	utils.LogEvent(span, "Response", response)
-	return response, nil
+	return response, list.page(total, &response), nil
 }
 
 func (c *InstitutionClient) GetInstitutionByID(ctx context.Context, id string) (*model.Institution, error) {
@@ -66,6 +101,13 @@ func (c *InstitutionClient) GetInstitutionByID(ctx context.Context, id string) (
 		return nil, err
 	}
 
+	if response != nil {
+		if err := c.loadLocations(ctx, response); err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+	}
+
 	utils.LogEvent(span, "Response", response)
 	return response, nil
 }
@@ -79,7 +121,13 @@ func (c *InstitutionClient) CreateNewInstitution(ctx context.Context, institutio
 	var args []interface{}
 
 	args = append(args, institution.ID, institution.Name, institution.Address, institution.PhoneNumber, institution.Email, institution.CreatedAt, institution.CreatedBy)
-	err := c.db.Debug().Exec("INSERT INTO institutions (id, name, address, phone_number, email, created_at, created_by) VALUES (?, ?, ?, ?, ?, ?, ?)", args...).Error
+	err := c.db.Debug().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("INSERT INTO institutions (id, name, address, phone_number, email, created_at, created_by) VALUES (?, ?, ?, ?, ?, ?, ?)", args...).Error; err != nil {
+			return err
+		}
+
+		return insertLocations(tx, institution)
+	})
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
@@ -97,7 +145,22 @@ func (c *InstitutionClient) UpdateInstitution(ctx context.Context, institution *
 
 	var args []interface{}
 	args = append(args, institution.Name, institution.Address, institution.PhoneNumber, institution.UpdatedAt, institution.UpdatedBy, institution.ID)
-	err := c.db.Debug().Exec("UPDATE institutions SET name = ?, address = ?, phone_number = ?, updated_at = ?, updated_by = ? WHERE id = ?", args...).Error
+	err := c.db.Debug().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("UPDATE institutions SET name = ?, address = ?, phone_number = ?, updated_at = ?, updated_by = ? WHERE id = ?", args...).Error; err != nil {
+			return err
+		}
+
+		// Locations are replaced only when the request carries them.
+		if institution.Locations == nil {
+			return nil
+		}
+
+		if err := tx.Exec("DELETE FROM institution_locations WHERE institution_id = ?", institution.ID).Error; err != nil {
+			return err
+		}
+
+		return insertLocations(tx, institution)
+	})
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
@@ -113,7 +176,13 @@ func (c *InstitutionClient) DeleteInstitution(ctx context.Context, id string) er
 
 	utils.LogEvent(span, "Request", id)
 
-	err := c.db.Debug().Exec("DELETE FROM institutions WHERE id = ?", id).Error
+	err := c.db.Debug().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM institution_locations WHERE institution_id = ?", id).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec("DELETE FROM institutions WHERE id = ?", id).Error
+	})
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
@@ -122,3 +191,45 @@ func (c *InstitutionClient) DeleteInstitution(ctx context.Context, id string) er
 	utils.LogEvent(span, "Response", "Success Delete Institution")
 	return nil
 }
+
+func (c *InstitutionClient) loadLocations(ctx context.Context, institutions ...*model.Institution) error {
+	if len(institutions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(institutions))
+	for _, v := range institutions {
+		ids = append(ids, v.ID)
+	}
+
+	var locations []*model.InstitutionLocation
+	err := c.db.Debug().WithContext(ctx).Raw("SELECT * FROM institution_locations WHERE institution_id IN ? ORDER BY name", ids).Scan(&locations).Error
+	if err != nil {
+		return err
+	}
+
+	byInstitution := make(map[string][]*model.InstitutionLocation)
+	for _, v := range locations {
+		byInstitution[v.InstitutionID] = append(byInstitution[v.InstitutionID], v)
+	}
+
+	for _, v := range institutions {
+		v.Locations = byInstitution[v.ID]
+	}
+
+	return nil
+}
+
+func insertLocations(tx *gorm.DB, institution *model.Institution) error {
+	for _, location := range institution.Locations {
+		var args []interface{}
+		args = append(args, location.ID, institution.ID, location.Name, location.Latitude, location.Longitude, location.RadiusMeters)
+
+		query := "INSERT INTO institution_locations (id, institution_id, name, latitude, longitude, radius_meters) VALUES (?, ?, ?, ?, ?, ?)"
+		if err := tx.Exec(query, args...).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
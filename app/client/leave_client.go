@@ -0,0 +1,292 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InterfaceLeaveClient interface {
+	GetAllLeaves(ctx context.Context, scope *model.DataScope, status string) ([]*model.LeaveRequest, error)
+	GetLeaveByID(ctx context.Context, id string) (*model.LeaveRequest, error)
+	CreateNewLeave(ctx context.Context, leave *model.LeaveRequest) error
+	HasOverlappingLeave(ctx context.Context, username string, startDate string, endDate string) (bool, error)
+	ApproveLeave(ctx context.Context, leave *model.LeaveRequest) error
+	CloseLeave(ctx context.Context, leave *model.LeaveRequest) error
+
+	CreateLeaveBalance(ctx context.Context, balance *model.LeaveBalance) error
+	GetLeaveBalances(ctx context.Context, username string, year int) ([]*model.LeaveBalance, error)
+}
+
+type LeaveClient struct {
+	db *gorm.DB
+}
+
+func NewLeaveClient(db *gorm.DB) *LeaveClient {
+	return &LeaveClient{db: db}
+}
+
+const leaveColumns = "l.id, l.username, u.fullname, u.institution_id, l.leave_type, DATE_FORMAT(l.start_date, '%Y-%m-%d') AS start_date, DATE_FORMAT(l.end_date, '%Y-%m-%d') AS end_date, l.days, l.reason, l.attachment, l.status, l.decided_by, l.decided_at, l.decision_note, l.created_at"
+
+// GetAllLeaves lists the leave requests within scope, newest first,
+// optionally only those in one status.
+func (c *LeaveClient) GetAllLeaves(ctx context.Context, scope *model.DataScope, status string) ([]*model.LeaveRequest, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllLeaves")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", status)
+
+	var response []*model.LeaveRequest
+
+	condition, args := scopeCondition(scope, "l.username", "u.institution_id")
+	if status != "" {
+		if condition != "" {
+			condition += " AND "
+		}
+		condition += "l.status = ?"
+		args = append(args, status)
+	}
+
+	query := "SELECT " + leaveColumns + " FROM leave_requests AS l INNER JOIN users AS u ON l.username = u.username"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY l.created_at DESC"
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+func (c *LeaveClient) GetLeaveByID(ctx context.Context, id string) (*model.LeaveRequest, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetLeaveByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	var response model.LeaveRequest
+
+	query := "SELECT " + leaveColumns + " FROM leave_requests AS l INNER JOIN users AS u ON l.username = u.username WHERE l.id = ?"
+	result := c.db.Debug().WithContext(ctx).Raw(query, id).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("leave request not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("leave request not found"))
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+func (c *LeaveClient) CreateNewLeave(ctx context.Context, leave *model.LeaveRequest) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateNewLeave")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", leave)
+
+	var args []interface{}
+	args = append(args, leave.ID, leave.Username, leave.Type, leave.StartDate, leave.EndDate, leave.Days, leave.Reason, leave.Attachment, leave.Status, leave.CreatedAt)
+
+	query := "INSERT INTO leave_requests (id, username, leave_type, start_date, end_date, days, reason, attachment, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create New Leave")
+
+	return nil
+}
+
+// HasOverlappingLeave reports whether the user has a pending or approved leave
+// request overlapping the date range.
+func (c *LeaveClient) HasOverlappingLeave(ctx context.Context, username string, startDate string, endDate string) (bool, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: HasOverlappingLeave")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", fmt.Sprintf("%s %s-%s", username, startDate, endDate))
+
+	var count int64
+
+	var args []interface{}
+	args = append(args, username, model.LeavePending, model.LeaveApproved, endDate, startDate)
+
+	query := "SELECT COUNT(1) FROM leave_requests WHERE username = ? AND status IN (?, ?) AND start_date <= ? AND end_date >= ?"
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&count).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ApproveLeave approves a pending leave request, deducts its days from the
+// balance of its year when the type has a quota, and records each of its
+// WorkDates in attendance. An Absent row the absence job wrote before the leave
+// was approved, the usual case for sick leave, is replaced; days the user
+// otherwise has attendance on are left as they are.
+func (c *LeaveClient) ApproveLeave(ctx context.Context, leave *model.LeaveRequest) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: ApproveLeave")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", leave)
+
+	err := c.db.Debug().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := closeLeave(tx, leave); err != nil {
+			return err
+		}
+
+		if _, ok := model.LeaveQuotaParams[leave.Type]; ok {
+			year, err := leaveYear(leave)
+			if err != nil {
+				return err
+			}
+
+			var args []interface{}
+			args = append(args, leave.Days, leave.Username, year, leave.Type, leave.Days)
+
+			query := "UPDATE leave_balances SET used = used + ? WHERE username = ? AND year = ? AND leave_type = ? AND quota - used >= ?"
+			result := tx.Exec(query, args...)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				return model.ThrowError(http.StatusBadRequest, errors.New("insufficient leave balance"))
+			}
+		}
+
+		status := model.LeaveAttendanceStatus(leave.Type)
+		source := fmt.Sprintf("leave:%s", leave.ID)
+
+		for _, date := range leave.WorkDates {
+			query := "DELETE FROM attendance WHERE username = ? AND " + workDateColumn + " = ? AND status_in = ? AND source_in = ? AND check_in IS NULL"
+			if err := tx.Exec(query, leave.Username, date, model.StatusAbsent, model.SourceSystem).Error; err != nil {
+				return err
+			}
+
+			var args []interface{}
+			args = append(args, leave.Username, status, status, leave.Reason, source, source, date, leave.Username, date)
+
+			query = "INSERT INTO attendance (username, status_in, status_out, remark_in, source_in, source_out, work_date) SELECT ?, ?, ?, ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM attendance WHERE username = ? AND " + workDateColumn + " = ?)"
+			if err := tx.Exec(query, args...).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Approve Leave")
+
+	return nil
+}
+
+// CloseLeave moves a pending leave request to its new status.
+func (c *LeaveClient) CloseLeave(ctx context.Context, leave *model.LeaveRequest) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CloseLeave")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", leave)
+
+	if err := closeLeave(c.db.Debug().WithContext(ctx), leave); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Close Leave")
+
+	return nil
+}
+
+func closeLeave(db *gorm.DB, leave *model.LeaveRequest) error {
+	var args []interface{}
+	args = append(args, leave.Status, leave.Days, leave.DecidedBy, leave.DecidedAt, leave.DecisionNote, leave.ID, model.LeavePending)
+
+	query := "UPDATE leave_requests SET status = ?, days = ?, decided_by = ?, decided_at = ?, decision_note = ? WHERE id = ? AND status = ?"
+	result := db.Exec(query, args...)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ThrowError(http.StatusBadRequest, errors.New("leave request is no longer pending"))
+	}
+
+	return nil
+}
+
+// CreateLeaveBalance opens a balance, leaving an existing one for the same
+// user, year and type untouched.
+func (c *LeaveClient) CreateLeaveBalance(ctx context.Context, balance *model.LeaveBalance) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateLeaveBalance")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", balance)
+
+	var args []interface{}
+	args = append(args, balance.Username, balance.Year, balance.Type, balance.Quota, balance.Used)
+
+	query := "INSERT IGNORE INTO leave_balances (username, year, leave_type, quota, used) VALUES (?, ?, ?, ?, ?)"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *LeaveClient) GetLeaveBalances(ctx context.Context, username string, year int) ([]*model.LeaveBalance, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetLeaveBalances")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", fmt.Sprintf("%s %d", username, year))
+
+	var response []*model.LeaveBalance
+
+	query := "SELECT username, year, leave_type, quota, used, quota - used AS remaining FROM leave_balances WHERE username = ? AND year = ? ORDER BY leave_type"
+	err := c.db.Debug().WithContext(ctx).Raw(query, username, year).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+// leaveYear returns the year a leave's days count towards.
+func leaveYear(leave *model.LeaveRequest) (int, error) {
+	start, err := time.Parse("2006-01-02", leave.StartDate)
+	if err != nil {
+		return 0, err
+	}
+
+	return start.Year(), nil
+}
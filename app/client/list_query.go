@@ -0,0 +1,314 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// listSpec whitelists what a list can be sorted and filtered by. Fields are
+// the JSON names of the listed rows, mapped to the SQL expressions they come
+// from; requests naming any other field are rejected, so no user input ever
+// reaches the SQL text. A filter expression containing ? placeholders is a
+// whole condition, each placeholder taking the filter value.
+type listSpec struct {
+	sorts   map[string]string
+	filters map[string]string
+	// dateColumn is the SQL expression From and To apply to, if any.
+	dateColumn string
+	// defaultSort is used when no sort is requested.
+	defaultSort string
+	// key is a sortable field unique per row. It breaks ties so pages and
+	// cursors are stable.
+	key string
+}
+
+// listQuery is a ListQuery checked against a listSpec, ready to be added to a
+// SELECT and its COUNT.
+type listQuery struct {
+	spec       *listSpec
+	query      *model.ListQuery
+	conditions []string
+	args       []interface{}
+	sortField  string
+	descending bool
+	cursor     *listCursor
+	size       int
+	rank       string
+	rankArgs   []interface{}
+}
+
+// listCursor is the position after the last row of a page: its sort and key
+// values.
+type listCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	Key   string `json:"k"`
+}
+
+func newListQuery(spec *listSpec, query *model.ListQuery) (*listQuery, error) {
+	if query == nil {
+		query = &model.ListQuery{}
+	}
+
+	l := &listQuery{spec: spec, query: query}
+
+	sort := query.Sort
+	if sort == "" {
+		sort = spec.defaultSort
+	}
+
+	l.descending = strings.HasPrefix(sort, "-")
+	l.sortField = strings.TrimPrefix(sort, "-")
+	if _, ok := spec.sorts[l.sortField]; !ok {
+		return nil, model.ThrowError(http.StatusBadRequest, fmt.Errorf("can't sort by %q", l.sortField))
+	}
+
+	for field, value := range query.Filters {
+		column, ok := spec.filters[field]
+		if !ok {
+			return nil, model.ThrowError(http.StatusBadRequest, fmt.Errorf("can't filter by %q", field))
+		}
+
+		if !strings.Contains(column, "?") {
+			l.where(column+" = ?", value)
+			continue
+		}
+
+		args := make([]interface{}, strings.Count(column, "?"))
+		for i := range args {
+			args[i] = value
+		}
+		l.where(column, args...)
+	}
+
+	if query.From != "" || query.To != "" {
+		if spec.dateColumn == "" {
+			return nil, model.ThrowError(http.StatusBadRequest, errors.New("this list can't be filtered by date"))
+		}
+
+		if err := l.dateRange(query.From, query.To); err != nil {
+			return nil, err
+		}
+	}
+
+	l.size = query.Size
+	if l.size <= 0 {
+		l.size = model.DefaultPageSize
+	}
+	if l.size > model.MaxPageSize {
+		l.size = model.MaxPageSize
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil || cursor.Sort != sort {
+			return nil, model.ThrowError(http.StatusBadRequest, errors.New("invalid cursor, it must come from a page with the same sort"))
+		}
+
+		l.cursor = cursor
+	}
+
+	return l, nil
+}
+
+func (l *listQuery) dateRange(from string, to string) error {
+	if from != "" {
+		if _, err := time.Parse("2006-01-02", from); err != nil {
+			return model.ThrowError(http.StatusBadRequest, errors.New("from must be formatted as YYYY-MM-DD"))
+		}
+
+		l.where(l.spec.dateColumn+" >= ?", from)
+	}
+
+	if to != "" {
+		day, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return model.ThrowError(http.StatusBadRequest, errors.New("to must be formatted as YYYY-MM-DD"))
+		}
+
+		l.where(l.spec.dateColumn+" < ?", day.AddDate(0, 0, 1).Format("2006-01-02"))
+	}
+
+	return nil
+}
+
+// where adds a condition, e.g. a data scope, to the query and its count.
+func (l *listQuery) where(condition string, args ...interface{}) {
+	if condition == "" {
+		return
+	}
+
+	l.conditions = append(l.conditions, condition)
+	l.args = append(l.args, args...)
+}
+
+// rankBy orders the list by a relevance expression, highest first, before its
+// sort. Ranked lists are paged by page only: their rows carry no rank to
+// build a cursor from.
+func (l *listQuery) rankBy(expression string, args ...interface{}) {
+	l.rank = expression
+	l.rankArgs = args
+}
+
+// countSQL returns the WHERE clause of the query's COUNT, with its args.
+func (l *listQuery) countSQL() (string, []interface{}) {
+	if len(l.conditions) == 0 {
+		return "", l.args
+	}
+
+	return " WHERE " + strings.Join(l.conditions, " AND "), l.args
+}
+
+// pageSQL returns the WHERE, ORDER BY and LIMIT clauses selecting the page,
+// with their args. One row more than the page size is selected to tell
+// whether there is a next page.
+func (l *listQuery) pageSQL() (string, []interface{}) {
+	conditions := l.conditions
+	args := append([]interface{}{}, l.args...)
+
+	sortColumn := l.spec.sorts[l.sortField]
+	keyColumn := l.spec.sorts[l.spec.key]
+
+	direction, operator := "ASC", ">"
+	if l.descending {
+		direction, operator = "DESC", "<"
+	}
+
+	if l.cursor != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", sortColumn, operator, sortColumn, keyColumn, operator))
+		args = append(args, l.cursor.Value, l.cursor.Value, l.cursor.Key)
+	}
+
+	sb := strings.Builder{}
+	if len(conditions) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	}
+
+	sb.WriteString(" ORDER BY ")
+	if l.rank != "" {
+		sb.WriteString(l.rank + " DESC, ")
+		args = append(args, l.rankArgs...)
+	}
+
+	sb.WriteString(fmt.Sprintf("%s %s", sortColumn, direction))
+	if l.sortField != l.spec.key {
+		sb.WriteString(fmt.Sprintf(", %s %s", keyColumn, direction))
+	}
+
+	if l.query.Unpaged {
+		return sb.String(), args
+	}
+
+	sb.WriteString(" LIMIT ?")
+	args = append(args, l.size+1)
+
+	if l.cursor == nil && l.query.Page > 1 {
+		sb.WriteString(" OFFSET ?")
+		args = append(args, (l.query.Page-1)*l.size)
+	}
+
+	return sb.String(), args
+}
+
+// page trims the extra row pageSQL selected from rows, a pointer to the
+// scanned slice, and returns the page with the cursor following its last row.
+func (l *listQuery) page(total int64, rows interface{}) *model.ListPage {
+	page := &model.ListPage{Total: total}
+
+	slice := reflect.ValueOf(rows).Elem()
+	if l.query.Unpaged || slice.Len() <= l.size {
+		return page
+	}
+
+	slice.Set(slice.Slice(0, l.size))
+	if l.rank != "" {
+		return page
+	}
+
+	last := slice.Index(l.size - 1)
+
+	sort := l.sortField
+	if l.descending {
+		sort = "-" + sort
+	}
+
+	page.NextCursor = encodeCursor(&listCursor{
+		Sort:  sort,
+		Value: fieldText(last, l.sortField),
+		Key:   fieldText(last, l.spec.key),
+	})
+
+	return page
+}
+
+// fieldText returns the value of the field of row with the given JSON name as
+// it compares in SQL.
+func fieldText(row reflect.Value, name string) string {
+	for row.Kind() == reflect.Ptr {
+		if row.IsNil() {
+			return ""
+		}
+		row = row.Elem()
+	}
+
+	if row.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for i := 0; i < row.NumField(); i++ {
+		field := row.Type().Field(i)
+
+		if field.Anonymous {
+			if text := fieldText(row.Field(i), name); text != "" {
+				return text
+			}
+			continue
+		}
+
+		if strings.Split(field.Tag.Get("json"), ",")[0] != name {
+			continue
+		}
+
+		value := row.Field(i)
+		for value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return ""
+			}
+			value = value.Elem()
+		}
+
+		if t, ok := value.Interface().(time.Time); ok {
+			return t.Format("2006-01-02 15:04:05.999999")
+		}
+
+		return fmt.Sprint(value.Interface())
+	}
+
+	return ""
+}
+
+func encodeCursor(cursor *listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testListSpec = &listSpec{
+	sorts: map[string]string{
+		"username":   "u.username",
+		"created_at": "u.created_at",
+	},
+	filters: map[string]string{
+		"role_id": "u.role_id",
+		"name":    "(u.fullname LIKE ? OR u.shortname LIKE ?)",
+	},
+	dateColumn:  "u.created_at",
+	defaultSort: "-created_at",
+	key:         "username",
+}
+
+func TestNewListQueryRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  *listSpec
+		query *model.ListQuery
+	}{
+		{name: "unknown sort", spec: testListSpec, query: &model.ListQuery{Sort: "password"}},
+		{name: "unknown descending sort", spec: testListSpec, query: &model.ListQuery{Sort: "-password"}},
+		{name: "unknown filter", spec: testListSpec, query: &model.ListQuery{Filters: map[string]string{"password": "x"}}},
+		{name: "from not a date", spec: testListSpec, query: &model.ListQuery{From: "01-02-2024"}},
+		{name: "to not a date", spec: testListSpec, query: &model.ListQuery{To: "2024-13-01"}},
+		{name: "date range without a date column", spec: &listSpec{sorts: testListSpec.sorts, defaultSort: "username", key: "username"}, query: &model.ListQuery{From: "2024-01-01"}},
+		{name: "cursor not base64", spec: testListSpec, query: &model.ListQuery{Cursor: "not a cursor"}},
+		{name: "cursor of another sort", spec: testListSpec, query: &model.ListQuery{Sort: "username", Cursor: encodeCursor(&listCursor{Sort: "-created_at", Value: "2024-01-01", Key: "a"})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newListQuery(tt.spec, tt.query)
+
+			var errResponse *model.ErrorResponse
+			if !errors.As(err, &errResponse) || errResponse.Code != http.StatusBadRequest {
+				t.Fatalf("newListQuery() error = %v, want a 400", err)
+			}
+		})
+	}
+}
+
+func TestListQueryPageSQL(t *testing.T) {
+	cursor := encodeCursor(&listCursor{Sort: "-created_at", Value: "2024-01-02 03:04:05", Key: "budi"})
+
+	tests := []struct {
+		name  string
+		query *model.ListQuery
+		sql   string
+		args  []interface{}
+	}{
+		{
+			name:  "nil query uses the defaults",
+			query: nil,
+			sql:   " ORDER BY u.created_at DESC, u.username DESC LIMIT ?",
+			args:  []interface{}{model.DefaultPageSize + 1},
+		},
+		{
+			name:  "sort by the key has no tie-breaker",
+			query: &model.ListQuery{Sort: "username", Size: 10},
+			sql:   " ORDER BY u.username ASC LIMIT ?",
+			args:  []interface{}{11},
+		},
+		{
+			name:  "size is capped",
+			query: &model.ListQuery{Sort: "username", Size: model.MaxPageSize + 1},
+			sql:   " ORDER BY u.username ASC LIMIT ?",
+			args:  []interface{}{model.MaxPageSize + 1},
+		},
+		{
+			name:  "page is an offset",
+			query: &model.ListQuery{Sort: "username", Size: 10, Page: 3},
+			sql:   " ORDER BY u.username ASC LIMIT ? OFFSET ?",
+			args:  []interface{}{11, 20},
+		},
+		{
+			name:  "plain filter",
+			query: &model.ListQuery{Sort: "username", Filters: map[string]string{"role_id": "admin"}},
+			sql:   " WHERE u.role_id = ? ORDER BY u.username ASC LIMIT ?",
+			args:  []interface{}{"admin", model.DefaultPageSize + 1},
+		},
+		{
+			name:  "filter expression takes the value per placeholder",
+			query: &model.ListQuery{Sort: "username", Filters: map[string]string{"name": "%budi%"}},
+			sql:   " WHERE (u.fullname LIKE ? OR u.shortname LIKE ?) ORDER BY u.username ASC LIMIT ?",
+			args:  []interface{}{"%budi%", "%budi%", model.DefaultPageSize + 1},
+		},
+		{
+			name:  "date range includes the to day",
+			query: &model.ListQuery{Sort: "username", From: "2024-01-01", To: "2024-01-31"},
+			sql:   " WHERE u.created_at >= ? AND u.created_at < ? ORDER BY u.username ASC LIMIT ?",
+			args:  []interface{}{"2024-01-01", "2024-02-01", model.DefaultPageSize + 1},
+		},
+		{
+			name:  "cursor continues after the last row and ignores the page",
+			query: &model.ListQuery{Cursor: cursor, Page: 3, Size: 5},
+			sql:   " WHERE (u.created_at < ? OR (u.created_at = ? AND u.username < ?)) ORDER BY u.created_at DESC, u.username DESC LIMIT ?",
+			args:  []interface{}{"2024-01-02 03:04:05", "2024-01-02 03:04:05", "budi", 6},
+		},
+		{
+			name:  "unpaged has no limit",
+			query: &model.ListQuery{Sort: "username", Page: 2, Unpaged: true},
+			sql:   " ORDER BY u.username ASC",
+			args:  []interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := newListQuery(testListSpec, tt.query)
+			if err != nil {
+				t.Fatalf("newListQuery() error = %v", err)
+			}
+
+			sql, args := list.pageSQL()
+			if sql != tt.sql {
+				t.Errorf("pageSQL() sql = %q, want %q", sql, tt.sql)
+			}
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("pageSQL() args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestListQueryPageSQLScopeAndRank(t *testing.T) {
+	list, err := newListQuery(testListSpec, &model.ListQuery{Sort: "username", Size: 10, Filters: map[string]string{"role_id": "admin"}})
+	if err != nil {
+		t.Fatalf("newListQuery() error = %v", err)
+	}
+
+	list.where("u.institution_id = ?", "inst-1")
+	list.rankBy("MATCH(u.fullname) AGAINST (?)", "budi")
+
+	sql, args := list.pageSQL()
+	wantSQL := " WHERE u.role_id = ? AND u.institution_id = ? ORDER BY MATCH(u.fullname) AGAINST (?) DESC, u.username ASC LIMIT ?"
+	if sql != wantSQL {
+		t.Errorf("pageSQL() sql = %q, want %q", sql, wantSQL)
+	}
+
+	wantArgs := []interface{}{"admin", "inst-1", "budi", 11}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("pageSQL() args = %v, want %v", args, wantArgs)
+	}
+
+	count, countArgs := list.countSQL()
+	if count != " WHERE u.role_id = ? AND u.institution_id = ?" || !reflect.DeepEqual(countArgs, []interface{}{"admin", "inst-1"}) {
+		t.Errorf("countSQL() = %q %v, want the conditions without paging", count, countArgs)
+	}
+}
+
+func TestListQueryPage(t *testing.T) {
+	type row struct {
+		Username  string    `json:"username"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	created := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+	rows := []*row{
+		{Username: "ani", CreatedAt: created.Add(time.Hour)},
+		{Username: "budi", CreatedAt: created},
+		{Username: "cici", CreatedAt: created.Add(-time.Hour)},
+	}
+
+	list, err := newListQuery(testListSpec, &model.ListQuery{Size: 2})
+	if err != nil {
+		t.Fatalf("newListQuery() error = %v", err)
+	}
+
+	page := list.page(3, &rows)
+	if len(rows) != 2 {
+		t.Fatalf("page() left %d rows, want 2", len(rows))
+	}
+
+	if page.Total != 3 || page.NextCursor == "" {
+		t.Fatalf("page() = %+v, want a total of 3 and a next cursor", page)
+	}
+
+	next, err := newListQuery(testListSpec, &model.ListQuery{Size: 2, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("newListQuery() with the next cursor error = %v", err)
+	}
+
+	want := &listCursor{Sort: "-created_at", Value: "2024-01-02 03:04:05", Key: "budi"}
+	if !reflect.DeepEqual(next.cursor, want) {
+		t.Errorf("next cursor = %+v, want %+v", next.cursor, want)
+	}
+
+	last := rows[:1]
+	if page := list.page(1, &last); page.NextCursor != "" {
+		t.Errorf("page() of the last page has next cursor %q", page.NextCursor)
+	}
+}
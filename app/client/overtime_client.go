@@ -0,0 +1,198 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+type InterfaceOvertimeClient interface {
+	GetAllOvertimes(ctx context.Context, scope *model.DataScope, status string) ([]*model.OvertimeRequest, error)
+	GetOvertimeByID(ctx context.Context, id string) (*model.OvertimeRequest, error)
+	CreateNewOvertime(ctx context.Context, overtime *model.OvertimeRequest) error
+	HasOpenOvertime(ctx context.Context, username string, workDate string) (bool, error)
+	CloseOvertime(ctx context.Context, overtime *model.OvertimeRequest) error
+
+	GetOvertimeTotals(ctx context.Context, scope *model.DataScope, from string, to string) ([]*model.OvertimeTotal, error)
+}
+
+type OvertimeClient struct {
+	db *gorm.DB
+}
+
+func NewOvertimeClient(db *gorm.DB) *OvertimeClient {
+	return &OvertimeClient{db: db}
+}
+
+const overtimeColumns = "o.id, o.username, u.fullname, u.institution_id, DATE_FORMAT(o.work_date, '%Y-%m-%d') AS work_date, o.day_type, o.minutes, o.reason, o.status, COALESCE(o.decided_by, '') AS decided_by, o.decided_at, COALESCE(o.decision_note, '') AS decision_note, o.created_at"
+
+// GetAllOvertimes lists the overtime requests within scope, newest first,
+// optionally only those in one status.
+func (c *OvertimeClient) GetAllOvertimes(ctx context.Context, scope *model.DataScope, status string) ([]*model.OvertimeRequest, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllOvertimes")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", status)
+
+	var response []*model.OvertimeRequest
+
+	condition, args := scopeCondition(scope, "o.username", "u.institution_id")
+	if status != "" {
+		if condition != "" {
+			condition += " AND "
+		}
+		condition += "o.status = ?"
+		args = append(args, status)
+	}
+
+	query := "SELECT " + overtimeColumns + " FROM overtime_requests AS o INNER JOIN users AS u ON o.username = u.username"
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY o.created_at DESC"
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+func (c *OvertimeClient) GetOvertimeByID(ctx context.Context, id string) (*model.OvertimeRequest, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetOvertimeByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	var response model.OvertimeRequest
+
+	query := "SELECT " + overtimeColumns + " FROM overtime_requests AS o INNER JOIN users AS u ON o.username = u.username WHERE o.id = ?"
+	result := c.db.Debug().WithContext(ctx).Raw(query, id).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("overtime request not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("overtime request not found"))
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+func (c *OvertimeClient) CreateNewOvertime(ctx context.Context, overtime *model.OvertimeRequest) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateNewOvertime")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", overtime)
+
+	var args []interface{}
+	args = append(args, overtime.ID, overtime.Username, overtime.WorkDate, overtime.DayType, overtime.Minutes, overtime.Reason, overtime.Status, overtime.CreatedAt)
+
+	query := "INSERT INTO overtime_requests (id, username, work_date, day_type, minutes, reason, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create New Overtime")
+
+	return nil
+}
+
+// HasOpenOvertime reports whether the user already has a pending or confirmed
+// overtime request for the work date.
+func (c *OvertimeClient) HasOpenOvertime(ctx context.Context, username string, workDate string) (bool, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: HasOpenOvertime")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username+" "+workDate)
+
+	var count int64
+
+	query := "SELECT COUNT(1) FROM overtime_requests WHERE username = ? AND work_date = ? AND status IN (?, ?)"
+	err := c.db.Debug().WithContext(ctx).Raw(query, username, workDate, model.OvertimePending, model.OvertimeConfirmed).Scan(&count).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// CloseOvertime stores the decision on a pending overtime request.
+func (c *OvertimeClient) CloseOvertime(ctx context.Context, overtime *model.OvertimeRequest) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CloseOvertime")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", overtime)
+
+	var args []interface{}
+	args = append(args, overtime.Status, overtime.DecidedBy, overtime.DecidedAt, overtime.DecisionNote, overtime.ID, model.OvertimePending)
+
+	query := "UPDATE overtime_requests SET status = ?, decided_by = ?, decided_at = ?, decision_note = ? WHERE id = ? AND status = ?"
+	result := c.db.Debug().WithContext(ctx).Exec(query, args...)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("overtime request is no longer pending"))
+		return model.ThrowError(http.StatusBadRequest, errors.New("overtime request is no longer pending"))
+	}
+
+	utils.LogEvent(span, "Response", "Success Close Overtime")
+
+	return nil
+}
+
+// GetOvertimeTotals sums the confirmed overtime of the users within scope
+// between the work dates from and to (inclusive), split by day type.
+func (c *OvertimeClient) GetOvertimeTotals(ctx context.Context, scope *model.DataScope, from string, to string) ([]*model.OvertimeTotal, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetOvertimeTotals")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", from+" "+to)
+
+	var response []*model.OvertimeTotal
+
+	args := []interface{}{model.OvertimeWeekday, model.OvertimeWeekend, model.OvertimeConfirmed, from, to}
+
+	query := "SELECT o.username, u.fullname, u.institution_id, " +
+		"COALESCE(SUM(CASE WHEN o.day_type = ? THEN o.minutes END), 0) AS weekday_minutes, " +
+		"COALESCE(SUM(CASE WHEN o.day_type = ? THEN o.minutes END), 0) AS weekend_minutes, " +
+		"COALESCE(SUM(o.minutes), 0) AS total_minutes " +
+		"FROM overtime_requests AS o INNER JOIN users AS u ON o.username = u.username WHERE o.status = ? AND o.work_date BETWEEN ? AND ?"
+
+	condition, scopeArgs := scopeCondition(scope, "o.username", "u.institution_id")
+	if condition != "" {
+		query += " AND " + condition
+		args = append(args, scopeArgs...)
+	}
+
+	query += " GROUP BY o.username, u.fullname, u.institution_id ORDER BY u.institution_id, u.fullname"
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
@@ -13,7 +13,7 @@ import (
 
 type InterfaceParamClient interface {
 	GetParameterByKey(ctx context.Context, key string) (*model.Param, error)
-	GetAllParam(ctx context.Context) ([]*model.Param, error)
+	GetAllParam(ctx context.Context, query *model.ListQuery) ([]*model.Param, *model.ListPage, error)
 	InsertNewParam(ctx context.Context, param *model.Param) error
 	UpdateParam(ctx context.Context, param *model.Param) error
 	DeleteParam(ctx context.Context, key string) error
@@ -72,23 +72,52 @@ func (c *ParamClient) GetParameterByKey(ctx context.Context, key string) (*model
 	return res, nil
 }
 
-func (c *ParamClient) GetAllParam(ctx context.Context) ([]*model.Param, error) {
-	span, ctx := utils.SpanFromContext(ctx, "Client: GetDatasetList")
+// paramList is what parameter lists can be sorted and filtered by.
+var paramList = &listSpec{
+	sorts: map[string]string{
+		"key":        "id",
+		"updated_at": "updated_at",
+	},
+	filters: map[string]string{
+		"updated_by": "updated_by",
+	},
+	dateColumn:  "updated_at",
+	defaultSort: "key",
+	key:         "key",
+}
+
+func (c *ParamClient) GetAllParam(ctx context.Context, query *model.ListQuery) ([]*model.Param, *model.ListPage, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllParam")
 	defer span.Finish()
 
 	var result []*model.Param
 
-	query := "SELECT * FROM parameter"
-	err := c.db.Debug().WithContext(ctx).Raw(query).Scan(&result).Error
+	list, err := newListQuery(paramList, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	var total int64
 
+	where, args := list.countSQL()
+	err = c.db.Debug().WithContext(ctx).Raw("SELECT COUNT(1) FROM parameter"+where, args...).Scan(&total).Error
 	if err != nil {
 		utils.LogEventError(span, err)
-		return nil, err
+		return nil, nil, err
+	}
+
+	page, args := list.pageSQL()
+	err = c.db.Debug().WithContext(ctx).Raw("SELECT * FROM parameter"+page, args...).Scan(&result).Error
+
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
 	}
 
 	utils.LogEvent(span, "Response", result)
 
-	return result, nil
+	return result, list.page(total, &result), nil
 }
 
 func (c *ParamClient) InsertNewParam(ctx context.Context, param *model.Param) error {
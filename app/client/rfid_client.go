@@ -0,0 +1,253 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+type InterfaceRFIDClient interface {
+	GetAllCards(ctx context.Context, institutionID string) ([]*model.RFIDCard, error)
+	GetActiveCard(ctx context.Context, cardUID string) (*model.RFIDCard, error)
+	AssignCard(ctx context.Context, card *model.RFIDCard) error
+	UpdateCardStatus(ctx context.Context, card *model.RFIDCard) error
+
+	GetAllDevices(ctx context.Context, institutionID string) ([]*model.RFIDDevice, error)
+	GetDeviceByID(ctx context.Context, id string) (*model.RFIDDevice, error)
+	CreateNewDevice(ctx context.Context, device *model.RFIDDevice) error
+	DeactivateDevice(ctx context.Context, id string, institutionID string) error
+}
+
+type RFIDClient struct {
+	db *gorm.DB
+}
+
+func NewRFIDClient(db *gorm.DB) *RFIDClient {
+	return &RFIDClient{db: db}
+}
+
+// GetAllCards lists cards, only those held by institutionID's users when it is
+// set.
+func (c *RFIDClient) GetAllCards(ctx context.Context, institutionID string) ([]*model.RFIDCard, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllCards")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", institutionID)
+
+	var response []*model.RFIDCard
+
+	var args []interface{}
+	query := "SELECT c.*, u.fullname, u.institution_id FROM rfid_cards AS c LEFT JOIN users AS u ON c.username = u.username"
+	if institutionID != "" {
+		query += " WHERE u.institution_id = ?"
+		args = append(args, institutionID)
+	}
+	query += " ORDER BY c.assigned_at DESC"
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+func (c *RFIDClient) GetActiveCard(ctx context.Context, cardUID string) (*model.RFIDCard, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetActiveCard")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", cardUID)
+
+	var response model.RFIDCard
+
+	query := "SELECT c.*, u.institution_id FROM rfid_cards AS c INNER JOIN users AS u ON c.username = u.username WHERE c.card_uid = ? AND c.status = ?"
+	result := c.db.Debug().WithContext(ctx).Raw(query, cardUID, model.RFIDCardActive).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("unknown card"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("unknown card"))
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+func (c *RFIDClient) AssignCard(ctx context.Context, card *model.RFIDCard) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: AssignCard")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", card)
+
+	// A revoked or lost card keeps its row, so re-issuing the same UID
+	// replaces it. An active card is left alone: the row lock makes a
+	// concurrent assignment of the same UID see it active and fall through to
+	// the insert, which then fails on the key.
+	query := "UPDATE rfid_cards SET username = ?, status = ?, remark = ?, assigned_at = ?, assigned_by = ?, revoked_at = NULL, revoked_by = NULL WHERE card_uid = ? AND status <> ?"
+	result := c.db.Debug().WithContext(ctx).Exec(query, card.Username, card.Status, card.Remark, card.AssignedAt, card.AssignedBy, card.CardUID, model.RFIDCardActive)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		query = "INSERT INTO rfid_cards (card_uid, username, status, remark, assigned_at, assigned_by) VALUES (?, ?, ?, ?, ?, ?)"
+		err := c.db.Debug().WithContext(ctx).Exec(query, card.CardUID, card.Username, card.Status, card.Remark, card.AssignedAt, card.AssignedBy).Error
+		if err != nil {
+			if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+				utils.LogEventError(span, errors.New("card is already assigned"))
+				return model.ThrowError(http.StatusConflict, errors.New("card is already assigned"))
+			}
+			utils.LogEventError(span, err)
+			return err
+		}
+	}
+
+	utils.LogEvent(span, "Response", "Success Assign Card")
+
+	return nil
+}
+
+func (c *RFIDClient) UpdateCardStatus(ctx context.Context, card *model.RFIDCard) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: UpdateCardStatus")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", card)
+
+	var args []interface{}
+	args = append(args, card.Status, card.Remark, card.RevokedAt, card.RevokedBy, card.CardUID, model.RFIDCardActive)
+
+	query := "UPDATE rfid_cards SET status = ?, remark = ?, revoked_at = ?, revoked_by = ? WHERE card_uid = ? AND status = ?"
+	result := c.db.Debug().WithContext(ctx).Exec(query, args...)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("active card not found"))
+		return model.ThrowError(http.StatusNotFound, errors.New("active card not found"))
+	}
+
+	utils.LogEvent(span, "Response", "Success Update Card Status")
+
+	return nil
+}
+
+// GetAllDevices lists devices, only institutionID's when it is set.
+func (c *RFIDClient) GetAllDevices(ctx context.Context, institutionID string) ([]*model.RFIDDevice, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllDevices")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", institutionID)
+
+	var response []*model.RFIDDevice
+
+	var args []interface{}
+	query := "SELECT * FROM rfid_devices"
+	if institutionID != "" {
+		query += " WHERE institution_id = ?"
+		args = append(args, institutionID)
+	}
+	query += " ORDER BY created_at DESC"
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+func (c *RFIDClient) GetDeviceByID(ctx context.Context, id string) (*model.RFIDDevice, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetDeviceByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	var response model.RFIDDevice
+
+	query := "SELECT * FROM rfid_devices WHERE id = ? AND is_active = 1"
+	result := c.db.Debug().WithContext(ctx).Raw(query, id).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("unknown device"))
+		return nil, model.ThrowError(http.StatusUnauthorized, errors.New("unknown device"))
+	}
+
+	return &response, nil
+}
+
+func (c *RFIDClient) CreateNewDevice(ctx context.Context, device *model.RFIDDevice) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateNewDevice")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", device)
+
+	var args []interface{}
+	args = append(args, device.ID, device.Name, device.InstitutionID, device.Secret, device.IsActive, device.CreatedAt, device.CreatedBy)
+
+	query := "INSERT INTO rfid_devices (id, name, institution_id, secret, is_active, created_at, created_by) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create New Device")
+
+	return nil
+}
+
+// DeactivateDevice deactivates a device, only one of institutionID when it is
+// set.
+func (c *RFIDClient) DeactivateDevice(ctx context.Context, id string, institutionID string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: DeactivateDevice")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	args := []interface{}{id}
+	query := "UPDATE rfid_devices SET is_active = 0 WHERE id = ?"
+	if institutionID != "" {
+		query += " AND institution_id = ?"
+		args = append(args, institutionID)
+	}
+	result := c.db.Debug().WithContext(ctx).Exec(query, args...)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("device not found"))
+		return model.ThrowError(http.StatusNotFound, errors.New("device not found"))
+	}
+
+	utils.LogEvent(span, "Response", "Success Deactivate Device")
+
+	return nil
+}
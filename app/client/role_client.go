@@ -4,17 +4,23 @@ import (
 	"bpkp-svc-portal/app/model"
 	"bpkp-svc-portal/app/utils"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 type InterfaceRoleClient interface {
 	GetMenuRoleMapping(ctx context.Context, roleID string) ([]*model.MenuRoleMapping, error)
+	GetRoleMenuMapping(ctx context.Context, roleID string) (map[string]string, error)
+	InvalidateMenuMapping(ctx context.Context) error
 	CreateNewRoleMapping(ctx context.Context, role *model.MenuRoleMapping) error
-	GetAllRoleMapping(ctx context.Context) ([]*model.MenuRoleMapping, error)
+	GetAllRoleMapping(ctx context.Context, query *model.ListQuery) ([]*model.MenuRoleMapping, *model.ListPage, error)
 	UpdateRoleMapping(ctx context.Context, req *model.MenuRoleMapping) error
 	DeleteRoleMapping(ctx context.Context, id string) error
 
@@ -30,11 +36,83 @@ type InterfaceRoleClient interface {
 }
 
 type RoleClient struct {
-	db *gorm.DB
+	db    *gorm.DB
+	redis *redis.Client
 }
 
-func NewRoleClient(db *gorm.DB) *RoleClient {
-	return &RoleClient{db: db}
+func NewRoleClient(db *gorm.DB, redis *redis.Client) *RoleClient {
+	return &RoleClient{db: db, redis: redis}
+}
+
+// menuMappingVersionKey counts mapping changes. Cached maps are keyed by the
+// version they were read at, so a change makes every cached map stale at
+// once, including one being filled from a read that raced the change.
+const menuMappingVersionKey = "menu-mapping:version"
+
+// menuMappingCacheTTL bounds how long a cached map is used, should a version
+// bump be missed.
+const menuMappingCacheTTL = 10 * time.Minute
+
+func menuMappingCacheKey(version string, roleID string) string {
+	return fmt.Sprintf("menu-mapping:%s:%s", version, roleID)
+}
+
+// GetRoleMenuMapping returns the current menu -> access method map of a role,
+// served from Redis when cached.
+func (r *RoleClient) GetRoleMenuMapping(ctx context.Context, roleID string) (map[string]string, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetRoleMenuMapping")
+	defer span.Finish()
+
+	version := r.redis.Get(ctx, menuMappingVersionKey).Val()
+	key := menuMappingCacheKey(version, roleID)
+
+	cache := r.redis.Get(ctx, key).Val()
+	if cache != "" {
+		utils.LogEvent(span, "Redis", cache)
+
+		resCache := make(map[string]string)
+		if err := json.Unmarshal([]byte(cache), &resCache); err != nil {
+			utils.LogEventError(span, err)
+		} else {
+			return resCache, nil
+		}
+	}
+
+	mapping, err := r.GetMenuRoleMapping(ctx, roleID)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res := make(map[string]string)
+	for _, v := range mapping {
+		res[v.MenuID] = v.AccessMethod
+	}
+
+	resJSON, err := json.Marshal(res)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return res, nil
+	}
+
+	if err := r.redis.Set(ctx, key, resJSON, menuMappingCacheTTL).Err(); err != nil {
+		utils.LogEventError(span, err)
+	}
+
+	return res, nil
+}
+
+// InvalidateMenuMapping makes every cached menu mapping stale.
+func (r *RoleClient) InvalidateMenuMapping(ctx context.Context) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: InvalidateMenuMapping")
+	defer span.Finish()
+
+	if err := r.redis.Incr(ctx, menuMappingVersionKey).Err(); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
 }
 
 func (r *RoleClient) GetMenuRoleMapping(ctx context.Context, roleID string) ([]*model.MenuRoleMapping, error) {
@@ -87,23 +165,60 @@ func (r *RoleClient) CreateNewRoleMapping(ctx context.Context, req *model.MenuRo
 	return nil
 }
 
-func (r *RoleClient) GetAllRoleMapping(ctx context.Context) ([]*model.MenuRoleMapping, error) {
+// roleMappingList is what menu role mapping lists can be sorted and filtered
+// by.
+var roleMappingList = &listSpec{
+	sorts: map[string]string{
+		"id":         "map.id",
+		"role_name":  "role.role_name",
+		"menu_name":  "menu.menu_name",
+		"created_at": "map.created_at",
+	},
+	filters: map[string]string{
+		"role_id":       "map.role_id",
+		"menu_id":       "map.menu_id",
+		"access_method": "map.access_method",
+	},
+	dateColumn:  "map.created_at",
+	defaultSort: "id",
+	key:         "id",
+}
+
+func (r *RoleClient) GetAllRoleMapping(ctx context.Context, query *model.ListQuery) ([]*model.MenuRoleMapping, *model.ListPage, error) {
 	span, _ := utils.SpanFromContext(ctx, "Client: GetAllRoleMapping")
 	defer span.Finish()
 
 	var response []*model.MenuRoleMapping
 
-	query := "SELECT map.id, map.menu_id, menu.menu_name, role.role_name, map.role_id, menu.menu_route, map.access_method, map.created_at, map.updated_at, map.created_by, map.updated_by FROM menu_mapping AS map JOIN menu ON map.menu_id = menu.id JOIN role ON map.role_id = role.id ORDER BY map.id ASC"
+	list, err := newListQuery(roleMappingList, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
 
-	err := r.db.Debug().Raw(query).Scan(&response).Error
+	from := " FROM menu_mapping AS map JOIN menu ON map.menu_id = menu.id JOIN role ON map.role_id = role.id"
+
+	var total int64
+
+	where, args := list.countSQL()
+	err = r.db.Debug().Raw("SELECT COUNT(1)"+from+where, args...).Scan(&total).Error
 	if err != nil {
 		utils.LogEventError(span, err)
-		return nil, err
+		return nil, nil, err
+	}
+
+	page, args := list.pageSQL()
+	sql := "SELECT map.id, map.menu_id, menu.menu_name, role.role_name, map.role_id, menu.menu_route, map.access_method, map.created_at, map.updated_at, map.created_by, map.updated_by" + from + page
+
+	err = r.db.Debug().Raw(sql, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
 	}
 
 	utils.LogEvent(span, "Response", response)
 
-	return response, nil
+	return response, list.page(total, &response), nil
 }
 
 func (r *RoleClient) GetAllMenu(ctx context.Context) ([]*model.Menu, error) {
@@ -195,8 +310,8 @@ func (r *RoleClient) CreateNewRole(ctx context.Context, req *model.Role) error {
 
 	var args []interface{}
 
-	args = append(args, req.Id, req.RoleName, req.RoleDesc, req.CreatedAt, req.UpdatedAt, req.CreatedBy, req.UpdatedBy, req.IsActive, req.Level)
-	query := "INSERT INTO role (id, role_name, role_desc, created_at, updated_at, created_by, updated_by, is_active, level) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	args = append(args, req.Id, req.RoleName, req.RoleDesc, req.CreatedAt, req.UpdatedAt, req.CreatedBy, req.UpdatedBy, req.IsActive, req.Level, req.Scope)
+	query := "INSERT INTO role (id, role_name, role_desc, created_at, updated_at, created_by, updated_by, is_active, level, scope) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
 	err := r.db.Exec(query, args...).Error
 	if err != nil {
@@ -217,8 +332,8 @@ func (r *RoleClient) UpdateRole(ctx context.Context, req *model.Role) error {
 
 	var args []interface{}
 
-	args = append(args, req.RoleName, req.Level, req.UpdatedAt, req.UpdatedBy, req.Id)
-	query := "UPDATE role SET role_name = ?, level = ?, updated_at = ?, updated_by = ? WHERE id = ?"
+	args = append(args, req.RoleName, req.Level, req.Scope, req.UpdatedAt, req.UpdatedBy, req.Id)
+	query := "UPDATE role SET role_name = ?, level = ?, scope = ?, updated_at = ?, updated_by = ? WHERE id = ?"
 
 	err := r.db.Exec(query, args...).Error
 	if err != nil {
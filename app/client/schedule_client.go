@@ -0,0 +1,357 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+type InterfaceScheduleClient interface {
+	GetAllShifts(ctx context.Context, institutionID string) ([]*model.Shift, error)
+	GetShiftByID(ctx context.Context, id string) (*model.Shift, error)
+	CreateNewShift(ctx context.Context, shift *model.Shift) error
+	UpdateShift(ctx context.Context, shift *model.Shift) error
+	DeleteShift(ctx context.Context, id string) error
+
+	GetAllAssignments(ctx context.Context, institutionID string) ([]*model.ScheduleAssignment, error)
+	CreateNewAssignment(ctx context.Context, assignment *model.ScheduleAssignment) error
+	DeleteAssignment(ctx context.Context, id string) error
+
+	GetActiveShift(ctx context.Context, username string, date string) (*model.Shift, error)
+}
+
+type ScheduleClient struct {
+	db *gorm.DB
+}
+
+func NewScheduleClient(db *gorm.DB) *ScheduleClient {
+	return &ScheduleClient{db: db}
+}
+
+const assignmentColumns = "a.id, a.shift_id, s.name AS shift_name, a.target_type, a.target_id, DATE_FORMAT(a.effective_from, '%Y-%m-%d') AS effective_from, DATE_FORMAT(a.effective_to, '%Y-%m-%d') AS effective_to, a.created_at, a.created_by"
+
+// GetAllShifts lists shared shifts plus, when institutionID is set, only that
+// institution's own shifts.
+func (c *ScheduleClient) GetAllShifts(ctx context.Context, institutionID string) ([]*model.Shift, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllShifts")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", institutionID)
+
+	var response []*model.Shift
+
+	var args []interface{}
+	query := "SELECT * FROM shifts"
+	if institutionID != "" {
+		query += " WHERE institution_id = ? OR institution_id = '' OR institution_id IS NULL"
+		args = append(args, institutionID)
+	}
+	query += " ORDER BY name"
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if err := c.loadShiftDays(ctx, c.db, response...); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+func (c *ScheduleClient) GetShiftByID(ctx context.Context, id string) (*model.Shift, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetShiftByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	var response model.Shift
+
+	query := "SELECT * FROM shifts WHERE id = ?"
+	result := c.db.Debug().WithContext(ctx).Raw(query, id).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("shift not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("shift not found"))
+	}
+
+	if err := c.loadShiftDays(ctx, c.db, &response); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+func (c *ScheduleClient) CreateNewShift(ctx context.Context, shift *model.Shift) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateNewShift")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", shift)
+
+	err := c.db.Debug().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var args []interface{}
+		args = append(args, shift.ID, shift.Name, shift.Description, shift.InstitutionID, shift.GraceMinutes, shift.CheckInWindowMinutes, shift.CreatedAt, shift.CreatedBy, shift.UpdatedAt, shift.UpdatedBy)
+
+		query := "INSERT INTO shifts (id, name, description, institution_id, grace_minutes, checkin_window_minutes, created_at, created_by, updated_at, updated_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		if err := tx.Exec(query, args...).Error; err != nil {
+			return err
+		}
+
+		return insertShiftDays(tx, shift)
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create New Shift")
+
+	return nil
+}
+
+// UpdateShift updates a shift and replaces all of its working days.
+func (c *ScheduleClient) UpdateShift(ctx context.Context, shift *model.Shift) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: UpdateShift")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", shift)
+
+	err := c.db.Debug().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var args []interface{}
+		args = append(args, shift.Name, shift.Description, shift.InstitutionID, shift.GraceMinutes, shift.CheckInWindowMinutes, shift.UpdatedAt, shift.UpdatedBy, shift.ID)
+
+		query := "UPDATE shifts SET name = ?, description = ?, institution_id = ?, grace_minutes = ?, checkin_window_minutes = ?, updated_at = ?, updated_by = ? WHERE id = ?"
+		result := tx.Exec(query, args...)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return model.ThrowError(http.StatusNotFound, errors.New("shift not found"))
+		}
+
+		if err := tx.Exec("DELETE FROM shift_days WHERE shift_id = ?", shift.ID).Error; err != nil {
+			return err
+		}
+
+		return insertShiftDays(tx, shift)
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Update Shift")
+
+	return nil
+}
+
+func (c *ScheduleClient) DeleteShift(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: DeleteShift")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	err := c.db.Debug().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var assigned int64
+		if err := tx.Raw("SELECT COUNT(1) FROM schedule_assignments WHERE shift_id = ?", id).Scan(&assigned).Error; err != nil {
+			return err
+		}
+
+		if assigned > 0 {
+			return model.ThrowError(http.StatusBadRequest, errors.New("shift is still assigned"))
+		}
+
+		if err := tx.Exec("DELETE FROM shift_days WHERE shift_id = ?", id).Error; err != nil {
+			return err
+		}
+
+		result := tx.Exec("DELETE FROM shifts WHERE id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return model.ThrowError(http.StatusNotFound, errors.New("shift not found"))
+		}
+
+		return nil
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Delete Shift")
+
+	return nil
+}
+
+// GetAllAssignments lists schedule assignments. When institutionID is set,
+// only assignments to that institution or its users are returned, along with
+// role assignments, which apply across institutions.
+func (c *ScheduleClient) GetAllAssignments(ctx context.Context, institutionID string) ([]*model.ScheduleAssignment, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllAssignments")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", institutionID)
+
+	var response []*model.ScheduleAssignment
+
+	var args []interface{}
+	query := "SELECT " + assignmentColumns + " FROM schedule_assignments AS a INNER JOIN shifts AS s ON a.shift_id = s.id"
+	if institutionID != "" {
+		query += " WHERE (a.target_type = ? AND a.target_id = ?) OR (a.target_type = ? AND a.target_id IN (SELECT username FROM users WHERE institution_id = ?)) OR a.target_type = ?"
+		args = append(args, model.ScheduleTargetInstitution, institutionID, model.ScheduleTargetUser, institutionID, model.ScheduleTargetRole)
+	}
+	query += " ORDER BY a.effective_from DESC"
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+func (c *ScheduleClient) CreateNewAssignment(ctx context.Context, assignment *model.ScheduleAssignment) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateNewAssignment")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", assignment)
+
+	var args []interface{}
+	args = append(args, assignment.ID, assignment.ShiftID, assignment.TargetType, assignment.TargetID, assignment.EffectiveFrom, assignment.EffectiveTo, assignment.CreatedAt, assignment.CreatedBy)
+
+	query := "INSERT INTO schedule_assignments (id, shift_id, target_type, target_id, effective_from, effective_to, created_at, created_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create New Assignment")
+
+	return nil
+}
+
+func (c *ScheduleClient) DeleteAssignment(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: DeleteAssignment")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	result := c.db.Debug().WithContext(ctx).Exec("DELETE FROM schedule_assignments WHERE id = ?", id)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("assignment not found"))
+		return model.ThrowError(http.StatusNotFound, errors.New("assignment not found"))
+	}
+
+	utils.LogEvent(span, "Response", "Success Delete Assignment")
+
+	return nil
+}
+
+// GetActiveShift returns the shift in effect for a user on a date (YYYY-MM-DD),
+// preferring a user assignment over a role one over an institution one, and
+// the most recent assignment within each. It returns nil if none applies.
+func (c *ScheduleClient) GetActiveShift(ctx context.Context, username string, date string) (*model.Shift, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetActiveShift")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username+" "+date)
+
+	var response model.Shift
+
+	var args []interface{}
+	args = append(args, username, date, date, model.ScheduleTargetUser, model.ScheduleTargetRole, model.ScheduleTargetInstitution, model.ScheduleTargetUser, model.ScheduleTargetRole, model.ScheduleTargetInstitution)
+
+	query := "SELECT s.* FROM schedule_assignments AS a INNER JOIN shifts AS s ON a.shift_id = s.id INNER JOIN users AS u ON u.username = ? WHERE a.effective_from <= ? AND (a.effective_to IS NULL OR a.effective_to >= ?) AND ((a.target_type = ? AND a.target_id = u.username) OR (a.target_type = ? AND (a.target_id = u.role_id OR a.target_id IN (SELECT role_id FROM user_roles WHERE username = u.username))) OR (a.target_type = ? AND a.target_id = u.institution_id)) ORDER BY FIELD(a.target_type, ?, ?, ?), a.effective_from DESC LIMIT 1"
+	result := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEvent(span, "Response", "No Active Shift")
+		return nil, nil
+	}
+
+	if err := c.loadShiftDays(ctx, c.db, &response); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+func (c *ScheduleClient) loadShiftDays(ctx context.Context, db *gorm.DB, shifts ...*model.Shift) error {
+	if len(shifts) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(shifts))
+	for _, v := range shifts {
+		ids = append(ids, v.ID)
+	}
+
+	var days []*model.ShiftDay
+	err := db.Debug().WithContext(ctx).Raw("SELECT * FROM shift_days WHERE shift_id IN ? ORDER BY weekday", ids).Scan(&days).Error
+	if err != nil {
+		return err
+	}
+
+	byShift := make(map[string][]*model.ShiftDay)
+	for _, v := range days {
+		byShift[v.ShiftID] = append(byShift[v.ShiftID], v)
+	}
+
+	for _, v := range shifts {
+		v.Days = byShift[v.ID]
+	}
+
+	return nil
+}
+
+func insertShiftDays(tx *gorm.DB, shift *model.Shift) error {
+	for _, day := range shift.Days {
+		var args []interface{}
+		args = append(args, shift.ID, day.Weekday, day.StartTime, day.EndTime)
+
+		query := "INSERT INTO shift_days (shift_id, weekday, start_time, end_time) VALUES (?, ?, ?, ?)"
+		if err := tx.Exec(query, args...).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
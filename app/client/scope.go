@@ -0,0 +1,16 @@
+package client
+
+import "bpkp-svc-portal/app/model"
+
+// scopeCondition turns a data scope into a SQL condition on the given owner
+// columns. It returns an empty condition when the scope spans every record.
+func scopeCondition(scope *model.DataScope, usernameColumn string, institutionColumn string) (string, []interface{}) {
+	switch scope.Scope {
+	case model.ScopeAll:
+		return "", nil
+	case model.ScopeInstitution:
+		return institutionColumn + " = ?", []interface{}{scope.InstitutionID}
+	default:
+		return usernameColumn + " = ?", []interface{}{scope.Username}
+	}
+}
@@ -21,10 +21,13 @@ type InterfaceStorageClient interface {
 	UploadFile(ctx context.Context, req *model.File, bucket string, path string) (string, error)
 	StoreFileData(ctx context.Context, url string, username string) error
 
+	InsertDatasetDB(ctx context.Context, dataset *model.Dataset) error
 	DeleteDatasetDB(ctx context.Context, tx *gorm.DB, username string) error
 	DeleteObject(ctx context.Context, bucket string, prefix string) error
 
+	GetAllDatasets(ctx context.Context, scope *model.DataScope) ([]*model.Dataset, error)
 	GetDatasetsByUsername(ctx context.Context, bucket string, username string) ([]string, error)
+	PresignURL(ctx context.Context, bucket string, key string, expiry time.Duration) (string, error)
 }
 
 type StorageClient struct {
@@ -69,7 +72,7 @@ func (c *StorageClient) DeleteObject(ctx context.Context, bucket string, prefix
 		Prefix: aws.String(prefix),
 	}
 
-	logrus.Printf("Deleting objects under prefix %s\n", bucket, prefix)
+	logrus.Printf("Deleting objects in bucket %s under prefix %s\n", bucket, prefix)
 
 	for {
 		// Get a batch of objects
@@ -199,3 +202,75 @@ func (c *StorageClient) GetDatasetsByUsername(ctx context.Context, bucket string
 
 	return res, nil
 }
+
+// PresignURL returns a download link for an object that is valid for expiry.
+func (c *StorageClient) PresignURL(ctx context.Context, bucket string, key string, expiry time.Duration) (string, error) {
+	span, _ := utils.SpanFromContext(ctx, "Client: PresignURL")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", bucket+"/"+key)
+
+	req, _ := c.s3.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+
+	urlStr, err := req.Presign(expiry)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return "", err
+	}
+
+	return urlStr, nil
+}
+
+func (c *StorageClient) InsertDatasetDB(ctx context.Context, dataset *model.Dataset) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: InsertDatasetDB")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", dataset)
+
+	var args []interface{}
+	args = append(args, dataset.ID, dataset.Username, dataset.Bucket, dataset.Dataset, dataset.CreatedAt, dataset.Username)
+
+	query := "INSERT INTO face_datasets (id, username, bucket, dataset, created_at) SELECT ?, ?, ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM face_datasets WHERE username = ?)"
+	result := c.db.Debug().WithContext(ctx).Exec(query, args...)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return result.Error
+	}
+
+	utils.LogEvent(span, "Response", fmt.Sprintf("inserted %d rows", result.RowsAffected))
+
+	return nil
+}
+
+// GetAllDatasets lists the datasets of the users within scope.
+func (c *StorageClient) GetAllDatasets(ctx context.Context, scope *model.DataScope) ([]*model.Dataset, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllDatasets")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", scope)
+
+	var response []*model.Dataset
+
+	query := "SELECT d.* FROM face_datasets AS d INNER JOIN users AS u ON d.username = u.username"
+	condition, args := scopeCondition(scope, "d.username", "u.institution_id")
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+	query += " ORDER BY d.created_at DESC"
+
+	utils.LogEvent(span, "Query", query)
+
+	err := c.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
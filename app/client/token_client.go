@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type InterfaceTokenClient interface {
+	StoreRefreshToken(ctx context.Context, family string, jti string, expiresAt time.Time) error
+	RotateRefreshToken(ctx context.Context, family string, current string, next string, expiresAt time.Time) error
+	RevokeRefreshFamily(ctx context.Context, family string) error
+	DenyAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	ClaimDeviceSignature(ctx context.Context, deviceID string, signature string, ttl time.Duration) (bool, error)
+}
+
+type TokenClient struct {
+	redis *redis.Client
+}
+
+func NewTokenClient(redis *redis.Client) *TokenClient {
+	return &TokenClient{redis: redis}
+}
+
+func refreshFamilyKey(family string) string {
+	return fmt.Sprintf("refresh:%s", family)
+}
+
+// StoreRefreshToken records jti as the only valid refresh token of its family.
+func (c *TokenClient) StoreRefreshToken(ctx context.Context, family string, jti string, expiresAt time.Time) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: StoreRefreshToken")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", family)
+
+	err := c.redis.Set(ctx, refreshFamilyKey(family), jti, time.Until(expiresAt)).Err()
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// rotateRefreshScript replaces the valid refresh token of a family (KEYS[1])
+// if it is still ARGV[1]. A different one means ARGV[1] was rotated out
+// already and is being reused, so the family is revoked. It returns 1 once
+// rotated, 0 on reuse and -1 when the family is revoked.
+var rotateRefreshScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return -1
+end
+if current ~= ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
+// RotateRefreshToken atomically makes next the only valid refresh token of
+// its family, provided current still is. Presenting a rotated-out token means
+// it leaked, so its whole family is revoked and its holder has to log in
+// again.
+func (c *TokenClient) RotateRefreshToken(ctx context.Context, family string, current string, next string, expiresAt time.Time) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: RotateRefreshToken")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", family)
+
+	result, err := rotateRefreshScript.Run(ctx, c.redis, []string{refreshFamilyKey(family)}, current, next, time.Until(expiresAt).Milliseconds()).Int()
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	switch result {
+	case 1:
+		return nil
+	case 0:
+		utils.LogEventError(span, errors.New("refresh token reuse detected"))
+	default:
+		utils.LogEventError(span, errors.New("refresh token has been revoked"))
+	}
+
+	return model.ThrowError(http.StatusUnauthorized, errors.New("refresh token has been revoked"))
+}
+
+func (c *TokenClient) RevokeRefreshFamily(ctx context.Context, family string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: RevokeRefreshFamily")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", family)
+
+	err := c.redis.Del(ctx, refreshFamilyKey(family)).Err()
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// DenyAccessToken keeps jti on the denylist until the token would have expired anyway.
+func (c *TokenClient) DenyAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: DenyAccessToken")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", jti)
+
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	err := c.redis.Set(ctx, utils.DenylistKey(jti), "1", ttl).Err()
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimDeviceSignature records a signature a device signed a request with for
+// ttl. It reports false when the signature was already used, i.e. the request
+// is replayed.
+func (c *TokenClient) ClaimDeviceSignature(ctx context.Context, deviceID string, signature string, ttl time.Duration) (bool, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: ClaimDeviceSignature")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", deviceID)
+
+	claimed, err := c.redis.SetNX(ctx, fmt.Sprintf("device-signature:%s:%s", deviceID, signature), "1", ttl).Result()
+	if err != nil {
+		utils.LogEventError(span, err)
+		return false, err
+	}
+
+	return claimed, nil
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/config"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type InterfaceTrainingClient interface {
+	GetModelTrainings(ctx context.Context, filter *model.FilterModelTraining) ([]*model.ModelTraining, error)
+	GetModelTrainingByID(ctx context.Context, id string) (*model.ModelTraining, error)
+	CreateModelTraining(ctx context.Context, request *model.ModelTraining) error
+	UpdateModelTrainingStatus(ctx context.Context, id string, status string) error
+	ActivateModelTraining(ctx context.Context, request *model.ModelTraining) error
+	RequestTrainModel(ctx context.Context, request *model.RequestAPITrainModel) (*model.ResponseAPITrainModel, error)
+	RequestRecognize(ctx context.Context, request *model.RequestAPIRecognize) (*model.ResponseAPIRecognize, error)
+}
+
+type TrainingClient struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+func NewTrainingClient(db *gorm.DB, cfg *config.Config) *TrainingClient {
+	return &TrainingClient{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+var modelTrainingOrderBy = map[string]string{
+	"created_at": "created_at",
+	"status":     "status",
+	"is_used":    "is_used",
+}
+
+func (c *TrainingClient) GetModelTrainings(ctx context.Context, filter *model.FilterModelTraining) ([]*model.ModelTraining, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetModelTrainings")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", filter)
+
+	var response []*model.ModelTraining
+	var conditions []string
+	var args []interface{}
+
+	if filter.InstitutionID != "" {
+		conditions = append(conditions, "institution_id = ?")
+		args = append(args, filter.InstitutionID)
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if filter.IsUsed != "" {
+		conditions = append(conditions, "is_used = ?")
+		args = append(args, filter.IsUsed)
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("SELECT * FROM model_training")
+
+	if len(conditions) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	}
+
+	orderBy, ok := modelTrainingOrderBy[filter.OrderBy]
+	if !ok {
+		orderBy = "created_at"
+	}
+
+	sortType := "DESC"
+	if strings.EqualFold(filter.SortType, "asc") {
+		sortType = "ASC"
+	}
+
+	sb.WriteString(fmt.Sprintf(" ORDER BY %s %s", orderBy, sortType))
+
+	utils.LogEvent(span, "Query", sb.String())
+
+	err := c.db.Debug().WithContext(ctx).Raw(sb.String(), args...).Scan(&response).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+func (c *TrainingClient) GetModelTrainingByID(ctx context.Context, id string) (*model.ModelTraining, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetModelTrainingByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	var response model.ModelTraining
+
+	query := "SELECT * FROM model_training WHERE id = ?"
+	result := c.db.Debug().WithContext(ctx).Raw(query, id).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("model training not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("model training not found"))
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+func (c *TrainingClient) CreateModelTraining(ctx context.Context, request *model.ModelTraining) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateModelTraining")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	var args []interface{}
+	args = append(args, request.ID, request.InstitutionID, request.Status, request.IsUsed, request.CreatedAt, request.CreatedBy)
+
+	query := "INSERT INTO model_training (id, institution_id, status, is_used, created_at, created_by) VALUES (?, ?, ?, ?, ?, ?)"
+	err := c.db.Debug().WithContext(ctx).Exec(query, args...).Error
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create Model Training")
+
+	return nil
+}
+
+// UpdateModelTrainingStatus moves a training to status, unless it has since
+// reached a status it can't move there from.
+func (c *TrainingClient) UpdateModelTrainingStatus(ctx context.Context, id string, status string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: UpdateModelTrainingStatus")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", fmt.Sprintf("%s: %s", id, status))
+
+	from := model.TrainingStatusesBefore(status)
+	if len(from) == 0 {
+		return model.ThrowError(http.StatusBadRequest, errors.New("invalid training status"))
+	}
+
+	query := "UPDATE model_training SET status = ? WHERE id = ? AND status IN ?"
+	result := c.db.Debug().WithContext(ctx).Exec(query, status, id, from)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("model training not found or can't move to "+status))
+		return model.ThrowError(http.StatusConflict, errors.New("model training not found or can't move to "+status))
+	}
+
+	utils.LogEvent(span, "Response", "Success Update Model Training Status")
+
+	return nil
+}
+
+func (c *TrainingClient) ActivateModelTraining(ctx context.Context, request *model.ModelTraining) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: ActivateModelTraining")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	err := c.db.Debug().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("UPDATE model_training SET is_used = '0' WHERE institution_id = ?", request.InstitutionID).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec("UPDATE model_training SET is_used = '1' WHERE id = ?", request.ID).Error
+	})
+
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Activate Model Training")
+
+	return nil
+}
+
+func (c *TrainingClient) RequestTrainModel(ctx context.Context, request *model.RequestAPITrainModel) (*model.ResponseAPITrainModel, error) {
+	span, _ := utils.SpanFromContext(ctx, "Client: RequestTrainModel")
+	defer span.Finish()
+
+	svc := c.cfg.API.ProcessingSVC
+	url := fmt.Sprintf("%s:%d%s", svc.Host, svc.Port, svc.Endpoint)
+
+	utils.LogEvent(span, "URL", url)
+	utils.LogEvent(span, "Request", request)
+
+	var response model.ResponseAPITrainModel
+	if err := utils.RequestAPI(http.MethodPost, url, request, &response); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
+
+func (c *TrainingClient) RequestRecognize(ctx context.Context, request *model.RequestAPIRecognize) (*model.ResponseAPIRecognize, error) {
+	span, _ := utils.SpanFromContext(ctx, "Client: RequestRecognize")
+	defer span.Finish()
+
+	svc := c.cfg.API.ProcessingSVC
+	url := fmt.Sprintf("%s:%d%s", svc.Host, svc.Port, svc.RecognizeEndpoint)
+
+	utils.LogEvent(span, "URL", url)
+	utils.LogEvent(span, "Request", request)
+
+	var response model.ResponseAPIRecognize
+	if err := utils.RequestAPI(http.MethodPost, url, request, &response); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return &response, nil
+}
@@ -6,7 +6,6 @@ import (
 	"bpkp-svc-portal/app/utils"
 	"context"
 	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,6 +13,7 @@ import (
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -22,13 +22,26 @@ type InterfaceUserClient interface {
 	GetUserDetail(ctx context.Context, username string) (*model.User, error)
 	UpdateUser(ctx context.Context, user *model.User) error
 	DeleteUser(ctx context.Context, username string) error
-	CreateAccessToken(ctx context.Context, user *model.User, isLogout bool, menuMapping map[string]string) (t string, expired int64, err error)
-	GetAllUser(ctx context.Context, roleLevel int, institutionID string) ([]*model.User, error)
-	GetInstitutionList(ctx context.Context) ([]string, error)
+	CreateAccessToken(ctx context.Context, user *model.User, isLogout bool, menuMapping map[string]string, family string) (t string, expired int64, err error)
+	CreateRefreshToken(ctx context.Context, user *model.User, family string) (t string, claims *model.JwtRefreshClaims, err error)
+	ParseRefreshToken(ctx context.Context, token string) (*model.JwtRefreshClaims, error)
+	GetAllUser(ctx context.Context, query *model.ListQuery) ([]*model.User, *model.ListPage, error)
+	SearchUsers(ctx context.Context, term string, query *model.ListQuery) ([]*model.User, *model.ListPage, error)
+	GetExistingUsers(ctx context.Context, usernames []string, emails []string) ([]*model.User, error)
+	CreateUsers(ctx context.Context, users []*model.User) error
+	ReviewRegistration(ctx context.Context, user *model.User, reviewedBy string) error
+	UpdatePassword(ctx context.Context, username string, password string) error
+	GetInstitutionList(ctx context.Context, scope *model.DataScope) ([]string, error)
 	UpdateProfilePhoto(ctx context.Context, url string, username string) error
 	UpdateCoverPhoto(ctx context.Context, url string, username string) error
+	GetUserRoles(ctx context.Context, usernames ...string) ([]*model.UserRole, error)
+	SetUserRoles(ctx context.Context, username string, roleIDs []string, assignedBy string) error
 }
 
+// approvedUsers limits a query on users AS u to the users that may log in.
+// Users created before registrations were reviewed have no status.
+const approvedUsers = "COALESCE(u.status, '" + model.UserApproved + "') = '" + model.UserApproved + "'"
+
 type UserClient struct {
 	db  *gorm.DB
 	cfg *config.Config
@@ -48,9 +61,9 @@ func (r *UserClient) CreateNewUser(ctx context.Context, req *model.User) error {
 	utils.LogEvent(span, "Request", req)
 
 	var args []interface{}
-	args = append(args, req.Username, req.Email, req.Password, req.Fullname, req.Shortname, req.RoleID, req.InstitutionID, utils.LocalTime(), req.Address, req.PhoneNumber, req.Gender, req.Religion)
+	args = append(args, req.Username, req.Email, req.Password, req.Fullname, req.Shortname, req.RoleID, req.InstitutionID, utils.LocalTime(), req.Address, req.PhoneNumber, req.Gender, req.Religion, req.Status)
 
-	query := "INSERT INTO users (username, email, password, fullname, shortname, role_id, institution_id, created_at, address, phone_number, gender, religion) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	query := "INSERT INTO users (username, email, password, fullname, shortname, role_id, institution_id, created_at, address, phone_number, gender, religion, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 	result := r.db.Debug().WithContext(ctx).Exec(query, args...)
 
 	if result.Error != nil {
@@ -68,6 +81,116 @@ func (r *UserClient) CreateNewUser(ctx context.Context, req *model.User) error {
 	return nil
 }
 
+// GetExistingUsers returns the username and email of the users holding any of
+// the usernames or emails.
+func (r *UserClient) GetExistingUsers(ctx context.Context, usernames []string, emails []string) ([]*model.User, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetExistingUsers")
+	defer span.Finish()
+
+	var response []*model.User
+
+	if len(usernames) == 0 && len(emails) == 0 {
+		return response, nil
+	}
+
+	// IN () is invalid SQL, so an empty list matches an impossible value.
+	if len(usernames) == 0 {
+		usernames = []string{""}
+	}
+	if len(emails) == 0 {
+		emails = []string{""}
+	}
+
+	query := "SELECT username, email FROM users WHERE username IN ? OR email IN ?"
+	result := r.db.Debug().WithContext(ctx).Raw(query, usernames, emails).Scan(&response)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, model.ThrowError(http.StatusInternalServerError, result.Error)
+	}
+
+	return response, nil
+}
+
+// CreateUsers inserts users, whose passwords are already hashed, all or
+// none.
+func (r *UserClient) CreateUsers(ctx context.Context, users []*model.User) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: CreateUsers")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", len(users))
+
+	if len(users) == 0 {
+		return nil
+	}
+
+	now := utils.LocalTime()
+
+	var values []string
+	var args []interface{}
+	for _, v := range users {
+		values = append(values, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args, v.Username, v.Email, v.Password, v.Fullname, v.Shortname, v.RoleID, v.InstitutionID, now, v.Address, v.PhoneNumber, v.Gender, v.Religion, model.UserApproved, v.MustChangePassword)
+	}
+
+	query := "INSERT INTO users (username, email, password, fullname, shortname, role_id, institution_id, created_at, address, phone_number, gender, religion, status, must_change_password) VALUES " + strings.Join(values, ", ")
+	result := r.db.Debug().WithContext(ctx).Exec(query, args...)
+
+	if result.Error != nil {
+		if mysqlErr, ok := result.Error.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
+			utils.LogEventError(span, errors.New("username or email already exists"))
+			return model.ThrowError(http.StatusBadRequest, errors.New("username or email already exists"))
+		}
+		utils.LogEventError(span, result.Error)
+		return model.ThrowError(http.StatusInternalServerError, result.Error)
+	}
+
+	return nil
+}
+
+// ReviewRegistration records the decision on a pending registration: the
+// user's status, role and reject reason.
+func (r *UserClient) ReviewRegistration(ctx context.Context, user *model.User, reviewedBy string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: ReviewRegistration")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", user)
+
+	var args []interface{}
+	args = append(args, user.Status, user.RoleID, user.RejectReason, reviewedBy, utils.LocalTime(), user.Username, model.UserPending)
+
+	query := "UPDATE users SET status = ?, role_id = ?, reject_reason = ?, reviewed_by = ?, reviewed_at = ? WHERE username = ? AND status = ?"
+	result := r.db.Debug().WithContext(ctx).Exec(query, args...)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return model.ThrowError(http.StatusInternalServerError, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		utils.LogEventError(span, errors.New("registration is no longer pending"))
+		return model.ThrowError(http.StatusBadRequest, errors.New("registration is no longer pending"))
+	}
+
+	return nil
+}
+
+// UpdatePassword stores the user's new, already hashed, password, which no
+// longer has to be changed.
+func (r *UserClient) UpdatePassword(ctx context.Context, username string, password string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: UpdatePassword")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username)
+
+	query := "UPDATE users SET password = ?, must_change_password = ? WHERE username = ?"
+	result := r.db.Debug().WithContext(ctx).Exec(query, password, false, username)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return model.ThrowError(http.StatusInternalServerError, result.Error)
+	}
+
+	return nil
+}
+
 func (r *UserClient) GetUserDetail(ctx context.Context, username string) (*model.User, error) {
 	span, ctx := utils.SpanFromContext(ctx, "Client: GetUserDetail")
 	defer span.Finish()
@@ -125,6 +248,11 @@ func (r *UserClient) DeleteUser(ctx context.Context, username string) error {
 
 	utils.LogEvent(span, "Request", username)
 
+	if err := r.db.Debug().WithContext(ctx).Exec("DELETE FROM user_roles WHERE username = ?", username).Error; err != nil {
+		utils.LogEventError(span, err)
+		return model.ThrowError(http.StatusInternalServerError, err)
+	}
+
 	query := "DELETE FROM users WHERE username = ?"
 	result := r.db.Debug().WithContext(ctx).Exec(query, username)
 
@@ -141,28 +269,32 @@ func (r *UserClient) DeleteUser(ctx context.Context, username string) error {
 	return nil
 }
 
-func (r *UserClient) CreateAccessToken(ctx context.Context, user *model.User, isLogout bool, menuMapping map[string]string) (t string, expired int64, err error) {
+func (r *UserClient) CreateAccessToken(ctx context.Context, user *model.User, isLogout bool, menuMapping map[string]string, family string) (t string, expired int64, err error) {
 	span, _ := utils.SpanFromContext(ctx, "Client: CreateAccessToken")
 	defer span.Finish()
 
 	utils.LogEvent(span, "Request", user)
 
-	ExpireCount, _ := strconv.Atoi(r.cfg.Auth.AccessExpiry)
+	ttl := r.cfg.Auth.AccessTTL()
 	if isLogout {
-		ExpireCount = 0
+		ttl = 0
 	}
 
-	utils.LogEvent(span, "Expiry", ExpireCount)
+	utils.LogEvent(span, "Expiry", ttl.String())
 
-	exp := utils.LocalTime().Add(time.Hour * time.Duration(ExpireCount))
+	exp := utils.LocalTime().Add(ttl)
 	claims := &model.JwtCustomClaims{
 		Name: user.Username,
 		Role: user.RoleID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
 			ExpiresAt: jwt.NewNumericDate(exp),
 		},
-		MenuMapping:   menuMapping,
-		InstitutionID: user.InstitutionID,
+		MenuMapping:        menuMapping,
+		InstitutionID:      user.InstitutionID,
+		Family:             family,
+		MustChangePassword: user.MustChangePassword,
+		Type:               model.TokenTypeAccess,
 	}
 	expired = exp.Unix()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -177,39 +309,127 @@ func (r *UserClient) CreateAccessToken(ctx context.Context, user *model.User, is
 	return t, expired, nil
 }
 
-func (r *UserClient) GetAllUser(ctx context.Context, roleLevel int, institutionID string) ([]*model.User, error) {
+func (r *UserClient) CreateRefreshToken(ctx context.Context, user *model.User, family string) (t string, claims *model.JwtRefreshClaims, err error) {
+	span, _ := utils.SpanFromContext(ctx, "Client: CreateRefreshToken")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", family)
+
+	ExpireCount, _ := strconv.Atoi(r.cfg.Auth.RefreshExpiry)
+
+	claims = &model.JwtRefreshClaims{
+		Name:   user.Username,
+		Role:   user.RoleID,
+		Family: family,
+		Type:   model.TokenTypeRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(utils.LocalTime().Add(time.Hour * time.Duration(ExpireCount))),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	t, err = token.SignedString([]byte(r.cfg.Auth.RefreshSecret))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return "", nil, err
+	}
+
+	return t, claims, nil
+}
+
+func (r *UserClient) ParseRefreshToken(ctx context.Context, token string) (*model.JwtRefreshClaims, error) {
+	span, _ := utils.SpanFromContext(ctx, "Client: ParseRefreshToken")
+	defer span.Finish()
+
+	claims := &model.JwtRefreshClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(r.cfg.Auth.RefreshSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, model.ThrowError(http.StatusUnauthorized, errors.New("invalid refresh token"))
+	}
+
+	utils.LogEvent(span, "Response", claims)
+
+	return claims, nil
+}
+
+// userList is what user lists can be sorted and filtered by.
+var userList = &listSpec{
+	sorts: map[string]string{
+		"username": "u.username",
+		"fullname": "u.fullname",
+		"email":    "u.email",
+	},
+	filters: map[string]string{
+		"institution_id": "u.institution_id",
+		"role_id":        "u.role_id",
+		"gender":         "u.gender",
+		"religion":       "u.religion",
+		"status":         "COALESCE(u.status, '" + model.UserApproved + "')",
+	},
+	dateColumn:  "u.created_at",
+	defaultSort: "username",
+	key:         "username",
+}
+
+func (r *UserClient) GetAllUser(ctx context.Context, query *model.ListQuery) ([]*model.User, *model.ListPage, error) {
 	span, ctx := utils.SpanFromContext(ctx, "Client: GetAllUser")
 	defer span.Finish()
 
+	utils.LogEvent(span, "Request", query)
+
 	var response []*model.User
 
-	sb := strings.Builder{}
+	list, err := newListQuery(userList, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	list.where(scopeCondition(query.Scope, "u.username", "u.institution_id"))
 
-	if roleLevel == 2 {
-		sb.WriteString(fmt.Sprintf(" WHERE u.institution_id = '%s'", institutionID))
+	var total int64
+
+	where, args := list.countSQL()
+	result := r.db.Debug().WithContext(ctx).Raw("SELECT COUNT(1) FROM users AS u"+where, args...).Scan(&total)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, nil, model.ThrowError(http.StatusInternalServerError, result.Error)
 	}
 
-	query := "SELECT u.username, u.fullname, u.shortname, u.email, u.institution_id, u.role_id, u.address, u.phone_number, u.gender, u.religion, u.created_at, i.name AS institution_name, r.role_name FROM users AS u LEFT JOIN institutions AS i ON u.institution_id = i.id LEFT JOIN role AS r ON u.role_id = r.id"
-	result := r.db.Debug().WithContext(ctx).Raw(query + sb.String()).Scan(&response)
+	page, args := list.pageSQL()
+	sql := "SELECT u.username, u.fullname, u.shortname, u.email, u.institution_id, u.role_id, u.address, u.phone_number, u.gender, u.religion, u.created_at, COALESCE(u.status, '" + model.UserApproved + "') AS status, COALESCE(u.reject_reason, '') AS reject_reason, i.name AS institution_name, r.role_name FROM users AS u LEFT JOIN institutions AS i ON u.institution_id = i.id LEFT JOIN role AS r ON u.role_id = r.id"
+	result = r.db.Debug().WithContext(ctx).Raw(sql+page, args...).Scan(&response)
 
 	if result.Error != nil {
 		utils.LogEventError(span, result.Error)
-		return nil, model.ThrowError(http.StatusInternalServerError, result.Error)
+		return nil, nil, model.ThrowError(http.StatusInternalServerError, result.Error)
 	}
 
 	utils.LogEvent(span, "Response", response)
 
-	return response, nil
+	return response, list.page(total, &response), nil
 }
 
-func (r *UserClient) GetInstitutionList(ctx context.Context) ([]string, error) {
+// GetInstitutionList lists the institutions of the users within scope.
+func (r *UserClient) GetInstitutionList(ctx context.Context, scope *model.DataScope) ([]string, error) {
 	span, ctx := utils.SpanFromContext(ctx, "Client: GetInstitutionList")
 	defer span.Finish()
 
+	utils.LogEvent(span, "Request", scope)
+
 	var response []string
 
-	query := "SELECT DISTINCT institution_id FROM users"
-	result := r.db.Debug().WithContext(ctx).Raw(query).Scan(&response)
+	query := "SELECT DISTINCT u.institution_id FROM users AS u"
+	condition, args := scopeCondition(scope, "u.username", "u.institution_id")
+	if condition != "" {
+		query += " WHERE " + condition
+	}
+
+	result := r.db.Debug().WithContext(ctx).Raw(query, args...).Scan(&response)
 
 	if result.Error != nil {
 		utils.LogEventError(span, result.Error)
@@ -260,3 +480,64 @@ func (r *UserClient) UpdateCoverPhoto(ctx context.Context, url string, username
 
 	return nil
 }
+
+// GetUserRoles returns every role held by the given users, including their
+// default role, ordered from the widest role level.
+func (r *UserClient) GetUserRoles(ctx context.Context, usernames ...string) ([]*model.UserRole, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: GetUserRoles")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", usernames)
+
+	var response []*model.UserRole
+
+	if len(usernames) == 0 {
+		return response, nil
+	}
+
+	query := "SELECT x.username, r.id AS role_id, r.role_name, r.level FROM (SELECT username, role_id FROM user_roles UNION SELECT username, role_id FROM users) AS x JOIN role AS r ON x.role_id = r.id WHERE x.username IN ? ORDER BY x.username, r.level, r.role_name"
+	result := r.db.Debug().WithContext(ctx).Raw(query, usernames).Scan(&response)
+
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, model.ThrowError(http.StatusInternalServerError, result.Error)
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+// SetUserRoles replaces the additional roles of a user. The default role in
+// users.role_id is kept separately and never needs to be listed here.
+func (r *UserClient) SetUserRoles(ctx context.Context, username string, roleIDs []string, assignedBy string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Client: SetUserRoles")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", roleIDs)
+
+	err := r.db.Debug().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_roles WHERE username = ?", username).Error; err != nil {
+			return err
+		}
+
+		now := utils.LocalTime()
+		for _, roleID := range roleIDs {
+			var args []interface{}
+			args = append(args, username, roleID, now, assignedBy)
+
+			query := "INSERT IGNORE INTO user_roles (username, role_id, assigned_at, assigned_by) VALUES (?, ?, ?, ?)"
+			if err := tx.Exec(query, args...).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+		return model.ThrowError(http.StatusInternalServerError, err)
+	}
+
+	return nil
+}
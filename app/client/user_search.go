@@ -0,0 +1,206 @@
+package client
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// userSearchList is what user search results can be sorted and filtered by,
+// after their rank. A role filter matches the users' additional roles too.
+var userSearchList = &listSpec{
+	sorts: map[string]string{
+		"username": "u.username",
+		"fullname": "u.fullname",
+	},
+	filters: map[string]string{
+		"institution_id": "u.institution_id",
+		"role_id":        "(u.role_id = ? OR u.username IN (SELECT username FROM user_roles WHERE role_id = ?))",
+		"gender":         "u.gender",
+	},
+	defaultSort: "username",
+	key:         "username",
+}
+
+// nameFolds are applied in order to names and search terms so that
+// punctuation and the old spellings of Indonesian names don't get in the way:
+// "Soekarno" finds "Sukarno", "Djoko" finds "Joko", "Ma'ruf" finds "Maruf".
+var nameFolds = [][2]string{
+	{"'", ""},
+	{".", ""},
+	{"-", " "},
+	{"oe", "u"},
+	{"dj", "j"},
+	{"tj", "c"},
+}
+
+// foldNameSQL is the SQL equivalent of foldName for a column.
+func foldNameSQL(column string) string {
+	expression := "LOWER(COALESCE(" + column + ", ''))"
+	for _, v := range nameFolds {
+		expression = fmt.Sprintf("REPLACE(%s, '%s', '%s')", expression, strings.ReplaceAll(v[0], "'", "''"), v[1])
+	}
+
+	return expression
+}
+
+func foldName(name string) string {
+	name = strings.ToLower(name)
+	for _, v := range nameFolds {
+		name = strings.ReplaceAll(name, v[0], v[1])
+	}
+
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// phoneSQL is a phone number column as foldPhone folds search terms.
+const phoneSQL = "REPLACE(REPLACE(REPLACE(REPLACE(COALESCE(u.phone_number, ''), '+', ''), '-', ''), ' ', ''), '.', '')"
+
+// foldPhone reduces a phone number to its digits in the national format,
+// e.g. "+62 812-3456" to "08123456". Terms that don't look like a phone
+// number fold to "".
+func foldPhone(term string) string {
+	var digits strings.Builder
+	for _, r := range term {
+		switch {
+		case unicode.IsDigit(r):
+			digits.WriteRune(r)
+		case r == '+' || r == '-' || r == '.' || r == ' ':
+		default:
+			return ""
+		}
+	}
+
+	phone := digits.String()
+	if strings.HasPrefix(phone, "62") {
+		phone = "0" + phone[2:]
+	}
+
+	if len(phone) < 3 {
+		return ""
+	}
+
+	return phone
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// userMatch is one way a user can match a search term, with the rank it
+// gives.
+type userMatch struct {
+	condition string
+	args      []interface{}
+	rank      int
+}
+
+// userMatches lists the ways a user can match term, best first: the exact
+// username or email, a username prefix, a full or short name prefix, a
+// prefix of a later word of the full name, an email prefix and a phone
+// number prefix.
+func userMatches(term string) []*userMatch {
+	lower := strings.ToLower(strings.TrimSpace(term))
+	name := escapeLike(foldName(term))
+	phone := foldPhone(strings.TrimSpace(term))
+
+	var matches []*userMatch
+	if lower != "" {
+		matches = append(matches,
+			&userMatch{"LOWER(u.username) = ?", []interface{}{lower}, 100},
+			&userMatch{"LOWER(u.email) = ?", []interface{}{lower}, 90},
+			&userMatch{"LOWER(u.username) LIKE ?", []interface{}{escapeLike(lower) + "%"}, 80},
+		)
+	}
+
+	if name != "" {
+		matches = append(matches,
+			&userMatch{foldNameSQL("u.fullname") + " LIKE ? OR " + foldNameSQL("u.shortname") + " LIKE ?", []interface{}{name + "%", name + "%"}, 60},
+			&userMatch{foldNameSQL("u.fullname") + " LIKE ?", []interface{}{"% " + name + "%"}, 40},
+		)
+	}
+
+	if lower != "" {
+		matches = append(matches, &userMatch{"LOWER(u.email) LIKE ?", []interface{}{escapeLike(lower) + "%"}, 30})
+	}
+
+	if phone != "" {
+		phoneColumn := fmt.Sprintf("(CASE WHEN %[1]s LIKE '62%%' THEN CONCAT('0', SUBSTRING(%[1]s, 3)) ELSE %[1]s END)", phoneSQL)
+		matches = append(matches, &userMatch{phoneColumn + " LIKE ?", []interface{}{phone + "%"}, 20})
+	}
+
+	return matches
+}
+
+// SearchUsers lists a page of the approved users matching term within the
+// query's scope, best matches first.
+func (r *UserClient) SearchUsers(ctx context.Context, term string, query *model.ListQuery) ([]*model.User, *model.ListPage, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Client: SearchUsers")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", term)
+
+	var response []*model.User
+
+	if query.Cursor != "" {
+		return nil, nil, model.ThrowError(http.StatusBadRequest, errors.New("search results are paged by page, not cursor"))
+	}
+
+	matches := userMatches(term)
+	if len(matches) == 0 {
+		return nil, nil, model.ThrowError(http.StatusBadRequest, errors.New("q shouldn't be empty"))
+	}
+
+	list, err := newListQuery(userSearchList, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	list.where(scopeCondition(query.Scope, "u.username", "u.institution_id"))
+	list.where(approvedUsers)
+
+	var conditions []string
+	var args, rankArgs []interface{}
+	rank := strings.Builder{}
+
+	rank.WriteString("CASE")
+	for _, v := range matches {
+		conditions = append(conditions, "("+v.condition+")")
+		args = append(args, v.args...)
+
+		rank.WriteString(fmt.Sprintf(" WHEN %s THEN %d", v.condition, v.rank))
+		rankArgs = append(rankArgs, v.args...)
+	}
+	rank.WriteString(" ELSE 0 END")
+
+	list.where("("+strings.Join(conditions, " OR ")+")", args...)
+	list.rankBy(rank.String(), rankArgs...)
+
+	var total int64
+
+	where, countArgs := list.countSQL()
+	result := r.db.Debug().WithContext(ctx).Raw("SELECT COUNT(1) FROM users AS u"+where, countArgs...).Scan(&total)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, nil, model.ThrowError(http.StatusInternalServerError, result.Error)
+	}
+
+	page, pageArgs := list.pageSQL()
+	sql := "SELECT u.username, u.fullname, u.shortname, u.email, u.institution_id, u.role_id, u.phone_number, u.gender, u.profile_photo, i.name AS institution_name, r.role_name FROM users AS u LEFT JOIN institutions AS i ON u.institution_id = i.id LEFT JOIN role AS r ON u.role_id = r.id"
+	result = r.db.Debug().WithContext(ctx).Raw(sql+page, pageArgs...).Scan(&response)
+	if result.Error != nil {
+		utils.LogEventError(span, result.Error)
+		return nil, nil, model.ThrowError(http.StatusInternalServerError, result.Error)
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, list.page(total, &response), nil
+}
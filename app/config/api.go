@@ -5,5 +5,7 @@ type APIEndpoint struct {
 		Host     string `yaml:"host"`
 		Port     int    `yaml:"port"`
 		Endpoint string `yaml:"endpoint"`
+		// RecognizeEndpoint predicts whose face an uploaded image shows.
+		RecognizeEndpoint string `yaml:"recognize_endpoint"`
 	} `yaml:"processingsvc"`
 }
@@ -1,7 +1,31 @@
 package config
 
+import (
+	"strconv"
+	"time"
+)
+
 type Auth struct {
 	AccessSecret  string `yaml:"accessSecret"`
 	RefreshSecret string `yaml:"refreshSecret"`
-	AccessExpiry  string `yaml:"accessExpiry"`
+	AccessExpiry  string `yaml:"accessExpiry"` // hours, used when accessExpiryMinutes is unset
+	// AccessExpiryMinutes sets the access token lifetime in minutes, which
+	// short-lived tokens renewed by refresh tokens need.
+	AccessExpiryMinutes string `yaml:"accessExpiryMinutes"`
+	RefreshExpiry       string `yaml:"refreshExpiry"` // hours
+	// ServiceKey is sent by internal services, such as the processing
+	// service, in the X-Service-Key header of their callbacks.
+	ServiceKey string `yaml:"serviceKey"`
+}
+
+// AccessTTL returns the access token lifetime: accessExpiryMinutes when set,
+// else accessExpiry in hours.
+func (a *Auth) AccessTTL() time.Duration {
+	if a.AccessExpiryMinutes != "" {
+		minutes, _ := strconv.Atoi(a.AccessExpiryMinutes)
+		return time.Duration(minutes) * time.Minute
+	}
+
+	hours, _ := strconv.Atoi(a.AccessExpiry)
+	return time.Duration(hours) * time.Hour
 }
@@ -1,8 +1,10 @@
 package config
 
 type RabbitMQ struct {
-	Host     string `yaml:"host"`
-	Port     string `yaml:"port"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
+	Host      string `yaml:"host"`
+	Port      string `yaml:"port"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+	Exchange  string `yaml:"exchange"`
+	RFIDQueue string `yaml:"rfidQueue"`
 }
@@ -0,0 +1,280 @@
+package consumer
+
+import (
+	"bpkp-svc-portal/app/config"
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// tapTTL bounds how long a processed (card, timestamp) pair is remembered for
+// deduplication. Gateways are not expected to replay taps older than this.
+const tapTTL = 14 * 24 * time.Hour
+
+// tapClaimTTL bounds how long a tap stays claimed by a consumer that died
+// while applying it.
+const tapClaimTTL = 2 * time.Minute
+
+// A batch that fails to apply, e.g. while Redis or the database is down, is
+// retried through the retry queue after retryDelay, and dead-lettered after
+// maxRetries attempts.
+const (
+	retryDelay    = 30 * time.Second
+	maxRetries    = 10
+	retriesHeader = "x-retries"
+)
+
+type RFIDConsumer struct {
+	mq         *amqp.Channel
+	redis      *redis.Client
+	queue      string
+	attendance controller.InterfaceAttendanceController
+}
+
+func NewRFIDConsumer(cfg *config.Config, mq *amqp.Channel, redis *redis.Client, attendance controller.InterfaceAttendanceController) *RFIDConsumer {
+	queue := cfg.RabbitMQ.RFIDQueue
+	if queue == "" {
+		queue = "rfid.taps"
+	}
+
+	return &RFIDConsumer{
+		mq:         mq,
+		redis:      redis,
+		queue:      queue,
+		attendance: attendance,
+	}
+}
+
+func (c *RFIDConsumer) deadLetterExchange() string {
+	return c.queue + ".dlx"
+}
+
+func (c *RFIDConsumer) retryQueue() string {
+	return c.queue + ".retry"
+}
+
+// Start declares the tap queue with its dead-letter and retry queues and
+// consumes tap batches in the background. Batches expire from the retry queue
+// back into the tap queue.
+func (c *RFIDConsumer) Start() error {
+	dlx := c.deadLetterExchange()
+
+	if err := c.mq.ExchangeDeclare(dlx, amqp.ExchangeFanout, true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	if _, err := c.mq.QueueDeclare(c.queue+".dead", true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	if err := c.mq.QueueBind(c.queue+".dead", "", dlx, false, nil); err != nil {
+		return err
+	}
+
+	_, err := c.mq.QueueDeclare(c.queue, true, false, false, false, amqp.Table{
+		"x-dead-letter-exchange": dlx,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.mq.QueueDeclare(c.retryQueue(), true, false, false, false, amqp.Table{
+		"x-message-ttl":             retryDelay.Milliseconds(),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": c.queue,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.mq.Qos(10, 0, false); err != nil {
+		return err
+	}
+
+	deliveries, err := c.mq.Consume(c.queue, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for d := range deliveries {
+			c.handle(d)
+		}
+		logrus.Printf("RFID consumer on %s stopped", c.queue)
+	}()
+
+	logrus.Printf("Consuming RFID taps from %s", c.queue)
+
+	return nil
+}
+
+func (c *RFIDConsumer) handle(d amqp.Delivery) {
+	span, ctx := utils.SpanFromContext(context.Background(), "Consumer: RFIDTapBatch")
+	defer span.Finish()
+
+	var batch model.RFIDTapBatch
+	if err := json.Unmarshal(d.Body, &batch); err != nil {
+		utils.LogEventError(span, err)
+		d.Nack(false, false)
+		return
+	}
+
+	utils.LogEvent(span, "Request", batch)
+
+	// Replay in tap order so a buffered check-out never precedes its check-in.
+	sort.SliceStable(batch.Taps, func(i, j int) bool {
+		if batch.Taps[i] == nil || batch.Taps[j] == nil {
+			return batch.Taps[j] != nil
+		}
+		return batch.Taps[i].TappedAt.Before(batch.Taps[j].TappedAt)
+	})
+
+	for _, tap := range batch.Taps {
+		if tap == nil || tap.CardUID == "" || tap.TappedAt.IsZero() {
+			c.deadLetter(ctx, d, tap, "malformed tap")
+			continue
+		}
+
+		// Claiming the tap first keeps a redelivery, or another replica
+		// consuming the same tap, from applying it twice and turning it into
+		// a check-out.
+		key := fmt.Sprintf("rfid-tap:%s:%d", tap.CardUID, tap.TappedAt.Unix())
+		claimed, err := c.redis.SetNX(ctx, key, "processing", tapClaimTTL).Result()
+		if err != nil {
+			utils.LogEventError(span, err)
+			c.retry(ctx, d)
+			return
+		}
+
+		if !claimed {
+			utils.LogEvent(span, "Duplicate", key)
+			continue
+		}
+
+		res, err := c.attendance.CheckInOutRFIDCardAt(ctx, batch.DeviceID, tap.CardUID, tap.TappedAt.In(utils.LocalTime().Location()))
+		if err != nil {
+			var errResponse *model.ErrorResponse
+			if errors.As(err, &errResponse) && errResponse.Code < http.StatusInternalServerError {
+				// Unknown cards and devices, and cards of another
+				// institution, are parked for review; anything else (e.g.
+				// already checked out) is a normal rejection.
+				if errResponse.Code == http.StatusNotFound || errResponse.Code == http.StatusUnauthorized || errResponse.Code == http.StatusForbidden {
+					c.deadLetter(ctx, d, tap, err.Error())
+				}
+				utils.LogEvent(span, "Rejected", fmt.Sprintf("%s: %s", key, err.Error()))
+				c.markProcessed(ctx, d, key)
+				continue
+			}
+
+			// The claim is released, so the retried batch applies it again.
+			utils.LogEventError(span, err)
+			c.release(ctx, key)
+			c.retry(ctx, d)
+			return
+		}
+
+		c.markProcessed(ctx, d, key)
+		utils.LogEvent(span, "Response", fmt.Sprintf("%s: %s", key, res))
+	}
+
+	d.Ack(false)
+}
+
+// markProcessed turns the claim on a tap into a mark that it has been applied
+// or finally rejected, so redeliveries skip it. A tap whose consumer died
+// keeps only the short claim and is applied again once that expires.
+func (c *RFIDConsumer) markProcessed(ctx context.Context, d amqp.Delivery, key string) {
+	if err := c.redis.Set(ctx, key, d.MessageId, tapTTL).Err(); err != nil {
+		logrus.Errorf("can't mark RFID tap %s as processed: %v", key, err)
+	}
+}
+
+// release drops the claim on a tap that failed to apply.
+func (c *RFIDConsumer) release(ctx context.Context, key string) {
+	if err := c.redis.Del(ctx, key).Err(); err != nil {
+		logrus.Errorf("can't release RFID tap %s: %v", key, err)
+	}
+}
+
+// retry sends the batch to the retry queue, or to the dead-letter queue once
+// it has been tried maxRetries times. Taps already applied are skipped when
+// it comes back.
+func (c *RFIDConsumer) retry(ctx context.Context, d amqp.Delivery) {
+	retries := retryCount(d) + 1
+	if retries > maxRetries {
+		logrus.Errorf("RFID batch %s failed %d times, dead-lettering it", d.MessageId, maxRetries)
+		d.Nack(false, false)
+		return
+	}
+
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[retriesHeader] = int32(retries)
+
+	err := c.mq.PublishWithContext(ctx, "", c.retryQueue(), false, false, amqp.Publishing{
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    d.MessageId,
+		Headers:      headers,
+		Body:         d.Body,
+	})
+	if err != nil {
+		logrus.Errorf("can't schedule a retry of RFID batch %s: %v", d.MessageId, err)
+		d.Nack(false, true)
+		return
+	}
+
+	d.Ack(false)
+}
+
+func retryCount(d amqp.Delivery) int {
+	switch v := d.Headers[retriesHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+func (c *RFIDConsumer) deadLetter(ctx context.Context, d amqp.Delivery, tap *model.RFIDTap, reason string) {
+	span, ctx := utils.SpanFromContext(ctx, "Consumer: DeadLetterTap")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", tap)
+
+	body, err := json.Marshal(tap)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return
+	}
+
+	err = c.mq.PublishWithContext(ctx, c.deadLetterExchange(), "", false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Headers: amqp.Table{
+			"x-reason":          reason,
+			"x-original-msg-id": d.MessageId,
+		},
+		Body: body,
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+	}
+}
@@ -2,54 +2,203 @@ package controller
 
 import (
 	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/config"
 	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
 	"bpkp-svc-portal/app/utils"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type InterfaceAttendanceController interface {
-	GetUserAttendances(ctx context.Context, request *model.RequestUserAttendances) ([]*model.UserAttendance, error)
+	GetUserAttendances(ctx context.Context, request *model.RequestUserAttendances) ([]*model.UserAttendance, *model.ListPage, error)
 	GetTodayAttendances(ctx context.Context) (*model.UserAttendance, error)
+	GetAttendanceRecap(ctx context.Context, request *model.RequestAttendanceRecap) (*model.AttendanceRecap, error)
 	CheckIn(ctx context.Context, request *model.Attendance) error
+	CheckInSelfie(ctx context.Context, request *model.Attendance) error
 	CheckOut(ctx context.Context, request *model.Attendance) error
-	CheckInOutRFID(ctx context.Context, request *model.Attendance) (string, error)
+	CheckInOutRFID(ctx context.Context, request *model.RequestRFIDTap) (string, error)
+	CheckInOutRFIDCardAt(ctx context.Context, deviceID string, cardUID string, at time.Time) (string, error)
+	DetectAbsences(ctx context.Context, now time.Time) error
 }
 
 type AttendanceController struct {
 	attendanceClient client.InterfaceAttendanceClient
 	paramClient      client.InterfaceParamClient
+	eventClient      client.InterfaceEventClient
+	rfidClient       client.InterfaceRFIDClient
+	tokenClient      client.InterfaceTokenClient
+	scopePolicy      policy.InterfaceScopePolicy
+	shifts           *shiftResolver
+	geofence         *geofence
+	faces            *faceVerifier
 }
 
-func NewAttendanceController(attendanceClient client.InterfaceAttendanceClient, paramClient client.InterfaceParamClient) *AttendanceController {
+func NewAttendanceController(cfg *config.Config, attendanceClient client.InterfaceAttendanceClient, paramClient client.InterfaceParamClient, eventClient client.InterfaceEventClient, rfidClient client.InterfaceRFIDClient, tokenClient client.InterfaceTokenClient, scheduleClient client.InterfaceScheduleClient, calendarClient client.InterfaceCalendarClient, geofenceClient client.InterfaceGeofenceClient, userClient client.InterfaceUserClient, trainingClient client.InterfaceTrainingClient, storageClient client.InterfaceStorageClient, scopePolicy policy.InterfaceScopePolicy) *AttendanceController {
 	return &AttendanceController{
 		attendanceClient: attendanceClient,
 		paramClient:      paramClient,
+		eventClient:      eventClient,
+		rfidClient:       rfidClient,
+		tokenClient:      tokenClient,
+		scopePolicy:      scopePolicy,
+		shifts:           newShiftResolver(scheduleClient, paramClient, calendarClient),
+		geofence: &geofence{
+			geofenceClient: geofenceClient,
+			paramClient:    paramClient,
+		},
+		faces: &faceVerifier{
+			bucket:         cfg.MinioProfile.Bucket,
+			userClient:     userClient,
+			trainingClient: trainingClient,
+			storageClient:  storageClient,
+			paramClient:    paramClient,
+		},
 	}
 }
 
-func (uc *AttendanceController) GetUserAttendances(ctx context.Context, request *model.RequestUserAttendances) ([]*model.UserAttendance, error) {
+func (uc *AttendanceController) GetUserAttendances(ctx context.Context, request *model.RequestUserAttendances) ([]*model.UserAttendance, *model.ListPage, error) {
 	span, ctx := utils.SpanFromContext(ctx, "Controller: GetUserAttendances")
 	defer span.Finish()
 
 	utils.LogEvent(span, "Request", request)
 
-	res, err := uc.attendanceClient.GetUserAttendances(ctx, request)
+	scope, err := uc.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	request.Scope = scope
+	legacyFilter(request)
+
+	res, page, err := uc.attendanceClient.GetUserAttendances(ctx, request)
+
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	completeAttendances(res)
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, page, nil
+}
+
+// legacyFilter carries the filter of older clients, a check-in sort
+// direction and a row limit, over to the list query when it sets neither.
+func legacyFilter(request *model.RequestUserAttendances) {
+	if request.Sort == "" {
+		switch strings.ToUpper(request.Filter.SortType) {
+		case "ASC":
+			request.Sort = "work_date"
+		case "DESC":
+			request.Sort = "-work_date"
+		}
+	}
+
+	if request.Size == 0 && request.Filter.Limit > 0 {
+		request.Size = request.Filter.Limit
+	}
+}
+
+// GetAttendanceRecap totals a month's attendance per user within the caller's
+// scope, rolled up per institution for callers beyond the self scope.
+func (uc *AttendanceController) GetAttendanceRecap(ctx context.Context, request *model.RequestAttendanceRecap) (*model.AttendanceRecap, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAttendanceRecap")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	if request.Month == "" {
+		request.Month = utils.LocalTime().Format("2006-01")
+	}
+
+	month, err := time.ParseInLocation("2006-01", request.Month, utils.LocalTime().Location())
+	if err != nil {
+		return nil, model.ThrowError(http.StatusBadRequest, errors.New("month must be formatted as YYYY-MM"))
+	}
 
+	scope, err := uc.scopePolicy.Resolve(ctx)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return nil, err
 	}
 
+	request.Scope = scope
+
+	users, err := uc.attendanceClient.GetAttendanceRecap(ctx, request, month.Format("2006-01-02"), month.AddDate(0, 1, -1).Format("2006-01-02"))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res := &model.AttendanceRecap{
+		Month: request.Month,
+		Users: users,
+	}
+
+	institutions := map[string]*model.InstitutionRecap{}
+	for _, v := range users {
+		v.WorkedHours = workedHours(v.WorkedMinutes)
+
+		if scope.Scope == model.ScopeSelf {
+			continue
+		}
+
+		institution, ok := institutions[v.InstitutionID]
+		if !ok {
+			institution = &model.InstitutionRecap{
+				InstitutionID:   v.InstitutionID,
+				InstitutionName: v.InstitutionName,
+			}
+			institutions[v.InstitutionID] = institution
+			res.Institutions = append(res.Institutions, institution)
+		}
+
+		institution.Users++
+		institution.Add(v.RecapCounts)
+	}
+
+	for _, v := range res.Institutions {
+		v.WorkedHours = workedHours(v.WorkedMinutes)
+	}
+
 	utils.LogEvent(span, "Response", res)
 
 	return res, nil
 }
 
+// completeAttendances fills in what listed rows don't store: the worked time
+// of rows checked out before it was stored, and the day type.
+func completeAttendances(rows []*model.UserAttendance) {
+	for _, v := range rows {
+		if v.WorkedMinutes == 0 && !v.CheckOut.IsZero() {
+			v.WorkedMinutes = wholeMinutes(v.CheckOut.Sub(v.CheckIn))
+		}
+
+		labelDayType(v)
+	}
+}
+
+// workedHours converts worked minutes to hours, rounded to two decimals.
+func workedHours(minutes int) float64 {
+	return math.Round(float64(minutes)/60*100) / 100
+}
+
 func (uc *AttendanceController) GetTodayAttendances(ctx context.Context) (*model.UserAttendance, error) {
 	span, ctx := utils.SpanFromContext(ctx, "Controller: GetTodayAttendances")
 	defer span.Finish()
@@ -69,6 +218,8 @@ func (uc *AttendanceController) GetTodayAttendances(ctx context.Context) (*model
 		return nil, err
 	}
 
+	labelDayType(res)
+
 	return res, nil
 }
 
@@ -76,27 +227,35 @@ func (uc *AttendanceController) CheckIn(ctx context.Context, request *model.Atte
 	span, ctx := utils.SpanFromContext(ctx, "Controller: CheckIn")
 	defer span.Finish()
 
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.Username = session.Username
 	request.CheckIn = utils.LocalTime()
+	request.SourceIn = model.SourceMobile
 
-	checkInThreshold, err := uc.paramClient.GetParameterByKey(ctx, "checkin-time")
+	shift, err := uc.shifts.at(ctx, request.Username, request.CheckIn)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
 	}
 
-	parsedTime, err := time.Parse("15:04", checkInThreshold.Value)
-	if err != nil {
+	if err := applyCheckInStatus(request, shift); err != nil {
 		utils.LogEventError(span, err)
 		return err
 	}
 
-	targetTime := time.Date(utils.LocalTime().Year(), utils.LocalTime().Month(), utils.LocalTime().Day(), parsedTime.Hour(), parsedTime.Minute(), 0, 0, time.Local)
-	if utils.LocalTime().Compare(targetTime) == -1 {
-		request.StatusIn = "On Time"
-	} else {
-		request.StatusIn = "Late"
+	request.GeofenceIn, err = uc.geofence.check(ctx, request.Username, request.Latitude, request.Longitude, request.Accuracy, request.CheckIn)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
 	}
 
+	request.LatitudeIn, request.LongitudeIn, request.AccuracyIn = request.Latitude, request.Longitude, request.Accuracy
+
 	utils.LogEvent(span, "Request", request)
 
 	err = uc.attendanceClient.CheckIn(ctx, request)
@@ -105,37 +264,80 @@ func (uc *AttendanceController) CheckIn(ctx context.Context, request *model.Atte
 		return err
 	}
 
+	if err := uc.eventClient.Publish(ctx, model.EventCheckIn, "", request); err != nil {
+		utils.LogEventError(span, err)
+	}
+
 	utils.LogEvent(span, "Response", "Success Check In")
 
 	return nil
 }
 
+// CheckInSelfie checks in once the request's selfie is verified against the
+// face model of the user's institution.
+func (uc *AttendanceController) CheckInSelfie(ctx context.Context, request *model.Attendance) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: CheckInSelfie")
+	defer span.Finish()
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.Username = session.Username
+
+	if err := uc.faces.verify(ctx, request); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return uc.CheckIn(ctx, request)
+}
+
 func (uc *AttendanceController) CheckOut(ctx context.Context, request *model.Attendance) error {
-	span, ctx := utils.SpanFromContext(ctx, "Controller: CheckIn")
+	span, ctx := utils.SpanFromContext(ctx, "Controller: CheckOut")
 	defer span.Finish()
 
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.Username = session.Username
 	request.CheckOut = utils.LocalTime()
+	request.SourceOut = model.SourceMobile
 
-	utils.LogEvent(span, "Request", request)
-	checkInThreshold, err := uc.paramClient.GetParameterByKey(ctx, "checkout-time")
+	shift, err := uc.shifts.at(ctx, request.Username, request.CheckOut)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
 	}
 
-	parsedTime, err := time.Parse("15:04", checkInThreshold.Value)
+	attendance, err := uc.attendanceClient.GetAttendanceByWorkDate(ctx, request.Username, shift.WorkDate)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
 	}
 
-	targetTime := time.Date(utils.LocalTime().Year(), utils.LocalTime().Month(), utils.LocalTime().Day(), parsedTime.Hour(), parsedTime.Minute(), 0, 0, time.Local)
-	if utils.LocalTime().Compare(targetTime) == -1 {
-		request.StatusOut = "Early"
-	} else {
-		request.StatusOut = "Normal"
+	// Days on leave or marked absent have an attendance row without a
+	// check-in.
+	if attendance == nil || attendance.CheckIn.IsZero() {
+		utils.LogEventError(span, errors.New("you haven't checked in"))
+		return model.ThrowError(http.StatusBadRequest, errors.New("you haven't checked in"))
 	}
 
+	applyCheckOutStatus(request, shift, attendance.CheckIn)
+
+	request.GeofenceOut, err = uc.geofence.check(ctx, request.Username, request.Latitude, request.Longitude, request.Accuracy, request.CheckOut)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.LatitudeOut, request.LongitudeOut, request.AccuracyOut = request.Latitude, request.Longitude, request.Accuracy
+
 	utils.LogEvent(span, "Request", request)
 
 	err = uc.attendanceClient.CheckOut(ctx, request)
@@ -144,82 +346,362 @@ func (uc *AttendanceController) CheckOut(ctx context.Context, request *model.Att
 		return err
 	}
 
+	if err := uc.eventClient.Publish(ctx, model.EventCheckOut, "", request); err != nil {
+		utils.LogEventError(span, err)
+	}
+
 	utils.LogEvent(span, "Response", "Success Check Out")
 
 	return nil
 }
 
-func (uc *AttendanceController) CheckInOutRFID(ctx context.Context, request *model.Attendance) (string, error) {
+func (uc *AttendanceController) CheckInOutRFID(ctx context.Context, request *model.RequestRFIDTap) (string, error) {
 	span, ctx := utils.SpanFromContext(ctx, "Controller: CheckInOutRFID")
 	defer span.Finish()
 
-	request.CheckIn = utils.LocalTime()
+	utils.LogEvent(span, "Request", request.DeviceID)
 
-	checkInThreshold, err := uc.paramClient.GetParameterByKey(ctx, "checkin-time")
+	device, err := uc.rfidClient.GetDeviceByID(ctx, request.DeviceID)
 	if err != nil {
+		logrus.Warnf("RFID tap rejected: unknown device %q", request.DeviceID)
 		utils.LogEventError(span, err)
-		return err.Error(), err
+		return "", err
+	}
+
+	if !verifyDeviceCredential(device, request) {
+		logrus.Warnf("RFID tap rejected: invalid credential for device %q", request.DeviceID)
+		utils.LogEventError(span, errors.New("invalid device credential"))
+		return "", model.ThrowError(http.StatusUnauthorized, errors.New("invalid device credential"))
 	}
 
-	parsedTime, err := time.Parse("15:04", checkInThreshold.Value)
+	// A signature stays valid for the whole window either side of its
+	// timestamp, so it is remembered that long to refuse replays.
+	if request.Signature != "" {
+		claimed, err := uc.tokenClient.ClaimDeviceSignature(ctx, device.ID, request.Signature, 2*deviceSignatureWindow)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return "", model.ThrowError(http.StatusInternalServerError, err)
+		}
+
+		if !claimed {
+			logrus.Warnf("RFID tap rejected: replayed request for device %q", request.DeviceID)
+			utils.LogEventError(span, errors.New("replayed device request"))
+			return "", model.ThrowError(http.StatusUnauthorized, errors.New("replayed device request"))
+		}
+	}
+
+	res, err := uc.CheckInOutRFIDCardAt(ctx, device.ID, request.CardUID, utils.LocalTime())
+	if err != nil {
+		utils.LogEventError(span, err)
+		return res, err
+	}
+
+	return res, nil
+}
+
+// CheckInOutRFIDCardAt resolves a card tapped on a registered device and
+// applies it at the given time.
+func (uc *AttendanceController) CheckInOutRFIDCardAt(ctx context.Context, deviceID string, cardUID string, at time.Time) (string, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: CheckInOutRFIDCardAt")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", fmt.Sprintf("%s: %s", deviceID, cardUID))
+
+	device, err := uc.rfidClient.GetDeviceByID(ctx, deviceID)
+	if err != nil {
+		logrus.Warnf("RFID tap rejected: unknown device %q", deviceID)
+		utils.LogEventError(span, err)
+		return "", err
+	}
+
+	card, err := uc.rfidClient.GetActiveCard(ctx, cardUID)
+	if err != nil {
+		logrus.Warnf("RFID tap rejected: unknown card %q on device %q", cardUID, deviceID)
+		utils.LogEventError(span, err)
+		return "", err
+	}
+
+	if card.InstitutionID != device.InstitutionID {
+		logrus.Warnf("RFID tap rejected: card %q of another institution on device %q", cardUID, deviceID)
+		utils.LogEventError(span, errors.New("card belongs to another institution"))
+		return "", model.ThrowError(http.StatusForbidden, errors.New("card belongs to another institution"))
+	}
+
+	request := &model.Attendance{
+		Username:  card.Username,
+		SourceIn:  fmt.Sprintf("rfid:%s", deviceID),
+		SourceOut: fmt.Sprintf("rfid:%s", deviceID),
+	}
+
+	return uc.checkInOutAt(ctx, request, at)
+}
+
+// checkInOutAt applies a tap that happened at the given time. The first tap
+// of that day checks the user in, the next one checks them out.
+func (uc *AttendanceController) checkInOutAt(ctx context.Context, request *model.Attendance, at time.Time) (string, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: checkInOutAt")
+	defer span.Finish()
+
+	request.CheckIn = at
+
+	shift, err := uc.shifts.at(ctx, request.Username, at)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err.Error(), err
 	}
 
-	targetTime := time.Date(utils.LocalTime().Year(), utils.LocalTime().Month(), utils.LocalTime().Day(), parsedTime.Hour(), parsedTime.Minute(), 0, 0, time.Local)
-	if utils.LocalTime().Compare(targetTime) == -1 {
-		request.StatusIn = "On Time"
-	} else {
-		request.StatusIn = "Late"
+	// A tap before check-in opens can still be the check-out of a shift the
+	// user already checked in to, so only reject it once no check-in is found.
+	tooEarly := applyCheckInStatus(request, shift)
+	if tooEarly == nil {
+		utils.LogEvent(span, "Request", request)
+
+		err = uc.attendanceClient.CheckIn(ctx, request)
+		if err == nil {
+			if err := uc.eventClient.Publish(ctx, model.EventCheckIn, "", request); err != nil {
+				utils.LogEventError(span, err)
+			}
+
+			return "Success Check In", nil
+		}
+
+		if !errors.Is(err, gorm.ErrRegistered) {
+			utils.LogEventError(span, err)
+			return "", err
+		}
+	}
+
+	attendance, err := uc.attendanceClient.GetAttendanceByWorkDate(ctx, request.Username, shift.WorkDate)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return "", err
+	}
+
+	if attendance == nil {
+		if tooEarly == nil {
+			tooEarly = model.ThrowError(http.StatusConflict, errors.New("attendance changed while checking in, please tap again"))
+		}
+
+		utils.LogEventError(span, tooEarly)
+		return tooEarly.Error(), tooEarly
 	}
 
+	if attendance.CheckIn.IsZero() {
+		utils.LogEventError(span, fmt.Errorf("you are recorded as %s", attendance.StatusIn))
+		return "You are recorded as " + attendance.StatusIn, model.ThrowError(http.StatusBadRequest, fmt.Errorf("you are recorded as %s", attendance.StatusIn))
+	}
+
+	request.CheckOut = at
+
+	applyCheckOutStatus(request, shift, attendance.CheckIn)
+
 	utils.LogEvent(span, "Request", request)
 
-	err = uc.attendanceClient.CheckIn(ctx, request)
+	err = uc.attendanceClient.CheckOut(ctx, request)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRegistered) {
+			return "You already checked out", model.ThrowError(http.StatusBadRequest, err)
+		}
 
-			request.CheckOut = utils.LocalTime()
+		utils.LogEventError(span, err)
+		return "", err
+	}
 
-			checkOutThreshold, err := uc.paramClient.GetParameterByKey(ctx, "checkout-time")
+	if err := uc.eventClient.Publish(ctx, model.EventCheckOut, "", request); err != nil {
+		utils.LogEventError(span, err)
+	}
+
+	return "Success Check Out", nil
+}
+
+// DetectAbsences records the work dates of today and yesterday whose shift
+// has ended by now: users without attendance are marked Absent and rows never
+// checked out are marked Missing Checkout. Yesterday is checked again for
+// shifts that ended after its own run. Days off and holidays are skipped, and
+// running it twice changes nothing.
+func (uc *AttendanceController) DetectAbsences(ctx context.Context, now time.Time) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: DetectAbsences")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", now)
+
+	absent, missing := 0, 0
+	for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+		workDate := day.Format("2006-01-02")
+
+		usernames, err := uc.attendanceClient.GetUnrecordedUsernames(ctx, workDate)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return err
+		}
+
+		for _, username := range usernames {
+			shift, err := uc.shifts.on(ctx, username, day)
 			if err != nil {
 				utils.LogEventError(span, err)
-				return err.Error(), err
+				continue
+			}
 
+			if !shift.IsWorkingDay() || now.Before(shift.End) {
+				continue
 			}
 
-			parsedTime, err := time.Parse("15:04", checkOutThreshold.Value)
+			err = uc.attendanceClient.MarkAbsent(ctx, &model.Attendance{
+				Username:  username,
+				StatusIn:  model.StatusAbsent,
+				StatusOut: model.StatusAbsent,
+				SourceIn:  model.SourceSystem,
+				SourceOut: model.SourceSystem,
+				WorkDate:  shift.WorkDate,
+				ShiftID:   shift.ShiftID,
+			})
 			if err != nil {
 				utils.LogEventError(span, err)
-				return err.Error(), err
-
+				return err
 			}
+			absent++
+		}
+
+		open, err := uc.attendanceClient.GetOpenAttendances(ctx, workDate)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return err
+		}
 
-			targetTime := time.Date(utils.LocalTime().Year(), utils.LocalTime().Month(), utils.LocalTime().Day(), parsedTime.Hour(), parsedTime.Minute(), 0, 0, time.Local)
-			if utils.LocalTime().Compare(targetTime) == -1 {
-				request.StatusOut = "Early"
-			} else {
-				request.StatusOut = "Normal"
+		for _, v := range open {
+			shift, err := uc.shifts.on(ctx, v.Username, day)
+			if err != nil {
+				utils.LogEventError(span, err)
+				continue
 			}
 
-			utils.LogEvent(span, "Request", request)
+			// Overtime on a day off may run until the end of that day.
+			end := shift.End
+			if !shift.IsWorkingDay() {
+				end = startOfDay(day).AddDate(0, 0, 1)
+			}
 
-			err = uc.attendanceClient.CheckOut(ctx, request)
-			if err != nil {
+			if now.Before(end) {
+				continue
+			}
 
-				if errors.Is(err, gorm.ErrRegistered) {
-					return "You already checked out", model.ThrowError(http.StatusBadRequest, err)
-				}
+			v.StatusOut = model.StatusMissingCheckout
+			v.SourceOut = model.SourceSystem
 
+			if err := uc.attendanceClient.MarkMissingCheckout(ctx, v); err != nil {
 				utils.LogEventError(span, err)
-				return "", err
+				return err
 			}
-			return "Success Check Out", err
+			missing++
 		}
-		utils.LogEventError(span, err)
-		return "", err
 	}
 
-	return "Success Check In", err
+	utils.LogEvent(span, "Response", fmt.Sprintf("%d absent, %d missing checkout", absent, missing))
+
+	return nil
+}
+
+// deviceSignatureWindow is how far a signed device request's timestamp may be
+// from the server's clock.
+const deviceSignatureWindow = 5 * time.Minute
+
+// verifyDeviceCredential accepts either the raw device key or an HMAC-SHA256
+// signature of "<timestamp>.<body>" made within the last five minutes.
+func verifyDeviceCredential(device *model.RFIDDevice, request *model.RequestRFIDTap) bool {
+	if request.Signature == "" {
+		return request.DeviceKey != "" && hmac.Equal([]byte(request.DeviceKey), []byte(device.Secret))
+	}
+
+	ts, err := strconv.ParseInt(request.Timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	if math.Abs(float64(utils.LocalTime().Unix()-ts)) > deviceSignatureWindow.Seconds() {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(device.Secret))
+	mac.Write([]byte(request.Timestamp + "."))
+	mac.Write(request.Body)
+
+	signature, err := hex.DecodeString(request.Signature)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// applyCheckInStatus stamps the check-in with its shift and compares it
+// against the shift start plus its grace period. Check-ins before the shift's
+// check-in window opens are rejected.
+func applyCheckInStatus(request *model.Attendance, shift *model.ShiftInstance) error {
+	if !shift.EarliestCheckIn.IsZero() && request.CheckIn.Before(shift.EarliestCheckIn) {
+		return model.ThrowError(http.StatusBadRequest, fmt.Errorf("check-in opens at %s", shift.EarliestCheckIn.Format("15:04")))
+	}
+
+	request.WorkDate = shift.WorkDate
+	request.ShiftID = shift.ShiftID
+	request.MinutesLate = 0
+
+	grace := time.Duration(shift.GraceMinutes) * time.Minute
+
+	switch {
+	case !shift.IsWorkingDay():
+		request.StatusIn = model.StatusOvertime
+	case !request.CheckIn.After(shift.Start.Add(grace)):
+		request.StatusIn = model.StatusOnTime
+	default:
+		request.StatusIn = model.StatusLate
+		request.MinutesLate = wholeMinutes(request.CheckIn.Sub(shift.Start))
+	}
+
+	return nil
+}
+
+// applyCheckOutStatus matches the check-out to its shift and compares it
+// against the shift end less its grace period. checkIn is the time the user
+// checked in and is used for the worked duration.
+func applyCheckOutStatus(request *model.Attendance, shift *model.ShiftInstance, checkIn time.Time) {
+	request.WorkDate = shift.WorkDate
+	request.MinutesEarly = 0
+	request.WorkedMinutes = wholeMinutes(request.CheckOut.Sub(checkIn))
+	request.OvertimeMinutes = wholeMinutes(request.CheckOut.Sub(shift.End))
+
+	grace := time.Duration(shift.GraceMinutes) * time.Minute
+
+	switch {
+	case !shift.IsWorkingDay():
+		request.StatusOut = model.StatusOvertime
+		request.OvertimeMinutes = request.WorkedMinutes
+	case request.CheckOut.Before(shift.End.Add(-grace)):
+		request.StatusOut = model.StatusEarly
+		request.MinutesEarly = wholeMinutes(shift.End.Sub(request.CheckOut))
+	default:
+		request.StatusOut = model.StatusNormal
+	}
+}
+
+// labelDayType labels a row not falling on a calendar day as an off day when it
+// was worked as overtime, otherwise as a working day.
+func labelDayType(attendance *model.UserAttendance) {
+	if attendance == nil || attendance.DayType != "" {
+		return
+	}
+
+	if attendance.StatusIn == model.StatusOvertime {
+		attendance.DayType = model.DayTypeOffDay
+	} else {
+		attendance.DayType = model.DayTypeWorkingDay
+	}
+}
+
+// wholeMinutes rounds d down to whole minutes, never below 0.
+func wholeMinutes(d time.Duration) int {
+	if d < 0 {
+		return 0
+	}
+
+	return int(d / time.Minute)
 }
@@ -0,0 +1,236 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InterfaceCalendarController interface {
+	GetAllCalendarDays(ctx context.Context, year int) ([]*model.CalendarDay, error)
+	GetCalendarDayByID(ctx context.Context, id string) (*model.CalendarDay, error)
+	CreateNewCalendarDay(ctx context.Context, request *model.CalendarDay) error
+	UpdateCalendarDay(ctx context.Context, request *model.CalendarDay) error
+	DeleteCalendarDay(ctx context.Context, id string) error
+}
+
+type CalendarController struct {
+	calendarClient client.InterfaceCalendarClient
+	scopePolicy    policy.InterfaceScopePolicy
+}
+
+func NewCalendarController(calendarClient client.InterfaceCalendarClient, scopePolicy policy.InterfaceScopePolicy) *CalendarController {
+	return &CalendarController{
+		calendarClient: calendarClient,
+		scopePolicy:    scopePolicy,
+	}
+}
+
+func (c *CalendarController) GetAllCalendarDays(ctx context.Context, year int) ([]*model.CalendarDay, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllCalendarDays")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", year)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.calendarClient.GetAllCalendarDays(ctx, year, scope.InstitutionFilter())
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *CalendarController) GetCalendarDayByID(ctx context.Context, id string) (*model.CalendarDay, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetCalendarDayByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	res, _, err := c.ownedCalendarDay(ctx, id, true)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *CalendarController) CreateNewCalendarDay(ctx context.Context, request *model.CalendarDay) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: CreateNewCalendarDay")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	// Only roles spanning every institution can add shared days.
+	if scope.Scope != model.ScopeAll {
+		request.InstitutionID = scope.InstitutionID
+	}
+
+	if err := validateCalendarDay(request); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.ID = uuid.New().String()
+	request.CreatedAt = utils.LocalTime()
+	request.CreatedBy = session.Username
+	request.UpdatedAt = request.CreatedAt
+	request.UpdatedBy = session.Username
+
+	err = c.calendarClient.CreateNewCalendarDay(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create New Calendar Day")
+
+	return nil
+}
+
+func (c *CalendarController) UpdateCalendarDay(ctx context.Context, request *model.CalendarDay) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: UpdateCalendarDay")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	_, scope, err := c.ownedCalendarDay(ctx, request.ID, false)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if scope.Scope != model.ScopeAll {
+		request.InstitutionID = scope.InstitutionID
+	}
+
+	if err := validateCalendarDay(request); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.UpdatedAt = utils.LocalTime()
+	request.UpdatedBy = session.Username
+
+	err = c.calendarClient.UpdateCalendarDay(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Update Calendar Day")
+
+	return nil
+}
+
+func (c *CalendarController) DeleteCalendarDay(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: DeleteCalendarDay")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	if _, _, err := c.ownedCalendarDay(ctx, id, false); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err := c.calendarClient.DeleteCalendarDay(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Delete Calendar Day")
+
+	return nil
+}
+
+// ownedCalendarDay checks that the caller may read, or else change, the
+// calendar day and returns it with the caller's scope. Shared days are read by
+// everyone but can only be changed by roles spanning every institution.
+func (c *CalendarController) ownedCalendarDay(ctx context.Context, id string, read bool) (*model.CalendarDay, *model.DataScope, error) {
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	day, err := c.calendarClient.GetCalendarDayByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if read && day.InstitutionID == "" {
+		return day, scope, nil
+	}
+
+	if scope.Scope != model.ScopeAll && day.InstitutionID != scope.InstitutionID {
+		if read {
+			return nil, nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to access this calendar day"))
+		}
+		return nil, nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to change this calendar day"))
+	}
+
+	return day, scope, nil
+}
+
+func validateCalendarDay(day *model.CalendarDay) error {
+	if day.Name == "" {
+		return model.ThrowError(http.StatusBadRequest, errors.New("name is required"))
+	}
+
+	if _, err := time.Parse("2006-01-02", day.Date); err != nil {
+		return model.ThrowError(http.StatusBadRequest, errors.New("date must be YYYY-MM-DD"))
+	}
+
+	switch day.Type {
+	case model.CalendarNationalHoliday:
+		if day.InstitutionID != "" {
+			return model.ThrowError(http.StatusBadRequest, errors.New("national holidays can't belong to an institution"))
+		}
+	case model.CalendarInstitutionClosure:
+		if day.InstitutionID == "" {
+			return model.ThrowError(http.StatusBadRequest, errors.New("institution_id is required for an institution closure"))
+		}
+	case model.CalendarCollectiveLeave:
+	default:
+		return model.ThrowError(http.StatusBadRequest, errors.New("type must be one of national_holiday, institution_closure or collective_leave"))
+	}
+
+	return nil
+}
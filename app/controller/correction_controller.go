@@ -0,0 +1,375 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InterfaceCorrectionController interface {
+	GetAllCorrections(ctx context.Context, status string) ([]*model.AttendanceCorrection, error)
+	GetMyCorrections(ctx context.Context) ([]*model.AttendanceCorrection, error)
+	GetCorrectionByID(ctx context.Context, id string) (*model.AttendanceCorrection, error)
+	SubmitCorrection(ctx context.Context, request *model.AttendanceCorrection) error
+	ApproveCorrection(ctx context.Context, id string, request *model.RequestCorrectionDecision) error
+	RejectCorrection(ctx context.Context, id string, request *model.RequestCorrectionDecision) error
+	CancelCorrection(ctx context.Context, id string) error
+}
+
+type CorrectionController struct {
+	correctionClient client.InterfaceCorrectionClient
+	attendanceClient client.InterfaceAttendanceClient
+	scopePolicy      policy.InterfaceScopePolicy
+	shifts           *shiftResolver
+}
+
+func NewCorrectionController(correctionClient client.InterfaceCorrectionClient, attendanceClient client.InterfaceAttendanceClient, paramClient client.InterfaceParamClient, scheduleClient client.InterfaceScheduleClient, calendarClient client.InterfaceCalendarClient, scopePolicy policy.InterfaceScopePolicy) *CorrectionController {
+	return &CorrectionController{
+		correctionClient: correctionClient,
+		attendanceClient: attendanceClient,
+		scopePolicy:      scopePolicy,
+		shifts:           newShiftResolver(scheduleClient, paramClient, calendarClient),
+	}
+}
+
+func (c *CorrectionController) GetAllCorrections(ctx context.Context, status string) ([]*model.AttendanceCorrection, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllCorrections")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", status)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.correctionClient.GetAllCorrections(ctx, scope, status)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *CorrectionController) GetMyCorrections(ctx context.Context) ([]*model.AttendanceCorrection, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetMyCorrections")
+	defer span.Finish()
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.correctionClient.GetAllCorrections(ctx, &model.DataScope{Scope: model.ScopeSelf, Username: session.Username}, "")
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *CorrectionController) GetCorrectionByID(ctx context.Context, id string) (*model.AttendanceCorrection, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetCorrectionByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.correctionClient.GetCorrectionByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if !scope.Allows(res.Username, res.InstitutionID) {
+		utils.LogEventError(span, errors.New("correction not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("correction not found"))
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+// SubmitCorrection files a pending correction of one of the session user's
+// past work dates.
+func (c *CorrectionController) SubmitCorrection(ctx context.Context, request *model.AttendanceCorrection) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: SubmitCorrection")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.Username = session.Username
+
+	if err := validateCorrection(request); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	pending, err := c.correctionClient.HasPendingCorrection(ctx, request.Username, request.WorkDate)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if pending {
+		utils.LogEventError(span, errors.New("you already have a pending correction for this work date"))
+		return model.ThrowError(http.StatusBadRequest, errors.New("you already have a pending correction for this work date"))
+	}
+
+	request.ID = uuid.New().String()
+	request.Status = model.CorrectionPending
+	request.CreatedAt = utils.LocalTime()
+
+	err = c.correctionClient.CreateNewCorrection(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Submit Correction")
+
+	return nil
+}
+
+// ApproveCorrection applies a pending correction to its work date, working the
+// statuses out again against the user's shift that day.
+func (c *CorrectionController) ApproveCorrection(ctx context.Context, id string, request *model.RequestCorrectionDecision) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ApproveCorrection")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	correction, err := c.decidableCorrection(ctx, id, model.CorrectionApproved, request.Note)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	attendance, err := c.correctedAttendance(ctx, correction)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err = c.correctionClient.ApproveCorrection(ctx, correction, attendance)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Approve Correction")
+
+	return nil
+}
+
+func (c *CorrectionController) RejectCorrection(ctx context.Context, id string, request *model.RequestCorrectionDecision) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: RejectCorrection")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	correction, err := c.decidableCorrection(ctx, id, model.CorrectionRejected, request.Note)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err = c.correctionClient.CloseCorrection(ctx, correction)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Reject Correction")
+
+	return nil
+}
+
+// CancelCorrection withdraws one of the session user's own pending
+// corrections.
+func (c *CorrectionController) CancelCorrection(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: CancelCorrection")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	correction, err := c.correctionClient.GetCorrectionByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if correction.Username != session.Username {
+		utils.LogEventError(span, errors.New("correction not found"))
+		return model.ThrowError(http.StatusNotFound, errors.New("correction not found"))
+	}
+
+	now := utils.LocalTime()
+	correction.Status = model.CorrectionCancelled
+	correction.DecidedBy = session.Username
+	correction.DecidedAt = &now
+
+	err = c.correctionClient.CloseCorrection(ctx, correction)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Cancel Correction")
+
+	return nil
+}
+
+// decidableCorrection loads a correction the caller may approve or reject and
+// stamps it with the decision.
+func (c *CorrectionController) decidableCorrection(ctx context.Context, id string, status string, note string) (*model.AttendanceCorrection, error) {
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	correction, err := c.correctionClient.GetCorrectionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scope.CanApprove(correction.Username, correction.InstitutionID) {
+		return nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to decide this correction"))
+	}
+
+	now := utils.LocalTime()
+	correction.Status = status
+	correction.DecidedBy = session.Username
+	correction.DecidedAt = &now
+	correction.DecisionNote = note
+
+	return correction, nil
+}
+
+// correctedAttendance merges a correction into the recorded attendance of its
+// work date and rates the result against the shift of that day.
+func (c *CorrectionController) correctedAttendance(ctx context.Context, correction *model.AttendanceCorrection) (*model.Attendance, error) {
+	current, err := c.attendanceClient.GetAttendanceByWorkDate(ctx, correction.Username, correction.WorkDate)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", correction.WorkDate, utils.LocalTime().Location())
+	if err != nil {
+		return nil, err
+	}
+
+	shift, err := c.shifts.on(ctx, correction.Username, day)
+	if err != nil {
+		return nil, err
+	}
+
+	// The check-in window only applies to live check-ins.
+	shift.EarliestCheckIn = time.Time{}
+
+	attendance := &model.Attendance{
+		Username:    correction.Username,
+		CorrectedBy: correction.DecidedBy,
+		CorrectedAt: correction.DecidedAt,
+	}
+
+	if current != nil {
+		attendance.CheckIn = current.CheckIn
+		attendance.CheckOut = current.CheckOut
+	}
+
+	if correction.CheckIn != nil {
+		attendance.CheckIn = correction.CheckIn.In(day.Location())
+	}
+
+	if correction.CheckOut != nil {
+		attendance.CheckOut = correction.CheckOut.In(day.Location())
+	}
+
+	if attendance.CheckIn.IsZero() {
+		return nil, model.ThrowError(http.StatusBadRequest, errors.New("this work date has no check-in, the correction must include one"))
+	}
+
+	if !attendance.CheckOut.IsZero() && !attendance.CheckOut.After(attendance.CheckIn) {
+		return nil, model.ThrowError(http.StatusBadRequest, errors.New("check_out must be after check_in"))
+	}
+
+	if err := applyCheckInStatus(attendance, shift); err != nil {
+		return nil, err
+	}
+
+	if !attendance.CheckOut.IsZero() {
+		applyCheckOutStatus(attendance, shift, attendance.CheckIn)
+	}
+
+	return attendance, nil
+}
+
+func validateCorrection(correction *model.AttendanceCorrection) error {
+	now := utils.LocalTime()
+
+	day, err := time.ParseInLocation("2006-01-02", correction.WorkDate, now.Location())
+	if err != nil {
+		return model.ThrowError(http.StatusBadRequest, errors.New("work_date must be YYYY-MM-DD"))
+	}
+
+	if day.After(now) {
+		return model.ThrowError(http.StatusBadRequest, errors.New("work_date can't be in the future"))
+	}
+
+	if correction.CheckIn == nil && correction.CheckOut == nil {
+		return model.ThrowError(http.StatusBadRequest, errors.New("check_in or check_out is required"))
+	}
+
+	// Night shifts may end the day after their work date.
+	until := day.AddDate(0, 0, 2)
+	for _, v := range []*time.Time{correction.CheckIn, correction.CheckOut} {
+		if v != nil && (v.Before(day) || !v.Before(until) || v.After(now)) {
+			return model.ThrowError(http.StatusBadRequest, errors.New("check_in and check_out must fall on the work date or the day after, and not in the future"))
+		}
+	}
+
+	if correction.CheckIn != nil && correction.CheckOut != nil && !correction.CheckOut.After(*correction.CheckIn) {
+		return model.ThrowError(http.StatusBadRequest, errors.New("check_out must be after check_in"))
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestValidateCorrection(t *testing.T) {
+	utils.InitTimeLocation()
+	now := utils.LocalTime()
+	day := startOfDay(now).AddDate(0, 0, -10)
+	workDate := day.Format("2006-01-02")
+	soon := now.Add(time.Minute)
+	at := func(days int, hour int, minute int) *time.Time {
+		v := day.AddDate(0, 0, days).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		return &v
+	}
+
+	tests := []struct {
+		name       string
+		correction *model.AttendanceCorrection
+		wantErr    bool
+	}{
+		{name: "check-in and check-out", correction: &model.AttendanceCorrection{WorkDate: workDate, CheckIn: at(0, 8, 0), CheckOut: at(0, 16, 0)}},
+		{name: "check-in only", correction: &model.AttendanceCorrection{WorkDate: workDate, CheckIn: at(0, 8, 0)}},
+		{name: "check-out only", correction: &model.AttendanceCorrection{WorkDate: workDate, CheckOut: at(0, 16, 0)}},
+		{name: "night shift check-out the day after", correction: &model.AttendanceCorrection{WorkDate: workDate, CheckIn: at(0, 22, 0), CheckOut: at(1, 6, 0)}},
+		{name: "today", correction: &model.AttendanceCorrection{WorkDate: now.Format("2006-01-02"), CheckIn: &now}},
+		{name: "invalid work date", correction: &model.AttendanceCorrection{WorkDate: "02/01/2024", CheckIn: at(0, 8, 0)}, wantErr: true},
+		{name: "future work date", correction: &model.AttendanceCorrection{WorkDate: now.AddDate(0, 0, 1).Format("2006-01-02"), CheckIn: at(0, 8, 0)}, wantErr: true},
+		{name: "neither check-in nor check-out", correction: &model.AttendanceCorrection{WorkDate: workDate}, wantErr: true},
+		{name: "check-in before the work date", correction: &model.AttendanceCorrection{WorkDate: workDate, CheckIn: at(-1, 23, 0)}, wantErr: true},
+		{name: "check-out two days after", correction: &model.AttendanceCorrection{WorkDate: workDate, CheckIn: at(0, 8, 0), CheckOut: at(2, 0, 0)}, wantErr: true},
+		{name: "check-in in the future", correction: &model.AttendanceCorrection{WorkDate: now.Format("2006-01-02"), CheckIn: &soon}, wantErr: true},
+		{name: "check-out before check-in", correction: &model.AttendanceCorrection{WorkDate: workDate, CheckIn: at(0, 16, 0), CheckOut: at(0, 8, 0)}, wantErr: true},
+		{name: "check-out at check-in", correction: &model.AttendanceCorrection{WorkDate: workDate, CheckIn: at(0, 8, 0), CheckOut: at(0, 8, 0)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCorrection(tt.correction)
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("validateCorrection() error = %v", err)
+				}
+				return
+			}
+
+			var errResponse *model.ErrorResponse
+			if !errors.As(err, &errResponse) || errResponse.Code != http.StatusBadRequest {
+				t.Errorf("validateCorrection() error = %v, want a 400", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/config"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type InterfaceDatasetController interface {
+	GetAllDatasets(ctx context.Context) ([]*model.Dataset, error)
+	GetDatasetByUsername(ctx context.Context, username string) ([]*model.DatasetURL, error)
+	UploadDataset(ctx context.Context, request *model.Dataset) error
+	DeleteDataset(ctx context.Context, username string) error
+}
+
+type DatasetController struct {
+	cfg           *config.Config
+	storageClient client.InterfaceStorageClient
+	userClient    client.InterfaceUserClient
+	scopePolicy   policy.InterfaceScopePolicy
+}
+
+func NewDatasetController(cfg *config.Config, storageClient client.InterfaceStorageClient, userClient client.InterfaceUserClient, scopePolicy policy.InterfaceScopePolicy) *DatasetController {
+	return &DatasetController{
+		cfg:           cfg,
+		storageClient: storageClient,
+		userClient:    userClient,
+		scopePolicy:   scopePolicy,
+	}
+}
+
+// institutionDatasetPrefix is where the face images of an institution's users
+// are stored, and what its models are trained on.
+func institutionDatasetPrefix(institutionID string) string {
+	return fmt.Sprintf("dataset/%s/", institutionID)
+}
+
+func datasetPrefix(institutionID string, username string) string {
+	return institutionDatasetPrefix(institutionID) + username + "/"
+}
+
+func (c *DatasetController) GetAllDatasets(ctx context.Context) ([]*model.Dataset, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllDatasets")
+	defer span.Finish()
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.storageClient.GetAllDatasets(ctx, scope)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *DatasetController) GetDatasetByUsername(ctx context.Context, username string) ([]*model.DatasetURL, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetDatasetByUsername")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username)
+
+	user, err := c.datasetOwner(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	urls, err := c.storageClient.GetDatasetsByUsername(ctx, c.cfg.MinioProfile.Bucket, datasetPrefix(user.InstitutionID, username))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res := make([]*model.DatasetURL, 0, len(urls))
+	for _, url := range urls {
+		res = append(res, &model.DatasetURL{URL: url})
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *DatasetController) UploadDataset(ctx context.Context, request *model.Dataset) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: UploadDataset")
+	defer span.Finish()
+
+	if len(request.File) == 0 {
+		utils.LogEventError(span, errors.New("no file uploaded"))
+		return model.ThrowError(http.StatusBadRequest, errors.New("no file uploaded"))
+	}
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if request.Username == "" {
+		request.Username = session.Username
+	}
+
+	user, err := c.datasetOwner(ctx, request.Username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.ID = uuid.New().String()
+	request.Bucket = c.cfg.MinioProfile.Bucket
+	request.Dataset = datasetPrefix(user.InstitutionID, request.Username)
+	request.CreatedAt = utils.LocalTime().Format("2006-01-02 15:04:05")
+
+	utils.LogEvent(span, "Request", request)
+
+	for _, file := range request.File {
+		_, err := c.storageClient.UploadFile(ctx, file, request.Bucket, request.Dataset+uuid.New().String())
+		if err != nil {
+			utils.LogEventError(span, err)
+			return err
+		}
+	}
+
+	err = c.storageClient.InsertDatasetDB(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Upload Dataset")
+
+	return nil
+}
+
+func (c *DatasetController) DeleteDataset(ctx context.Context, username string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: DeleteDataset")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username)
+
+	user, err := c.datasetOwner(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err = c.storageClient.DeleteDatasetDB(ctx, nil, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	// The row is gone first, so no row ever points at deleted objects. Objects
+	// left behind by a failed delete are only orphaned files, and a dataset
+	// without objects has nothing to delete.
+	err = c.storageClient.DeleteObject(ctx, c.cfg.MinioProfile.Bucket, datasetPrefix(user.InstitutionID, username))
+	var errResponse *model.ErrorResponse
+	if err != nil && !(errors.As(err, &errResponse) && errResponse.Code == http.StatusNotFound) {
+		utils.LogEventError(span, err)
+	}
+
+	utils.LogEvent(span, "Response", "Success Delete Dataset")
+
+	return nil
+}
+
+// datasetOwner returns the user whose dataset is accessed, if the caller's
+// scope allows it.
+func (c *DatasetController) datasetOwner(ctx context.Context, username string) (*model.User, error) {
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := c.userClient.GetUserDetail(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scope.Allows(user.Username, user.InstitutionID) {
+		return nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to access this user's dataset"))
+	}
+
+	return user, nil
+}
@@ -0,0 +1,536 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultExportSyncLimit is the most rows streamed in the response when the
+// export-sync-limit param is not set; larger exports run as background jobs.
+const defaultExportSyncLimit = 1000
+
+// exportBucket is where background exports are written, under
+// export/<username>/.
+const exportBucket = "bpkp"
+
+// exportURLExpiry is how long the download link of an export stays valid.
+const exportURLExpiry = 2 * time.Hour
+
+// exportTimeout is how long a background export may run. Jobs still running
+// after it, e.g. because their replica stopped, are failed.
+const exportTimeout = 30 * time.Minute
+
+var attendanceHeaders = []string{
+	"Nama Pengguna",
+	"Nama Lengkap",
+	"Tanggal Kerja",
+	"Jenis Hari",
+	"Nama Hari Libur",
+	"Jam Masuk (WIB)",
+	"Status Masuk",
+	"Keterangan Masuk",
+	"Sumber Masuk",
+	"Jam Pulang (WIB)",
+	"Status Pulang",
+	"Keterangan Pulang",
+	"Sumber Pulang",
+	"Terlambat (Menit)",
+	"Pulang Cepat (Menit)",
+	"Durasi Kerja (Menit)",
+	"Dikoreksi Oleh",
+	"Dikoreksi Pada (WIB)",
+}
+
+var summaryHeaders = []string{
+	"Nama Pengguna",
+	"Nama Lengkap",
+	"Bulan",
+	"Tepat Waktu",
+	"Terlambat",
+	"Pulang Cepat",
+	"Tidak Absen Pulang",
+	"Tanpa Keterangan",
+	"Total Terlambat (Menit)",
+	"Total Jam Kerja",
+}
+
+var userHeaders = []string{
+	"Nama Pengguna",
+	"Nama Lengkap",
+	"Nama Panggilan",
+	"Email",
+	"Instansi",
+	"Peran",
+	"Jenis Kelamin",
+	"Agama",
+	"Nomor Telepon",
+	"Alamat",
+	"Tanggal Dibuat (WIB)",
+}
+
+type InterfaceExportController interface {
+	ExportAttendances(ctx context.Context, request *model.RequestUserAttendances, format string) (*model.ExportResult, error)
+	ExportAttendanceSummary(ctx context.Context, request *model.RequestUserAttendances, format string) (*model.ExportResult, error)
+	ExportUsers(ctx context.Context, format string) (*model.ExportResult, error)
+	GetExportJob(ctx context.Context, id string) (*model.ExportJob, error)
+}
+
+// exportSheets queries the rows of an export and builds its sheets.
+type exportSheets func(ctx context.Context) ([]*model.Sheet, error)
+
+type ExportController struct {
+	attendanceClient client.InterfaceAttendanceClient
+	userClient       client.InterfaceUserClient
+	storageClient    client.InterfaceStorageClient
+	paramClient      client.InterfaceParamClient
+	exportClient     client.InterfaceExportClient
+	scopePolicy      policy.InterfaceScopePolicy
+}
+
+func NewExportController(attendanceClient client.InterfaceAttendanceClient, userClient client.InterfaceUserClient, storageClient client.InterfaceStorageClient, paramClient client.InterfaceParamClient, exportClient client.InterfaceExportClient, scopePolicy policy.InterfaceScopePolicy) *ExportController {
+	return &ExportController{
+		attendanceClient: attendanceClient,
+		userClient:       userClient,
+		storageClient:    storageClient,
+		paramClient:      paramClient,
+		exportClient:     exportClient,
+		scopePolicy:      scopePolicy,
+	}
+}
+
+// ExportAttendances exports the attendances GetUserAttendances would list,
+// with a monthly summary sheet in XLSX exports.
+func (c *ExportController) ExportAttendances(ctx context.Context, request *model.RequestUserAttendances, format string) (*model.ExportResult, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ExportAttendances")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	total, err := c.countAttendances(ctx, request, format)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	return c.export(ctx, model.ExportAttendance, format, total, func(ctx context.Context) ([]*model.Sheet, error) {
+		rows, err := c.attendances(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		sheets := []*model.Sheet{attendanceSheet(rows)}
+		if format == model.ExportXLSX {
+			sheets = append(sheets, summarySheet(rows))
+		}
+
+		return sheets, nil
+	})
+}
+
+// ExportAttendanceSummary exports the per-user monthly summary of the
+// attendances GetUserAttendances would list.
+func (c *ExportController) ExportAttendanceSummary(ctx context.Context, request *model.RequestUserAttendances, format string) (*model.ExportResult, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ExportAttendanceSummary")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	total, err := c.countAttendances(ctx, request, format)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	return c.export(ctx, model.ExportAttendanceSummary, format, total, func(ctx context.Context) ([]*model.Sheet, error) {
+		rows, err := c.attendances(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*model.Sheet{summarySheet(rows)}, nil
+	})
+}
+
+// ExportUsers exports the users GetAllUser would list.
+func (c *ExportController) ExportUsers(ctx context.Context, format string) (*model.ExportResult, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ExportUsers")
+	defer span.Finish()
+
+	if err := validateExportFormat(format); err != nil {
+		return nil, err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	_, page, err := c.userClient.GetAllUser(ctx, &model.ListQuery{Scope: scope, Size: 1})
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	return c.export(ctx, model.ExportUser, format, int(page.Total), func(ctx context.Context) ([]*model.Sheet, error) {
+		users, _, err := c.userClient.GetAllUser(ctx, &model.ListQuery{Scope: scope, Unpaged: true})
+		if err != nil {
+			return nil, err
+		}
+
+		sheet := &model.Sheet{Name: "Pengguna", Headers: userHeaders}
+		for _, v := range users {
+			sheet.Rows = append(sheet.Rows, []interface{}{
+				v.Username,
+				v.Fullname,
+				v.Shortname,
+				v.Email,
+				v.InstitutionName,
+				v.RoleName,
+				v.Gender,
+				v.Religion,
+				v.PhoneNumber,
+				v.Address,
+				createdAt(v.CreatedAt),
+			})
+		}
+
+		return []*model.Sheet{sheet}, nil
+	})
+}
+
+// GetExportJob returns one of the session's export jobs, with a download link
+// once it succeeded.
+func (c *ExportController) GetExportJob(ctx context.Context, id string) (*model.ExportJob, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetExportJob")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if err := c.exportClient.FailStaleExportJobs(ctx, utils.LocalTime().Add(-exportTimeout)); err != nil {
+		utils.LogEventError(span, err)
+	}
+
+	job, err := c.exportClient.GetExportJob(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if job.Username != session.Username {
+		utils.LogEventError(span, errors.New("you are not allowed to access this export"))
+		return nil, model.ThrowError(http.StatusUnauthorized, errors.New("you are not allowed to access this export"))
+	}
+
+	if job.Status == model.ExportStatusSucceeded {
+		job.URL, err = c.storageClient.PresignURL(ctx, exportBucket, job.ObjectKey, exportURLExpiry)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+	}
+
+	utils.LogEvent(span, "Response", job)
+
+	return job, nil
+}
+
+// countAttendances scopes request to the session and counts the attendances
+// it exports.
+func (c *ExportController) countAttendances(ctx context.Context, request *model.RequestUserAttendances, format string) (int, error) {
+	if err := validateExportFormat(format); err != nil {
+		return 0, err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	request.Scope = scope
+	legacyFilter(request)
+
+	count := *request
+	count.Page, count.Size, count.Cursor = 0, 1, ""
+
+	_, page, err := c.attendanceClient.GetUserAttendances(ctx, &count)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(page.Total), nil
+}
+
+// attendances lists every attendance of a request scoped by
+// countAttendances.
+func (c *ExportController) attendances(ctx context.Context, request *model.RequestUserAttendances) ([]*model.UserAttendance, error) {
+	all := *request
+	all.Unpaged = true
+
+	rows, _, err := c.attendanceClient.GetUserAttendances(ctx, &all)
+	if err != nil {
+		return nil, err
+	}
+
+	completeAttendances(rows)
+
+	return rows, nil
+}
+
+// export builds small exports, to be streamed, and starts a background job
+// building and writing large ones to object storage. rows is the number of
+// rows the export queries.
+func (c *ExportController) export(ctx context.Context, kind string, format string, rows int, build exportSheets) (*model.ExportResult, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: export")
+	defer span.Finish()
+
+	result := &model.ExportResult{
+		FileName: fmt.Sprintf("%s-%s.%s", kind, utils.LocalTime().Format("20060102150405"), format),
+		Format:   format,
+	}
+
+	limit, err := c.syncLimit(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if rows <= limit {
+		result.Sheets, err = build(ctx)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+
+		return result, nil
+	}
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	job := &model.ExportJob{
+		ID:        uuid.New().String(),
+		Username:  session.Username,
+		Kind:      kind,
+		Format:    format,
+		Status:    model.ExportStatusRunning,
+		Rows:      rows,
+		CreatedAt: utils.LocalTime(),
+	}
+
+	if err := c.exportClient.CreateExportJob(ctx, job); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	// The job outlives the request, so it doesn't inherit its context.
+	go c.run(job, build)
+
+	result.Job = job
+
+	return result, nil
+}
+
+// run builds a background export within exportTimeout, writes it to object
+// storage and records how it went.
+func (c *ExportController) run(job *model.ExportJob, build exportSheets) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	span, ctx := utils.SpanFromContext(ctx, "Controller: runExport")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", job)
+
+	var buffer bytes.Buffer
+
+	sheets, err := build(ctx)
+	if err == nil {
+		err = utils.WriteSheets(&buffer, job.Format, sheets)
+	}
+
+	if err == nil {
+		path := fmt.Sprintf("export/%s/%s", job.Username, job.ID)
+		_, err = c.storageClient.UploadFile(ctx, &model.File{BytesObject: buffer.Bytes(), Extension: job.Format}, exportBucket, path)
+		job.ObjectKey = path + "." + job.Format
+	}
+
+	finishedAt := utils.LocalTime()
+	job.FinishedAt = &finishedAt
+	job.Status = model.ExportStatusSucceeded
+
+	if err != nil {
+		utils.LogEventError(span, err)
+		job.Status = model.ExportStatusFailed
+		job.ObjectKey = ""
+		job.Error = err.Error()
+	}
+
+	// The outcome is recorded even when the deadline was what failed the job.
+	if err := c.exportClient.FinishExportJob(context.WithoutCancel(ctx), job); err != nil {
+		utils.LogEventError(span, err)
+	}
+}
+
+func (c *ExportController) syncLimit(ctx context.Context) (int, error) {
+	param, err := c.paramClient.GetParameterByKey(ctx, "export-sync-limit")
+	if err != nil {
+		return 0, err
+	}
+
+	if param == nil || param.Value == "" {
+		return defaultExportSyncLimit, nil
+	}
+
+	value, err := strconv.Atoi(param.Value)
+	if err != nil {
+		return 0, model.ThrowError(http.StatusInternalServerError, fmt.Errorf("invalid export-sync-limit param %q", param.Value))
+	}
+
+	return value, nil
+}
+
+func validateExportFormat(format string) error {
+	if format != model.ExportCSV && format != model.ExportXLSX {
+		return model.ThrowError(http.StatusBadRequest, errors.New("format must be csv or xlsx"))
+	}
+
+	return nil
+}
+
+func attendanceSheet(rows []*model.UserAttendance) *model.Sheet {
+	sheet := &model.Sheet{Name: "Kehadiran", Headers: attendanceHeaders}
+
+	for _, v := range rows {
+		sheet.Rows = append(sheet.Rows, []interface{}{
+			v.Username,
+			v.Fullname,
+			v.WorkDate,
+			v.DayType,
+			v.HolidayName,
+			v.CheckIn,
+			v.StatusIn,
+			v.RemarkIn,
+			v.SourceIn,
+			v.CheckOut,
+			v.StatusOut,
+			v.RemarkOut,
+			v.SourceOut,
+			v.MinutesLate,
+			v.MinutesEarly,
+			v.WorkedMinutes,
+			v.CorrectedBy,
+			v.CorrectedAt,
+		})
+	}
+
+	return sheet
+}
+
+// summarySheet totals rows per user and month, counting statuses the same way
+// as the attendance recap.
+func summarySheet(rows []*model.UserAttendance) *model.Sheet {
+	type key struct{ username, month string }
+
+	var keys []key
+	fullnames := map[string]string{}
+	totals := map[key]*model.RecapCounts{}
+
+	for _, v := range rows {
+		month := v.WorkDate
+		if len(month) >= 7 {
+			month = month[:7]
+		}
+
+		k := key{v.Username, month}
+		counts, ok := totals[k]
+		if !ok {
+			counts = &model.RecapCounts{}
+			totals[k] = counts
+			keys = append(keys, k)
+		}
+
+		fullnames[v.Username] = v.Fullname
+
+		counts.Add(model.RecapCounts{
+			OnTime:          boolCount(v.StatusIn == model.StatusOnTime),
+			Late:            boolCount(v.StatusIn == model.StatusLate),
+			EarlyLeave:      boolCount(v.StatusOut == model.StatusEarly),
+			MissingCheckout: boolCount(v.StatusOut == model.StatusMissingCheckout),
+			NoRecord:        boolCount(v.StatusIn == model.StatusAbsent),
+			MinutesLate:     v.MinutesLate,
+			WorkedMinutes:   v.WorkedMinutes,
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].username != keys[j].username {
+			return keys[i].username < keys[j].username
+		}
+
+		return keys[i].month < keys[j].month
+	})
+
+	sheet := &model.Sheet{Name: "Rekap Bulanan", Headers: summaryHeaders}
+
+	for _, k := range keys {
+		counts := totals[k]
+		sheet.Rows = append(sheet.Rows, []interface{}{
+			k.username,
+			fullnames[k.username],
+			k.month,
+			counts.OnTime,
+			counts.Late,
+			counts.EarlyLeave,
+			counts.MissingCheckout,
+			counts.NoRecord,
+			counts.MinutesLate,
+			workedHours(counts.WorkedMinutes),
+		})
+	}
+
+	return sheet
+}
+
+func boolCount(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// createdAt returns a user's creation time as a time in WIB when it parses,
+// otherwise as stored.
+func createdAt(value string) interface{} {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", value, utils.LocalTime().Location()); err == nil {
+		return t
+	}
+
+	return value
+}
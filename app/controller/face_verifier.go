@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultFaceConfidence is the confidence a selfie must be matched with when
+// the face-confidence-threshold param is not set.
+const defaultFaceConfidence = 0.8
+
+var selfieExtensions = map[string]bool{
+	"jpg":  true,
+	"jpeg": true,
+	"png":  true,
+}
+
+// faceVerifier checks check-in selfies against the active face model of the
+// user's institution.
+type faceVerifier struct {
+	// bucket is where selfies are stored, under attendance-selfie/<username>/.
+	bucket         string
+	userClient     client.InterfaceUserClient
+	trainingClient client.InterfaceTrainingClient
+	storageClient  client.InterfaceStorageClient
+	paramClient    client.InterfaceParamClient
+}
+
+// verify stores the request's selfie and asks the processing service whose
+// face it shows. It sets SelfieIn and FaceScoreIn when the face model matches
+// the selfie to the requesting user with enough confidence.
+func (f *faceVerifier) verify(ctx context.Context, request *model.Attendance) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: faceVerifier.verify")
+	defer span.Finish()
+
+	if request.Selfie == nil {
+		return model.ThrowError(http.StatusBadRequest, errors.New("selfie is required"))
+	}
+
+	extension := strings.ToLower(request.Selfie.Extension)
+	if !selfieExtensions[extension] {
+		return model.ThrowError(http.StatusBadRequest, errors.New("selfie must be a jpg or png image"))
+	}
+
+	user, err := f.userClient.GetUserDetail(ctx, request.Username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	trainings, err := f.trainingClient.GetModelTrainings(ctx, &model.FilterModelTraining{
+		InstitutionID: user.InstitutionID,
+		Status:        model.TrainingStatusSucceeded,
+		IsUsed:        "1",
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if len(trainings) == 0 {
+		utils.LogEventError(span, errors.New("no active face model"))
+		return model.ThrowError(http.StatusConflict, errors.New("your institution has no active face model"))
+	}
+
+	threshold, err := f.threshold(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	path := fmt.Sprintf("attendance-selfie/%s/%s", request.Username, utils.LocalTime().Format("20060102150405"))
+	request.Selfie.Extension = extension
+
+	url, err := f.storageClient.UploadFile(ctx, request.Selfie, f.bucket, path)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	res, err := f.trainingClient.RequestRecognize(ctx, &model.RequestAPIRecognize{
+		ID:         trainings[0].ID,
+		BucketName: f.bucket,
+		Key:        path + "." + extension,
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	if res.Data.Username != request.Username || res.Data.Confidence < threshold {
+		return model.ThrowError(http.StatusForbidden, errors.New("your face could not be verified, please retake the selfie"))
+	}
+
+	confidence := res.Data.Confidence
+	request.SelfieIn = url
+	request.FaceScoreIn = &confidence
+
+	return nil
+}
+
+func (f *faceVerifier) threshold(ctx context.Context) (float64, error) {
+	param, err := f.paramClient.GetParameterByKey(ctx, "face-confidence-threshold")
+	if err != nil {
+		return 0, err
+	}
+
+	if param == nil || param.Value == "" {
+		return defaultFaceConfidence, nil
+	}
+
+	value, err := strconv.ParseFloat(param.Value, 64)
+	if err != nil {
+		return 0, model.ThrowError(http.StatusInternalServerError, fmt.Errorf("invalid face-confidence-threshold param %q", param.Value))
+	}
+
+	return value, nil
+}
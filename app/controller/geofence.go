@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultMaxAccuracy is the worst GPS accuracy, in meters, accepted as being
+// inside a geofence when the geofence-max-accuracy param is not set.
+const defaultMaxAccuracy = 100
+
+// earthRadius is the mean radius of the earth in meters.
+const earthRadius = 6371000
+
+// geofence checks located check-ins against the office locations of the
+// user's institution. Outside check-ins are rejected, or only flagged when the
+// geofence-mode param is "flag".
+type geofence struct {
+	geofenceClient client.InterfaceGeofenceClient
+	paramClient    client.InterfaceParamClient
+}
+
+// check returns the geofence result of a check-in or check-out made at the
+// given point, which it must carry.
+func (g *geofence) check(ctx context.Context, username string, latitude *float64, longitude *float64, accuracy *float64, at time.Time) (string, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: geofence.check")
+	defer span.Finish()
+
+	if latitude == nil || longitude == nil {
+		return "", model.ThrowError(http.StatusBadRequest, errors.New("latitude and longitude are required"))
+	}
+
+	if *latitude < -90 || *latitude > 90 || *longitude < -180 || *longitude > 180 || (accuracy != nil && *accuracy < 0) {
+		return "", model.ThrowError(http.StatusBadRequest, errors.New("invalid latitude, longitude or accuracy"))
+	}
+
+	exemption, err := g.geofenceClient.GetActiveExemption(ctx, username, at.Format("2006-01-02"))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return "", err
+	}
+
+	if exemption != nil {
+		return model.GeofenceExempt, nil
+	}
+
+	locations, err := g.geofenceClient.GetUserLocations(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return "", err
+	}
+
+	if len(locations) == 0 {
+		return model.GeofenceUnchecked, nil
+	}
+
+	maxAccuracy, err := g.intParam(ctx, "geofence-max-accuracy", defaultMaxAccuracy)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return "", err
+	}
+
+	if accuracy == nil || *accuracy <= float64(maxAccuracy) {
+		for _, v := range locations {
+			if distance(*latitude, *longitude, v.Latitude, v.Longitude) <= float64(v.RadiusMeters) {
+				utils.LogEvent(span, "Response", "inside "+v.Name)
+				return model.GeofenceInside, nil
+			}
+		}
+	}
+
+	mode, err := g.paramClient.GetParameterByKey(ctx, "geofence-mode")
+	if err != nil {
+		utils.LogEventError(span, err)
+		return "", err
+	}
+
+	if mode != nil && mode.Value == "flag" {
+		utils.LogEvent(span, "Response", model.GeofenceOutside)
+		return model.GeofenceOutside, nil
+	}
+
+	if accuracy != nil && *accuracy > float64(maxAccuracy) {
+		return "", model.ThrowError(http.StatusForbidden, fmt.Errorf("your location is not accurate enough (%.0f m, at most %d m)", *accuracy, maxAccuracy))
+	}
+
+	return "", model.ThrowError(http.StatusForbidden, errors.New("you are outside your institution's check-in area"))
+}
+
+func (g *geofence) intParam(ctx context.Context, key string, fallback int) (int, error) {
+	param, err := g.paramClient.GetParameterByKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if param == nil || param.Value == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.Atoi(param.Value)
+	if err != nil {
+		return 0, model.ThrowError(http.StatusInternalServerError, fmt.Errorf("invalid %s param %q", key, param.Value))
+	}
+
+	return value, nil
+}
+
+// distance returns the great-circle distance in meters between two points.
+func distance(lat1 float64, lng1 float64, lat2 float64, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+type InterfaceGeofenceController interface {
+	GetAllExemptions(ctx context.Context) ([]*model.GeofenceExemption, error)
+	SaveExemption(ctx context.Context, exemption *model.GeofenceExemption) error
+	DeleteExemption(ctx context.Context, username string) error
+}
+
+type GeofenceController struct {
+	geofenceClient client.InterfaceGeofenceClient
+	userClient     client.InterfaceUserClient
+	scopePolicy    policy.InterfaceScopePolicy
+}
+
+func NewGeofenceController(geofenceClient client.InterfaceGeofenceClient, userClient client.InterfaceUserClient, scopePolicy policy.InterfaceScopePolicy) *GeofenceController {
+	return &GeofenceController{
+		geofenceClient: geofenceClient,
+		userClient:     userClient,
+		scopePolicy:    scopePolicy,
+	}
+}
+
+func (c *GeofenceController) GetAllExemptions(ctx context.Context) ([]*model.GeofenceExemption, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllExemptions")
+	defer span.Finish()
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.geofenceClient.GetAllExemptions(ctx, scope.InstitutionFilter())
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *GeofenceController) SaveExemption(ctx context.Context, exemption *model.GeofenceExemption) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: SaveExemption")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", exemption)
+
+	if exemption.Username == "" {
+		return model.ThrowError(http.StatusBadRequest, errors.New("username is required"))
+	}
+
+	if exemption.ValidUntil != nil {
+		if _, err := time.Parse("2006-01-02", *exemption.ValidUntil); err != nil {
+			return model.ThrowError(http.StatusBadRequest, errors.New("valid_until must be a date (YYYY-MM-DD)"))
+		}
+	}
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if err := c.managedUser(ctx, exemption.Username); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	exemption.CreatedAt = utils.LocalTime()
+	exemption.CreatedBy = session.Username
+
+	err = c.geofenceClient.SaveExemption(ctx, exemption)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *GeofenceController) DeleteExemption(ctx context.Context, username string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: DeleteExemption")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username)
+
+	if err := c.managedUser(ctx, username); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err := c.geofenceClient.DeleteExemption(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	return nil
+}
+
+// managedUser checks that the session may manage the exemption of username,
+// which takes the same rights as approving the user's requests.
+func (c *GeofenceController) managedUser(ctx context.Context, username string) error {
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := c.userClient.GetUserDetail(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if !scope.CanApprove(user.Username, user.InstitutionID) {
+		return model.ThrowError(http.StatusUnauthorized, errors.New("you are not allowed to manage this user's exemption (out of role scope)"))
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fakeGeofenceClient serves the same locations and exemption to every user.
+type fakeGeofenceClient struct {
+	client.InterfaceGeofenceClient
+	locations []*model.InstitutionLocation
+	exemption *model.GeofenceExemption
+}
+
+func (f *fakeGeofenceClient) GetUserLocations(ctx context.Context, username string) ([]*model.InstitutionLocation, error) {
+	return f.locations, nil
+}
+
+func (f *fakeGeofenceClient) GetActiveExemption(ctx context.Context, username string, date string) (*model.GeofenceExemption, error) {
+	return f.exemption, nil
+}
+
+func TestDistance(t *testing.T) {
+	// oneDegree is the length of a degree of a great circle.
+	oneDegree := 2 * math.Pi * earthRadius / 360
+
+	tests := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		want                   float64
+	}{
+		{name: "same point", lat1: -6.2, lng1: 106.8, lat2: -6.2, lng2: 106.8, want: 0},
+		{name: "one degree along the equator", lat1: 0, lng1: 0, lat2: 0, lng2: 1, want: oneDegree},
+		{name: "one degree along a meridian", lat1: -6.5, lng1: 106.8, lat2: -5.5, lng2: 106.8, want: oneDegree},
+		{name: "across the antimeridian", lat1: 0, lng1: 179.5, lat2: 0, lng2: -179.5, want: oneDegree},
+		{name: "pole to pole", lat1: 90, lng1: 0, lat2: -90, lng2: 0, want: math.Pi * earthRadius},
+		{name: "antipodes", lat1: 0, lng1: 0, lat2: 0, lng2: 180, want: math.Pi * earthRadius},
+		{name: "a longitude degree shrinks away from the equator", lat1: 60, lng1: 0, lat2: 60, lng2: 1, want: 55597.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := distance(tt.lat1, tt.lng1, tt.lat2, tt.lng2)
+			if math.Abs(got-tt.want) > 1 {
+				t.Errorf("distance() = %.1f m, want %.1f m", got, tt.want)
+			}
+
+			if back := distance(tt.lat2, tt.lng2, tt.lat1, tt.lng1); math.Abs(back-got) > 1e-6 {
+				t.Errorf("distance() is %.1f m one way and %.1f m back", got, back)
+			}
+		})
+	}
+}
+
+func TestGeofenceCheck(t *testing.T) {
+	office := &model.InstitutionLocation{ID: "loc-1", Name: "Office", Latitude: -6.2, Longitude: 106.8, RadiusMeters: 100}
+	annex := &model.InstitutionLocation{ID: "loc-2", Name: "Annex", Latitude: -6.3, Longitude: 106.9, RadiusMeters: 50}
+
+	// Moving 0.0005 degrees north is about 56 m, 0.002 degrees about 222 m.
+	inside, outside := -6.2+0.0005, -6.2+0.002
+	point := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		locations []*model.InstitutionLocation
+		exemption *model.GeofenceExemption
+		params    map[string]string
+		latitude  *float64
+		longitude *float64
+		accuracy  *float64
+		want      string
+		wantCode  int
+	}{
+		{name: "missing point", locations: []*model.InstitutionLocation{office}, latitude: point(inside), wantCode: http.StatusBadRequest},
+		{name: "latitude out of range", locations: []*model.InstitutionLocation{office}, latitude: point(91), longitude: point(106.8), wantCode: http.StatusBadRequest},
+		{name: "longitude out of range", locations: []*model.InstitutionLocation{office}, latitude: point(inside), longitude: point(-181), wantCode: http.StatusBadRequest},
+		{name: "negative accuracy", locations: []*model.InstitutionLocation{office}, latitude: point(inside), longitude: point(106.8), accuracy: point(-1), wantCode: http.StatusBadRequest},
+		{name: "exempt user anywhere", locations: []*model.InstitutionLocation{office}, exemption: &model.GeofenceExemption{Username: "user"}, latitude: point(0), longitude: point(0), want: model.GeofenceExempt},
+		{name: "institution without locations", latitude: point(0), longitude: point(0), want: model.GeofenceUnchecked},
+		{name: "inside the radius", locations: []*model.InstitutionLocation{office}, latitude: point(inside), longitude: point(106.8), accuracy: point(20), want: model.GeofenceInside},
+		{name: "inside without an accuracy", locations: []*model.InstitutionLocation{office}, latitude: point(inside), longitude: point(106.8), want: model.GeofenceInside},
+		{name: "inside another location", locations: []*model.InstitutionLocation{office, annex}, latitude: point(-6.3), longitude: point(106.9), want: model.GeofenceInside},
+		{name: "outside is rejected", locations: []*model.InstitutionLocation{office, annex}, latitude: point(outside), longitude: point(106.8), wantCode: http.StatusForbidden},
+		{name: "outside is flagged in flag mode", locations: []*model.InstitutionLocation{office}, params: map[string]string{"geofence-mode": "flag"}, latitude: point(outside), longitude: point(106.8), want: model.GeofenceOutside},
+		{name: "inaccurate fix is rejected", locations: []*model.InstitutionLocation{office}, latitude: point(inside), longitude: point(106.8), accuracy: point(150), wantCode: http.StatusForbidden},
+		{name: "inaccurate fix is flagged in flag mode", locations: []*model.InstitutionLocation{office}, params: map[string]string{"geofence-mode": "flag"}, latitude: point(inside), longitude: point(106.8), accuracy: point(150), want: model.GeofenceOutside},
+		{name: "max accuracy from the param", locations: []*model.InstitutionLocation{office}, params: map[string]string{"geofence-max-accuracy": "200"}, latitude: point(inside), longitude: point(106.8), accuracy: point(150), want: model.GeofenceInside},
+		{name: "invalid max accuracy param", locations: []*model.InstitutionLocation{office}, params: map[string]string{"geofence-max-accuracy": "far"}, latitude: point(inside), longitude: point(106.8), wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &geofence{
+				geofenceClient: &fakeGeofenceClient{locations: tt.locations, exemption: tt.exemption},
+				paramClient:    &fakeParamClient{params: tt.params},
+			}
+
+			got, err := g.check(context.Background(), "user", tt.latitude, tt.longitude, tt.accuracy, time.Now())
+			if tt.wantCode != 0 {
+				var errResponse *model.ErrorResponse
+				if !errors.As(err, &errResponse) || errResponse.Code != tt.wantCode {
+					t.Fatalf("check() error = %v, want a %d", err, tt.wantCode)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("check() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
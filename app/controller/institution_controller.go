@@ -3,14 +3,17 @@ package controller
 import (
 	"bpkp-svc-portal/app/client"
 	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
 	"bpkp-svc-portal/app/utils"
 	"context"
+	"errors"
+	"net/http"
 
 	"github.com/google/uuid"
 )
 
 type InterfaceInstitutionController interface {
-	GetAllInstitution(ctx context.Context) ([]*model.Institution, error)
+	GetAllInstitution(ctx context.Context, query *model.ListQuery) ([]*model.Institution, *model.ListPage, error)
 	GetInstitutionByID(ctx context.Context, id string) (*model.Institution, error)
 	InsertNewInstitution(ctx context.Context, institution *model.Institution) error
 	UpdateInstitution(ctx context.Context, institution *model.Institution) error
@@ -19,23 +22,33 @@ type InterfaceInstitutionController interface {
 
 type InstitutionController struct {
 	institutionClient client.InterfaceInstitutionClient
+	scopePolicy       policy.InterfaceScopePolicy
 }
 
-func NewInstitutionController(institutionClient client.InterfaceInstitutionClient) *InstitutionController {
-	return &InstitutionController{institutionClient: institutionClient}
+func NewInstitutionController(institutionClient client.InterfaceInstitutionClient, scopePolicy policy.InterfaceScopePolicy) *InstitutionController {
+	return &InstitutionController{
+		institutionClient: institutionClient,
+		scopePolicy:       scopePolicy,
+	}
 }
 
-func (c *InstitutionController) GetAllInstitution(ctx context.Context) ([]*model.Institution, error) {
+func (c *InstitutionController) GetAllInstitution(ctx context.Context, query *model.ListQuery) ([]*model.Institution, *model.ListPage, error) {
 	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllInstitution")
 	defer span.Finish()
 
-	res, err := c.institutionClient.GetAllInstitutions(ctx)
+	scope, err := c.scopePolicy.Resolve(ctx)
 	if err != nil {
 		utils.LogEventError(span, err)
-		return nil, err
+		return nil, nil, err
 	}
 
-	return res, nil
+	res, page, err := c.institutionClient.GetAllInstitutions(ctx, scope.InstitutionFilter(), query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	return res, page, nil
 }
 
 func (c *InstitutionController) GetInstitutionByID(ctx context.Context, id string) (*model.Institution, error) {
@@ -43,6 +56,18 @@ func (c *InstitutionController) GetInstitutionByID(ctx context.Context, id strin
 	defer span.Finish()
 
 	utils.LogEvent(span, "Request", id)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if !scope.AllowsInstitution(id) {
+		utils.LogEventError(span, errors.New("you are not allowed to access this data (out of role scope)"))
+		return nil, model.ThrowError(http.StatusUnauthorized, errors.New("you are not allowed to access this data (out of role scope)"))
+	}
+
 	res, err := c.institutionClient.GetInstitutionByID(ctx, id)
 	if err != nil {
 		utils.LogEventError(span, err)
@@ -62,6 +87,11 @@ func (c *InstitutionController) InsertNewInstitution(ctx context.Context, instit
 		utils.LogEventError(span, err)
 		return err
 	}
+	if err := validateLocations(institution); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
 	institution.ID = uuid.New().String()
 	institution.CreatedAt = utils.LocalTime().Format("2006-01-02 15:04:05")
 	institution.CreatedBy = session.Username
@@ -82,7 +112,24 @@ func (c *InstitutionController) UpdateInstitution(ctx context.Context, instituti
 	defer span.Finish()
 
 	utils.LogEvent(span, "Request", institution)
-	err := c.institutionClient.UpdateInstitution(ctx, institution)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if !scope.AllowsInstitution(institution.ID) {
+		utils.LogEventError(span, errors.New("you are not allowed to change this institution (out of role scope)"))
+		return model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to change this institution (out of role scope)"))
+	}
+
+	if err := validateLocations(institution); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err = c.institutionClient.UpdateInstitution(ctx, institution)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
@@ -97,7 +144,19 @@ func (c *InstitutionController) DeleteInstitution(ctx context.Context, id string
 	defer span.Finish()
 
 	utils.LogEvent(span, "Request", id)
-	err := c.institutionClient.DeleteInstitution(ctx, id)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if !scope.AllowsInstitution(id) {
+		utils.LogEventError(span, errors.New("you are not allowed to change this institution (out of role scope)"))
+		return model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to change this institution (out of role scope)"))
+	}
+
+	err = c.institutionClient.DeleteInstitution(ctx, id)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
@@ -106,3 +165,23 @@ func (c *InstitutionController) DeleteInstitution(ctx context.Context, id string
 	utils.LogEvent(span, "Response", "Success Delete Institution")
 	return nil
 }
+
+// validateLocations checks an institution's office locations and gives new
+// ones an id.
+func validateLocations(institution *model.Institution) error {
+	for _, v := range institution.Locations {
+		if v.Latitude < -90 || v.Latitude > 90 || v.Longitude < -180 || v.Longitude > 180 {
+			return model.ThrowError(http.StatusBadRequest, errors.New("latitude must be between -90 and 90 and longitude between -180 and 180"))
+		}
+
+		if v.RadiusMeters <= 0 {
+			return model.ThrowError(http.StatusBadRequest, errors.New("radius_meters must be positive"))
+		}
+
+		if v.ID == "" {
+			v.ID = uuid.New().String()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,498 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultLeaveQuota is the yearly quota of a leave type whose quota param is
+// not set.
+const defaultLeaveQuota = 12
+
+type InterfaceLeaveController interface {
+	GetAllLeaves(ctx context.Context, status string) ([]*model.LeaveRequest, error)
+	GetMyLeaves(ctx context.Context) ([]*model.LeaveRequest, error)
+	GetLeaveByID(ctx context.Context, id string) (*model.LeaveRequest, error)
+	SubmitLeave(ctx context.Context, request *model.LeaveRequest) error
+	ApproveLeave(ctx context.Context, id string, request *model.RequestLeaveDecision) error
+	RejectLeave(ctx context.Context, id string, request *model.RequestLeaveDecision) error
+	CancelLeave(ctx context.Context, id string) error
+
+	GetLeaveBalances(ctx context.Context, username string, year int) ([]*model.LeaveBalance, error)
+}
+
+type LeaveController struct {
+	leaveClient   client.InterfaceLeaveClient
+	userClient    client.InterfaceUserClient
+	storageClient client.InterfaceStorageClient
+	paramClient   client.InterfaceParamClient
+	scopePolicy   policy.InterfaceScopePolicy
+	shifts        *shiftResolver
+}
+
+func NewLeaveController(leaveClient client.InterfaceLeaveClient, userClient client.InterfaceUserClient, storageClient client.InterfaceStorageClient, paramClient client.InterfaceParamClient, scheduleClient client.InterfaceScheduleClient, calendarClient client.InterfaceCalendarClient, scopePolicy policy.InterfaceScopePolicy) *LeaveController {
+	return &LeaveController{
+		leaveClient:   leaveClient,
+		userClient:    userClient,
+		storageClient: storageClient,
+		paramClient:   paramClient,
+		scopePolicy:   scopePolicy,
+		shifts:        newShiftResolver(scheduleClient, paramClient, calendarClient),
+	}
+}
+
+func (c *LeaveController) GetAllLeaves(ctx context.Context, status string) ([]*model.LeaveRequest, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllLeaves")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", status)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.leaveClient.GetAllLeaves(ctx, scope, status)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *LeaveController) GetMyLeaves(ctx context.Context) ([]*model.LeaveRequest, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetMyLeaves")
+	defer span.Finish()
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.leaveClient.GetAllLeaves(ctx, &model.DataScope{Scope: model.ScopeSelf, Username: session.Username}, "")
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *LeaveController) GetLeaveByID(ctx context.Context, id string) (*model.LeaveRequest, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetLeaveByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.leaveClient.GetLeaveByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if !scope.Allows(res.Username, res.InstitutionID) {
+		utils.LogEventError(span, errors.New("leave request not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("leave request not found"))
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+// SubmitLeave files a pending leave request for the session's user. Only the
+// working days in the range count towards it and its balance.
+func (c *LeaveController) SubmitLeave(ctx context.Context, request *model.LeaveRequest) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: SubmitLeave")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.Username = session.Username
+	request.Attachment = ""
+
+	if err := validateLeave(request); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	overlaps, err := c.leaveClient.HasOverlappingLeave(ctx, request.Username, request.StartDate, request.EndDate)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if overlaps {
+		utils.LogEventError(span, errors.New("you already have a leave request on these dates"))
+		return model.ThrowError(http.StatusBadRequest, errors.New("you already have a leave request on these dates"))
+	}
+
+	if err := c.countWorkDates(ctx, request); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if err := c.checkBalance(ctx, request); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.ID = uuid.New().String()
+	request.Status = model.LeavePending
+	request.CreatedAt = utils.LocalTime()
+
+	if request.File != nil {
+		request.Attachment, err = c.storageClient.UploadFile(ctx, request.File, "bpkp", fmt.Sprintf("%s/%s/%s", "leave-attachment", request.Username, request.ID))
+		if err != nil {
+			utils.LogEventError(span, err)
+			return err
+		}
+	}
+
+	err = c.leaveClient.CreateNewLeave(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Submit Leave")
+
+	return nil
+}
+
+// ApproveLeave approves a pending request. Its working days are counted again
+// so holidays added since it was submitted are not taken from the balance.
+func (c *LeaveController) ApproveLeave(ctx context.Context, id string, request *model.RequestLeaveDecision) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ApproveLeave")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	leave, err := c.decidableLeave(ctx, id, model.LeaveApproved, request.Note)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if err := c.countWorkDates(ctx, leave); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if err := c.openBalances(ctx, leave.Username, leave.StartDate); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err = c.leaveClient.ApproveLeave(ctx, leave)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Approve Leave")
+
+	return nil
+}
+
+func (c *LeaveController) RejectLeave(ctx context.Context, id string, request *model.RequestLeaveDecision) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: RejectLeave")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	leave, err := c.decidableLeave(ctx, id, model.LeaveRejected, request.Note)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err = c.leaveClient.CloseLeave(ctx, leave)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Reject Leave")
+
+	return nil
+}
+
+// CancelLeave withdraws one of the session user's own pending requests.
+func (c *LeaveController) CancelLeave(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: CancelLeave")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	leave, err := c.leaveClient.GetLeaveByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if leave.Username != session.Username {
+		utils.LogEventError(span, errors.New("leave request not found"))
+		return model.ThrowError(http.StatusNotFound, errors.New("leave request not found"))
+	}
+
+	now := utils.LocalTime()
+	leave.Status = model.LeaveCancelled
+	leave.DecidedBy = session.Username
+	leave.DecidedAt = &now
+
+	err = c.leaveClient.CloseLeave(ctx, leave)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Cancel Leave")
+
+	return nil
+}
+
+// GetLeaveBalances returns a user's balances for a year, the session's own
+// user and the current year when empty.
+func (c *LeaveController) GetLeaveBalances(ctx context.Context, username string, year int) ([]*model.LeaveBalance, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetLeaveBalances")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", fmt.Sprintf("%s %d", username, year))
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if username != "" && username != session.Username {
+		scope, err := c.scopePolicy.Resolve(ctx)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+
+		user, err := c.userClient.GetUserDetail(ctx, username)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+
+		if !scope.Allows(user.Username, user.InstitutionID) {
+			utils.LogEventError(span, errors.New("you are not allowed to access this data (out of role scope)"))
+			return nil, model.ThrowError(http.StatusUnauthorized, errors.New("you are not allowed to access this data (out of role scope)"))
+		}
+	} else {
+		username = session.Username
+	}
+
+	if year == 0 {
+		year = utils.LocalTime().Year()
+	}
+
+	if err := c.openBalances(ctx, username, strconv.Itoa(year)); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.leaveClient.GetLeaveBalances(ctx, username, year)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+// decidableLeave loads a leave request the caller may approve or reject and
+// stamps it with the decision. Approvers must reach the requester's
+// institution through their role's scope and can't decide their own requests.
+func (c *LeaveController) decidableLeave(ctx context.Context, id string, status string, note string) (*model.LeaveRequest, error) {
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	leave, err := c.leaveClient.GetLeaveByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scope.CanApprove(leave.Username, leave.InstitutionID) {
+		return nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to decide this leave request"))
+	}
+
+	now := utils.LocalTime()
+	leave.Status = status
+	leave.DecidedBy = session.Username
+	leave.DecidedAt = &now
+	leave.DecisionNote = note
+
+	return leave, nil
+}
+
+// countWorkDates fills the leave's working days and their count.
+func (c *LeaveController) countWorkDates(ctx context.Context, leave *model.LeaveRequest) error {
+	location := utils.LocalTime().Location()
+
+	start, err := time.ParseInLocation("2006-01-02", leave.StartDate, location)
+	if err != nil {
+		return err
+	}
+
+	end, err := time.ParseInLocation("2006-01-02", leave.EndDate, location)
+	if err != nil {
+		return err
+	}
+
+	leave.WorkDates = nil
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		shift, err := c.shifts.on(ctx, leave.Username, day)
+		if err != nil {
+			return err
+		}
+
+		if shift.IsWorkingDay() {
+			leave.WorkDates = append(leave.WorkDates, shift.WorkDate)
+		}
+	}
+
+	leave.Days = len(leave.WorkDates)
+	if leave.Days == 0 {
+		return model.ThrowError(http.StatusBadRequest, errors.New("there are no working days in this date range"))
+	}
+
+	return nil
+}
+
+// checkBalance rejects a leave taking more days than remain in its balance.
+func (c *LeaveController) checkBalance(ctx context.Context, leave *model.LeaveRequest) error {
+	if _, ok := model.LeaveQuotaParams[leave.Type]; !ok {
+		return nil
+	}
+
+	if err := c.openBalances(ctx, leave.Username, leave.StartDate); err != nil {
+		return err
+	}
+
+	balances, err := c.leaveClient.GetLeaveBalances(ctx, leave.Username, leaveDateYear(leave.StartDate))
+	if err != nil {
+		return err
+	}
+
+	for _, v := range balances {
+		if v.Type == leave.Type && v.Remaining < leave.Days {
+			return model.ThrowError(http.StatusBadRequest, fmt.Errorf("insufficient leave balance, %d day(s) remaining", v.Remaining))
+		}
+	}
+
+	return nil
+}
+
+// openBalances opens the user's balances for the year of date (YYYY or
+// YYYY-MM-DD) with the current quota params. Balances already open keep
+// their quota.
+func (c *LeaveController) openBalances(ctx context.Context, username string, date string) error {
+	year := leaveDateYear(date)
+
+	for leaveType, key := range model.LeaveQuotaParams {
+		quota := defaultLeaveQuota
+
+		param, err := c.paramClient.GetParameterByKey(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if param != nil && param.Value != "" {
+			if quota, err = strconv.Atoi(param.Value); err != nil {
+				return model.ThrowError(http.StatusInternalServerError, fmt.Errorf("invalid %s param %q, expected days", key, param.Value))
+			}
+		}
+
+		err = c.leaveClient.CreateLeaveBalance(ctx, &model.LeaveBalance{
+			Username: username,
+			Year:     year,
+			Type:     leaveType,
+			Quota:    quota,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func leaveDateYear(date string) int {
+	year, _ := strconv.Atoi(date[:4])
+	return year
+}
+
+func validateLeave(leave *model.LeaveRequest) error {
+	if !model.IsValidLeaveType(leave.Type) {
+		return model.ThrowError(http.StatusBadRequest, errors.New("type must be one of sick, annual or duty_trip"))
+	}
+
+	start, err := time.Parse("2006-01-02", leave.StartDate)
+	if err != nil {
+		return model.ThrowError(http.StatusBadRequest, errors.New("start_date must be YYYY-MM-DD"))
+	}
+
+	end, err := time.Parse("2006-01-02", leave.EndDate)
+	if err != nil {
+		return model.ThrowError(http.StatusBadRequest, errors.New("end_date must be YYYY-MM-DD"))
+	}
+
+	if end.Before(start) {
+		return model.ThrowError(http.StatusBadRequest, errors.New("end_date must not be before start_date"))
+	}
+
+	if end.Year() != start.Year() {
+		return model.ThrowError(http.StatusBadRequest, errors.New("a leave request can't span two years, split it at the end of the year"))
+	}
+
+	return nil
+}
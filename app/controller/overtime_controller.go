@@ -0,0 +1,357 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InterfaceOvertimeController interface {
+	GetAllOvertimes(ctx context.Context, status string) ([]*model.OvertimeRequest, error)
+	GetMyOvertimes(ctx context.Context) ([]*model.OvertimeRequest, error)
+	GetOvertimeByID(ctx context.Context, id string) (*model.OvertimeRequest, error)
+	SubmitOvertime(ctx context.Context, request *model.OvertimeRequest) error
+	ConfirmOvertime(ctx context.Context, id string, request *model.RequestOvertimeDecision) error
+	RejectOvertime(ctx context.Context, id string, request *model.RequestOvertimeDecision) error
+	CancelOvertime(ctx context.Context, id string) error
+	GetOvertimeTotals(ctx context.Context, month string) ([]*model.OvertimeTotal, error)
+}
+
+type OvertimeController struct {
+	overtimeClient   client.InterfaceOvertimeClient
+	attendanceClient client.InterfaceAttendanceClient
+	scopePolicy      policy.InterfaceScopePolicy
+}
+
+func NewOvertimeController(overtimeClient client.InterfaceOvertimeClient, attendanceClient client.InterfaceAttendanceClient, scopePolicy policy.InterfaceScopePolicy) *OvertimeController {
+	return &OvertimeController{
+		overtimeClient:   overtimeClient,
+		attendanceClient: attendanceClient,
+		scopePolicy:      scopePolicy,
+	}
+}
+
+func (c *OvertimeController) GetAllOvertimes(ctx context.Context, status string) ([]*model.OvertimeRequest, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllOvertimes")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", status)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.overtimeClient.GetAllOvertimes(ctx, scope, status)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *OvertimeController) GetMyOvertimes(ctx context.Context) ([]*model.OvertimeRequest, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetMyOvertimes")
+	defer span.Finish()
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.overtimeClient.GetAllOvertimes(ctx, &model.DataScope{Scope: model.ScopeSelf, Username: session.Username}, "")
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *OvertimeController) GetOvertimeByID(ctx context.Context, id string) (*model.OvertimeRequest, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetOvertimeByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.overtimeClient.GetOvertimeByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if !scope.Allows(res.Username, res.InstitutionID) {
+		utils.LogEventError(span, errors.New("overtime request not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("overtime request not found"))
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+// SubmitOvertime files a pending claim for overtime the session user worked on
+// a past or current work date. Without minutes, all the overtime recorded on
+// that date is claimed.
+func (c *OvertimeController) SubmitOvertime(ctx context.Context, request *model.OvertimeRequest) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: SubmitOvertime")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	request.Username = session.Username
+
+	now := utils.LocalTime()
+
+	day, err := time.ParseInLocation("2006-01-02", request.WorkDate, now.Location())
+	if err != nil {
+		return model.ThrowError(http.StatusBadRequest, errors.New("work_date must be formatted as YYYY-MM-DD"))
+	}
+
+	if day.After(now) {
+		return model.ThrowError(http.StatusBadRequest, errors.New("overtime can't be claimed for a future work date"))
+	}
+
+	if request.Reason == "" {
+		return model.ThrowError(http.StatusBadRequest, errors.New("reason is required"))
+	}
+
+	attendance, err := c.attendanceClient.GetAttendanceByWorkDate(ctx, request.Username, request.WorkDate)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if attendance == nil || attendance.CheckOut.IsZero() {
+		return model.ThrowError(http.StatusBadRequest, errors.New("you have not checked out on this work date"))
+	}
+
+	if attendance.OvertimeMinutes == 0 {
+		return model.ThrowError(http.StatusBadRequest, errors.New("no overtime is recorded on this work date"))
+	}
+
+	if request.Minutes == 0 {
+		request.Minutes = attendance.OvertimeMinutes
+	}
+
+	if request.Minutes < 0 || request.Minutes > attendance.OvertimeMinutes {
+		return model.ThrowError(http.StatusBadRequest, fmt.Errorf("minutes must be between 1 and the %d overtime minutes recorded", attendance.OvertimeMinutes))
+	}
+
+	open, err := c.overtimeClient.HasOpenOvertime(ctx, request.Username, request.WorkDate)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if open {
+		utils.LogEventError(span, errors.New("you already claimed overtime for this work date"))
+		return model.ThrowError(http.StatusBadRequest, errors.New("you already claimed overtime for this work date"))
+	}
+
+	// The check-in was already matched against the user's shift and the
+	// calendar: it is Overtime on any non-working day.
+	request.DayType = model.OvertimeWeekday
+	if attendance.StatusIn == model.StatusOvertime {
+		request.DayType = model.OvertimeWeekend
+	}
+
+	request.ID = uuid.New().String()
+	request.Status = model.OvertimePending
+	request.CreatedAt = now
+
+	err = c.overtimeClient.CreateNewOvertime(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Submit Overtime")
+
+	return nil
+}
+
+func (c *OvertimeController) ConfirmOvertime(ctx context.Context, id string, request *model.RequestOvertimeDecision) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ConfirmOvertime")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	overtime, err := c.decidableOvertime(ctx, id, model.OvertimeConfirmed, request.Note)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err = c.overtimeClient.CloseOvertime(ctx, overtime)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Confirm Overtime")
+
+	return nil
+}
+
+func (c *OvertimeController) RejectOvertime(ctx context.Context, id string, request *model.RequestOvertimeDecision) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: RejectOvertime")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	overtime, err := c.decidableOvertime(ctx, id, model.OvertimeRejected, request.Note)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err = c.overtimeClient.CloseOvertime(ctx, overtime)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Reject Overtime")
+
+	return nil
+}
+
+// CancelOvertime withdraws one of the session user's own pending overtime
+// requests.
+func (c *OvertimeController) CancelOvertime(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: CancelOvertime")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	overtime, err := c.overtimeClient.GetOvertimeByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if overtime.Username != session.Username {
+		utils.LogEventError(span, errors.New("overtime request not found"))
+		return model.ThrowError(http.StatusNotFound, errors.New("overtime request not found"))
+	}
+
+	now := utils.LocalTime()
+	overtime.Status = model.OvertimeCancelled
+	overtime.DecidedBy = session.Username
+	overtime.DecidedAt = &now
+
+	err = c.overtimeClient.CloseOvertime(ctx, overtime)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Cancel Overtime")
+
+	return nil
+}
+
+// GetOvertimeTotals sums the confirmed overtime of a month (YYYY-MM, this
+// month when empty) per user within the caller's scope.
+func (c *OvertimeController) GetOvertimeTotals(ctx context.Context, month string) ([]*model.OvertimeTotal, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetOvertimeTotals")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", month)
+
+	if month == "" {
+		month = utils.LocalTime().Format("2006-01")
+	}
+
+	start, err := time.ParseInLocation("2006-01", month, utils.LocalTime().Location())
+	if err != nil {
+		return nil, model.ThrowError(http.StatusBadRequest, errors.New("month must be formatted as YYYY-MM"))
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.overtimeClient.GetOvertimeTotals(ctx, scope, start.Format("2006-01-02"), start.AddDate(0, 1, -1).Format("2006-01-02"))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	for _, v := range res {
+		v.WeekdayHours = workedHours(v.WeekdayMinutes)
+		v.WeekendHours = workedHours(v.WeekendMinutes)
+		v.TotalHours = workedHours(v.TotalMinutes)
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+// decidableOvertime loads an overtime request the caller may confirm or
+// reject and stamps it with the decision.
+func (c *OvertimeController) decidableOvertime(ctx context.Context, id string, status string, note string) (*model.OvertimeRequest, error) {
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	overtime, err := c.overtimeClient.GetOvertimeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scope.CanApprove(overtime.Username, overtime.InstitutionID) {
+		return nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to decide this overtime request"))
+	}
+
+	now := utils.LocalTime()
+	overtime.Status = status
+	overtime.DecidedBy = session.Username
+	overtime.DecidedAt = &now
+	overtime.DecisionNote = note
+
+	return overtime, nil
+}
@@ -13,21 +13,23 @@ import (
 
 type InterfaceParamController interface {
 	GetParameterByKey(ctx context.Context, key string) (*model.Param, error)
-	GetAllParam(ctx context.Context) ([]*model.Param, error)
+	GetAllParam(ctx context.Context, query *model.ListQuery) ([]*model.Param, *model.ListPage, error)
 	InsertNewParam(ctx context.Context, param *model.Param) error
 	UpdateParam(ctx context.Context, param *model.Param) error
 	DeleteParam(ctx context.Context, key string) error
 }
 
 type ParamController struct {
-	redis  *redis.Client
-	client client.InterfaceParamClient
+	redis       *redis.Client
+	client      client.InterfaceParamClient
+	eventClient client.InterfaceEventClient
 }
 
-func NewParamController(redis *redis.Client, client client.InterfaceParamClient) *ParamController {
+func NewParamController(redis *redis.Client, client client.InterfaceParamClient, eventClient client.InterfaceEventClient) *ParamController {
 	return &ParamController{
-		redis:  redis,
-		client: client,
+		redis:       redis,
+		client:      client,
+		eventClient: eventClient,
 	}
 }
 
@@ -48,21 +50,21 @@ func (c *ParamController) GetParameterByKey(ctx context.Context, key string) (*m
 	return res, nil
 }
 
-func (c *ParamController) GetAllParam(ctx context.Context) ([]*model.Param, error) {
+func (c *ParamController) GetAllParam(ctx context.Context, query *model.ListQuery) ([]*model.Param, *model.ListPage, error) {
 	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllParam")
 	defer span.Finish()
 
-	utils.LogEvent(span, "Request", "All")
+	utils.LogEvent(span, "Request", query)
 
-	res, err := c.client.GetAllParam(ctx)
+	res, page, err := c.client.GetAllParam(ctx, query)
 	if err != nil {
 		utils.LogEventError(span, err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	utils.LogEvent(span, "Response", res)
 
-	return res, nil
+	return res, page, nil
 }
 
 func (c *ParamController) InsertNewParam(ctx context.Context, param *model.Param) error {
@@ -123,6 +125,10 @@ func (c *ParamController) UpdateParam(ctx context.Context, param *model.Param) e
 		return err
 	}
 
+	if err := c.eventClient.Publish(ctx, model.EventParamUpdated, "", param); err != nil {
+		utils.LogEventError(span, err)
+	}
+
 	utils.LogEvent(span, "Response", "Success Update Param")
 
 	return nil
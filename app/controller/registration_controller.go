@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type InterfaceRegistrationController interface {
+	GetRegistrations(ctx context.Context, query *model.ListQuery) ([]*model.User, *model.ListPage, error)
+	ApproveRegistration(ctx context.Context, username string, request *model.RequestRegistrationDecision) error
+	RejectRegistration(ctx context.Context, username string, request *model.RequestRegistrationDecision) error
+}
+
+type RegistrationController struct {
+	userClient  client.InterfaceUserClient
+	roleClient  client.InterfaceRoleClient
+	eventClient client.InterfaceEventClient
+	scopePolicy policy.InterfaceScopePolicy
+}
+
+func NewRegistrationController(userClient client.InterfaceUserClient, roleClient client.InterfaceRoleClient, eventClient client.InterfaceEventClient, scopePolicy policy.InterfaceScopePolicy) *RegistrationController {
+	return &RegistrationController{
+		userClient:  userClient,
+		roleClient:  roleClient,
+		eventClient: eventClient,
+		scopePolicy: scopePolicy,
+	}
+}
+
+// GetRegistrations lists the registrations the caller can review, pending
+// ones unless the query filters by another status.
+func (c *RegistrationController) GetRegistrations(ctx context.Context, query *model.ListQuery) ([]*model.User, *model.ListPage, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetRegistrations")
+	defer span.Finish()
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	if scope.Scope == model.ScopeSelf {
+		return nil, nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to review registrations"))
+	}
+
+	if query.Filters == nil {
+		query.Filters = make(map[string]string)
+	}
+
+	switch query.Filters["status"] {
+	case "":
+		query.Filters["status"] = model.UserPending
+	case model.UserPending, model.UserRejected:
+	default:
+		return nil, nil, model.ThrowError(http.StatusBadRequest, errors.New("status must be pending or rejected"))
+	}
+
+	query.Scope = scope
+
+	users, page, err := c.userClient.GetAllUser(ctx, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	utils.LogEvent(span, "Response", users)
+
+	return users, page, nil
+}
+
+// ApproveRegistration lets a pending user log in with the role chosen by the
+// reviewer, which must be active and see no more than the reviewer does.
+func (c *RegistrationController) ApproveRegistration(ctx context.Context, username string, request *model.RequestRegistrationDecision) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ApproveRegistration")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	if request.RoleID == "" {
+		return model.ThrowError(http.StatusBadRequest, errors.New("role_id shouldn't be empty"))
+	}
+
+	scope, user, err := c.reviewableRegistration(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	role, err := c.roleClient.GetRoleByID(ctx, request.RoleID)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if role == nil || !role.IsActive {
+		return model.ThrowError(http.StatusBadRequest, errors.New("role not found"))
+	}
+
+	if !scope.Covers(role.DataScope()) {
+		return model.ThrowError(http.StatusForbidden, errors.New("you can't grant a role that sees more than yours"))
+	}
+
+	user.Status = model.UserApproved
+	user.RoleID = role.Id
+	user.RoleName = role.RoleName
+	user.RejectReason = ""
+
+	if err := c.review(ctx, user); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	payload := *user
+	payload.Password = ""
+	if err := c.eventClient.Publish(ctx, model.EventUserCreated, user.InstitutionID, payload); err != nil {
+		utils.LogEventError(span, err)
+	}
+
+	utils.LogEvent(span, "Response", "Success Approve Registration")
+
+	return nil
+}
+
+// RejectRegistration keeps a pending user from logging in, for the given
+// reason.
+func (c *RegistrationController) RejectRegistration(ctx context.Context, username string, request *model.RequestRegistrationDecision) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: RejectRegistration")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return model.ThrowError(http.StatusBadRequest, errors.New("reason shouldn't be empty"))
+	}
+
+	_, user, err := c.reviewableRegistration(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	user.Status = model.UserRejected
+	user.RejectReason = reason
+
+	if err := c.review(ctx, user); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Reject Registration")
+
+	return nil
+}
+
+// reviewableRegistration returns the caller's scope and the pending user,
+// if the caller may review its registration.
+func (c *RegistrationController) reviewableRegistration(ctx context.Context, username string) (*model.DataScope, *model.User, error) {
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := c.userClient.GetUserDetail(ctx, username)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !scope.CanApprove(user.Username, user.InstitutionID) {
+		return nil, nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to review this registration"))
+	}
+
+	if user.Status != model.UserPending {
+		return nil, nil, model.ThrowError(http.StatusBadRequest, errors.New("registration is no longer pending"))
+	}
+
+	return scope, user, nil
+}
+
+func (c *RegistrationController) review(ctx context.Context, user *model.User) error {
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.userClient.ReviewRegistration(ctx, user, session.Username)
+}
@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type InterfaceRFIDController interface {
+	GetAllCards(ctx context.Context) ([]*model.RFIDCard, error)
+	AssignCard(ctx context.Context, request *model.RFIDCard) error
+	RevokeCard(ctx context.Context, request *model.RequestRFIDCardStatus) error
+	ReportLostCard(ctx context.Context, request *model.RequestRFIDCardStatus) error
+
+	GetAllDevices(ctx context.Context) ([]*model.RFIDDevice, error)
+	RegisterDevice(ctx context.Context, request *model.RFIDDevice) (*model.ResponseRegisterDevice, error)
+	DeactivateDevice(ctx context.Context, id string) error
+}
+
+type RFIDController struct {
+	rfidClient  client.InterfaceRFIDClient
+	userClient  client.InterfaceUserClient
+	scopePolicy policy.InterfaceScopePolicy
+}
+
+func NewRFIDController(rfidClient client.InterfaceRFIDClient, userClient client.InterfaceUserClient, scopePolicy policy.InterfaceScopePolicy) *RFIDController {
+	return &RFIDController{
+		rfidClient:  rfidClient,
+		userClient:  userClient,
+		scopePolicy: scopePolicy,
+	}
+}
+
+func (c *RFIDController) GetAllCards(ctx context.Context) ([]*model.RFIDCard, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllCards")
+	defer span.Finish()
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.rfidClient.GetAllCards(ctx, scope.InstitutionFilter())
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *RFIDController) AssignCard(ctx context.Context, request *model.RFIDCard) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: AssignCard")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if request.CardUID == "" || request.Username == "" {
+		utils.LogEventError(span, errors.New("card_uid and username are required"))
+		return model.ThrowError(http.StatusBadRequest, errors.New("card_uid and username are required"))
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	user, err := c.userClient.GetUserDetail(ctx, request.Username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if !scope.AllowsInstitution(user.InstitutionID) {
+		utils.LogEventError(span, errors.New("user is out of scope"))
+		return model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to assign cards to this user"))
+	}
+
+	// An active card is refused by the client, which returns 409.
+	request.Status = model.RFIDCardActive
+	request.AssignedAt = utils.LocalTime()
+	request.AssignedBy = session.Username
+
+	err = c.rfidClient.AssignCard(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Assign Card")
+
+	return nil
+}
+
+func (c *RFIDController) RevokeCard(ctx context.Context, request *model.RequestRFIDCardStatus) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: RevokeCard")
+	defer span.Finish()
+
+	err := c.updateCardStatus(ctx, request, model.RFIDCardRevoked)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Revoke Card")
+
+	return nil
+}
+
+func (c *RFIDController) ReportLostCard(ctx context.Context, request *model.RequestRFIDCardStatus) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ReportLostCard")
+	defer span.Finish()
+
+	err := c.updateCardStatus(ctx, request, model.RFIDCardLost)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Report Lost Card")
+
+	return nil
+}
+
+// updateCardStatus ends an active card whose holder is within the caller's
+// institution; other cards are reported as missing.
+func (c *RFIDController) updateCardStatus(ctx context.Context, request *model.RequestRFIDCardStatus, status string) error {
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		return err
+	}
+
+	card, err := c.rfidClient.GetActiveCard(ctx, request.CardUID)
+	if err != nil {
+		return err
+	}
+
+	if !scope.AllowsInstitution(card.InstitutionID) {
+		return model.ThrowError(http.StatusNotFound, errors.New("active card not found"))
+	}
+
+	now := utils.LocalTime()
+
+	return c.rfidClient.UpdateCardStatus(ctx, &model.RFIDCard{
+		CardUID:   request.CardUID,
+		Status:    status,
+		Remark:    request.Remark,
+		RevokedAt: &now,
+		RevokedBy: session.Username,
+	})
+}
+
+func (c *RFIDController) GetAllDevices(ctx context.Context) ([]*model.RFIDDevice, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllDevices")
+	defer span.Finish()
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.rfidClient.GetAllDevices(ctx, scope.InstitutionFilter())
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *RFIDController) RegisterDevice(ctx context.Context, request *model.RFIDDevice) (*model.ResponseRegisterDevice, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: RegisterDevice")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	// A device only accepts cards of its institution, so it can only be
+	// registered for one the caller manages.
+	if request.InstitutionID == "" {
+		request.InstitutionID = scope.InstitutionID
+	}
+
+	if !scope.AllowsInstitution(request.InstitutionID) {
+		utils.LogEventError(span, errors.New("institution is out of scope"))
+		return nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to register devices for this institution"))
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	request.ID = uuid.New().String()
+	request.Secret = hex.EncodeToString(secret)
+	request.IsActive = true
+	request.CreatedAt = utils.LocalTime()
+	request.CreatedBy = session.Username
+
+	err = c.rfidClient.CreateNewDevice(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", request.ID)
+
+	// The secret is only ever returned here; listings never expose it.
+	return &model.ResponseRegisterDevice{
+		ID:     request.ID,
+		Secret: request.Secret,
+	}, nil
+}
+
+func (c *RFIDController) DeactivateDevice(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: DeactivateDevice")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if scope.Scope == model.ScopeSelf {
+		utils.LogEventError(span, errors.New("self scope can't manage devices"))
+		return model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to manage devices"))
+	}
+
+	err = c.rfidClient.DeactivateDevice(ctx, id, scope.InstitutionFilter())
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Deactivate Device")
+
+	return nil
+}
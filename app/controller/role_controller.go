@@ -5,13 +5,15 @@ import (
 	"bpkp-svc-portal/app/model"
 	"bpkp-svc-portal/app/utils"
 	"context"
+	"errors"
+	"net/http"
 
 	"github.com/google/uuid"
 )
 
 type InterfaceRoleController interface {
 	CreateNewRoleMapping(ctx context.Context, request *model.MenuRoleMapping) error
-	GetAllRoleMapping(ctx context.Context) ([]*model.MenuRoleMapping, error)
+	GetAllRoleMapping(ctx context.Context, query *model.ListQuery) ([]*model.MenuRoleMapping, *model.ListPage, error)
 	UpdateRoleMapping(ctx context.Context, request *model.MenuRoleMapping) error
 	DeleteRoleMapping(ctx context.Context, id string) error
 
@@ -22,15 +24,19 @@ type InterfaceRoleController interface {
 
 	GetAllRole(ctx context.Context) ([]*model.Role, error)
 	CreateNewRole(ctx context.Context, request *model.Role) error
+
+	GetAllRoutePermission(ctx context.Context) ([]*model.RoutePermission, error)
 }
 
 type RoleController struct {
-	roleClient client.InterfaceRoleClient
+	roleClient  client.InterfaceRoleClient
+	eventClient client.InterfaceEventClient
 }
 
-func NewRoleController(roleClient client.InterfaceRoleClient) *RoleController {
+func NewRoleController(roleClient client.InterfaceRoleClient, eventClient client.InterfaceEventClient) *RoleController {
 	return &RoleController{
-		roleClient: roleClient,
+		roleClient:  roleClient,
+		eventClient: eventClient,
 	}
 }
 
@@ -56,22 +62,31 @@ func (c *RoleController) CreateNewRoleMapping(ctx context.Context, request *mode
 		utils.LogEventError(span, err)
 		return err
 	}
+
+	if err := c.roleClient.InvalidateMenuMapping(ctx); err != nil {
+		utils.LogEventError(span, err)
+	}
+
+	if err := c.eventClient.Publish(ctx, model.EventRoleMappingCreated, "", request); err != nil {
+		utils.LogEventError(span, err)
+	}
+
 	return nil
 }
 
-func (c *RoleController) GetAllRoleMapping(ctx context.Context) ([]*model.MenuRoleMapping, error) {
+func (c *RoleController) GetAllRoleMapping(ctx context.Context, query *model.ListQuery) ([]*model.MenuRoleMapping, *model.ListPage, error) {
 	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllRoleMapping")
 	defer span.Finish()
 
-	response, err := c.roleClient.GetAllRoleMapping(ctx)
+	response, page, err := c.roleClient.GetAllRoleMapping(ctx, query)
 	if err != nil {
 		utils.LogEventError(span, err)
-		return nil, err
+		return nil, nil, err
 	}
 
 	utils.LogEvent(span, "Response", response)
 
-	return response, nil
+	return response, page, nil
 }
 
 func (c *RoleController) UpdateRoleMapping(ctx context.Context, request *model.MenuRoleMapping) error {
@@ -95,6 +110,14 @@ func (c *RoleController) UpdateRoleMapping(ctx context.Context, request *model.M
 		return err
 	}
 
+	if err := c.roleClient.InvalidateMenuMapping(ctx); err != nil {
+		utils.LogEventError(span, err)
+	}
+
+	if err := c.eventClient.Publish(ctx, model.EventRoleMappingUpdated, "", request); err != nil {
+		utils.LogEventError(span, err)
+	}
+
 	utils.LogEvent(span, "Response", "Success Update Role Mapping")
 	return nil
 }
@@ -165,6 +188,11 @@ func (c *RoleController) CreateNewRole(ctx context.Context, request *model.Role)
 		return err
 	}
 
+	if request.Scope != "" && !model.IsValidScope(request.Scope) {
+		utils.LogEventError(span, errors.New("scope must be one of self, institution or all"))
+		return model.ThrowError(http.StatusBadRequest, errors.New("scope must be one of self, institution or all"))
+	}
+
 	request.Id = uuid.New().String()
 	request.CreatedAt = utils.LocalTime()
 	request.UpdatedAt = utils.LocalTime()
@@ -220,6 +248,10 @@ func (c *RoleController) DeleteMenu(ctx context.Context, id string) error {
 		return err
 	}
 
+	if err := c.roleClient.InvalidateMenuMapping(ctx); err != nil {
+		utils.LogEventError(span, err)
+	}
+
 	utils.LogEvent(span, "Response", "Success Delete Menu")
 
 	return nil
@@ -237,7 +269,26 @@ func (c *RoleController) DeleteRoleMapping(ctx context.Context, id string) error
 		return err
 	}
 
+	if err := c.roleClient.InvalidateMenuMapping(ctx); err != nil {
+		utils.LogEventError(span, err)
+	}
+
+	if err := c.eventClient.Publish(ctx, model.EventRoleMappingDeleted, "", map[string]string{"id": id}); err != nil {
+		utils.LogEventError(span, err)
+	}
+
 	utils.LogEvent(span, "Response", "Success Delete Role Mapping")
 
 	return nil
 }
+
+func (c *RoleController) GetAllRoutePermission(ctx context.Context) ([]*model.RoutePermission, error) {
+	span, _ := utils.SpanFromContext(ctx, "Controller: GetAllRoutePermission")
+	defer span.Finish()
+
+	response := utils.GetAllRoutePermission()
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
@@ -0,0 +1,475 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InterfaceScheduleController interface {
+	GetAllShifts(ctx context.Context) ([]*model.Shift, error)
+	GetShiftByID(ctx context.Context, id string) (*model.Shift, error)
+	CreateNewShift(ctx context.Context, request *model.Shift) error
+	UpdateShift(ctx context.Context, request *model.Shift) error
+	DeleteShift(ctx context.Context, id string) error
+
+	GetAllAssignments(ctx context.Context) ([]*model.ScheduleAssignment, error)
+	CreateNewAssignment(ctx context.Context, request *model.ScheduleAssignment) error
+	DeleteAssignment(ctx context.Context, id string) error
+
+	GetUserShift(ctx context.Context, username string, date string) (*model.ShiftInstance, error)
+}
+
+type ScheduleController struct {
+	scheduleClient client.InterfaceScheduleClient
+	userClient     client.InterfaceUserClient
+	scopePolicy    policy.InterfaceScopePolicy
+	shifts         *shiftResolver
+}
+
+func NewScheduleController(scheduleClient client.InterfaceScheduleClient, userClient client.InterfaceUserClient, paramClient client.InterfaceParamClient, calendarClient client.InterfaceCalendarClient, scopePolicy policy.InterfaceScopePolicy) *ScheduleController {
+	return &ScheduleController{
+		scheduleClient: scheduleClient,
+		userClient:     userClient,
+		scopePolicy:    scopePolicy,
+		shifts:         newShiftResolver(scheduleClient, paramClient, calendarClient),
+	}
+}
+
+func (c *ScheduleController) GetAllShifts(ctx context.Context) ([]*model.Shift, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllShifts")
+	defer span.Finish()
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.scheduleClient.GetAllShifts(ctx, scope.InstitutionFilter())
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *ScheduleController) GetShiftByID(ctx context.Context, id string) (*model.Shift, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetShiftByID")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.scheduleClient.GetShiftByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	// Shared shifts are listed for everyone; another institution's shift is
+	// reported as missing, as it is left out of the list.
+	if res.InstitutionID != "" && !scope.AllowsInstitution(res.InstitutionID) {
+		utils.LogEventError(span, errors.New("shift not found"))
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("shift not found"))
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *ScheduleController) CreateNewShift(ctx context.Context, request *model.Shift) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: CreateNewShift")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if err := validateShift(request); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	// Only roles spanning every institution can create shared shifts.
+	if scope.Scope != model.ScopeAll {
+		request.InstitutionID = scope.InstitutionID
+	}
+
+	request.ID = uuid.New().String()
+	request.CreatedAt = utils.LocalTime()
+	request.CreatedBy = session.Username
+	request.UpdatedAt = request.CreatedAt
+	request.UpdatedBy = session.Username
+
+	err = c.scheduleClient.CreateNewShift(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create New Shift")
+
+	return nil
+}
+
+func (c *ScheduleController) UpdateShift(ctx context.Context, request *model.Shift) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: UpdateShift")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if err := validateShift(request); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	scope, err := c.ownedShift(ctx, request.ID)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if scope.Scope != model.ScopeAll {
+		request.InstitutionID = scope.InstitutionID
+	}
+
+	request.UpdatedAt = utils.LocalTime()
+	request.UpdatedBy = session.Username
+
+	err = c.scheduleClient.UpdateShift(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Update Shift")
+
+	return nil
+}
+
+func (c *ScheduleController) DeleteShift(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: DeleteShift")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	if _, err := c.ownedShift(ctx, id); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	err := c.scheduleClient.DeleteShift(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Delete Shift")
+
+	return nil
+}
+
+func (c *ScheduleController) GetAllAssignments(ctx context.Context) ([]*model.ScheduleAssignment, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllAssignments")
+	defer span.Finish()
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	res, err := c.scheduleClient.GetAllAssignments(ctx, scope.InstitutionFilter())
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+func (c *ScheduleController) CreateNewAssignment(ctx context.Context, request *model.ScheduleAssignment) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: CreateNewAssignment")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if err := validateAssignment(request); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if _, err := c.scheduleClient.GetShiftByID(ctx, request.ShiftID); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	switch request.TargetType {
+	case model.ScheduleTargetUser:
+		user, err := c.userClient.GetUserDetail(ctx, request.TargetID)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return err
+		}
+
+		if !scope.AllowsInstitution(user.InstitutionID) {
+			utils.LogEventError(span, errors.New("you are not allowed to assign schedules to this user"))
+			return model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to assign schedules to this user"))
+		}
+	case model.ScheduleTargetInstitution:
+		if !scope.AllowsInstitution(request.TargetID) {
+			utils.LogEventError(span, errors.New("you are not allowed to assign schedules to this institution"))
+			return model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to assign schedules to this institution"))
+		}
+	case model.ScheduleTargetRole:
+		// Roles are shared by every institution.
+		if scope.Scope != model.ScopeAll {
+			utils.LogEventError(span, errors.New("you are not allowed to assign schedules to a role"))
+			return model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to assign schedules to a role"))
+		}
+	}
+
+	request.ID = uuid.New().String()
+	request.CreatedAt = utils.LocalTime()
+	request.CreatedBy = session.Username
+
+	err = c.scheduleClient.CreateNewAssignment(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Create New Assignment")
+
+	return nil
+}
+
+func (c *ScheduleController) DeleteAssignment(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: DeleteAssignment")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if scope.Scope != model.ScopeAll {
+		assignments, err := c.scheduleClient.GetAllAssignments(ctx, scope.InstitutionID)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return err
+		}
+
+		owned := false
+		for _, v := range assignments {
+			if v.ID == id && v.TargetType != model.ScheduleTargetRole {
+				owned = true
+			}
+		}
+
+		if !owned {
+			utils.LogEventError(span, errors.New("assignment not found"))
+			return model.ThrowError(http.StatusNotFound, errors.New("assignment not found"))
+		}
+	}
+
+	err = c.scheduleClient.DeleteAssignment(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Delete Assignment")
+
+	return nil
+}
+
+// GetUserShift returns the shift a user works on date (YYYY-MM-DD, today when
+// empty).
+func (c *ScheduleController) GetUserShift(ctx context.Context, username string, date string) (*model.ShiftInstance, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetUserShift")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", username+" "+date)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	user, err := c.userClient.GetUserDetail(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if !scope.Allows(user.Username, user.InstitutionID) {
+		utils.LogEventError(span, errors.New("you are not allowed to access this data (out of role scope)"))
+		return nil, model.ThrowError(http.StatusUnauthorized, errors.New("you are not allowed to access this data (out of role scope)"))
+	}
+
+	day := utils.LocalTime()
+	if date != "" {
+		day, err = time.ParseInLocation("2006-01-02", date, day.Location())
+		if err != nil {
+			utils.LogEventError(span, err)
+			return nil, model.ThrowError(http.StatusBadRequest, errors.New("date must be YYYY-MM-DD"))
+		}
+	}
+
+	res, err := c.shifts.on(ctx, user.Username, day)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+// ownedShift checks that the caller may change the shift and returns its scope.
+// Shared shifts can only be changed by roles spanning every institution.
+func (c *ScheduleController) ownedShift(ctx context.Context, id string) (*model.DataScope, error) {
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shift, err := c.scheduleClient.GetShiftByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if scope.Scope != model.ScopeAll && shift.InstitutionID != scope.InstitutionID {
+		return nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to change this shift"))
+	}
+
+	return scope, nil
+}
+
+func validateShift(shift *model.Shift) error {
+	if shift.Name == "" {
+		return model.ThrowError(http.StatusBadRequest, errors.New("name is required"))
+	}
+
+	if shift.GraceMinutes < 0 || shift.CheckInWindowMinutes < 0 {
+		return model.ThrowError(http.StatusBadRequest, errors.New("grace_minutes and checkin_window_minutes must not be negative"))
+	}
+
+	seen := make(map[int]bool)
+	for _, v := range shift.Days {
+		if v.Weekday < 0 || v.Weekday > 6 {
+			return model.ThrowError(http.StatusBadRequest, errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)"))
+		}
+
+		if seen[v.Weekday] {
+			return model.ThrowError(http.StatusBadRequest, errors.New("each weekday can only be set once"))
+		}
+		seen[v.Weekday] = true
+
+		start, err := parseClock(v.StartTime)
+		if err != nil {
+			return err
+		}
+
+		end, err := parseClock(v.EndTime)
+		if err != nil {
+			return err
+		}
+
+		if start.Equal(end) {
+			return model.ThrowError(http.StatusBadRequest, errors.New("start_time and end_time must differ"))
+		}
+
+		v.StartTime = start.Format("15:04")
+		v.EndTime = end.Format("15:04")
+	}
+
+	return nil
+}
+
+func validateAssignment(assignment *model.ScheduleAssignment) error {
+	switch assignment.TargetType {
+	case model.ScheduleTargetUser, model.ScheduleTargetRole, model.ScheduleTargetInstitution:
+	default:
+		return model.ThrowError(http.StatusBadRequest, errors.New("target_type must be one of user, role or institution"))
+	}
+
+	if assignment.ShiftID == "" || assignment.TargetID == "" {
+		return model.ThrowError(http.StatusBadRequest, errors.New("shift_id and target_id are required"))
+	}
+
+	from, err := time.Parse("2006-01-02", assignment.EffectiveFrom)
+	if err != nil {
+		return model.ThrowError(http.StatusBadRequest, errors.New("effective_from must be YYYY-MM-DD"))
+	}
+
+	if assignment.EffectiveTo != nil && *assignment.EffectiveTo == "" {
+		assignment.EffectiveTo = nil
+	}
+
+	if assignment.EffectiveTo != nil {
+		to, err := time.Parse("2006-01-02", *assignment.EffectiveTo)
+		if err != nil {
+			return model.ThrowError(http.StatusBadRequest, errors.New("effective_to must be YYYY-MM-DD"))
+		}
+
+		if to.Before(from) {
+			return model.ThrowError(http.StatusBadRequest, errors.New("effective_to must not be before effective_from"))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// shiftResolver works out which shift a user works on a given day. Users
+// without an assigned schedule fall back to the checkin-time and
+// checkout-time params on the workdays param's weekdays. Calendar days are
+// never working days.
+type shiftResolver struct {
+	scheduleClient client.InterfaceScheduleClient
+	paramClient    client.InterfaceParamClient
+	calendarClient client.InterfaceCalendarClient
+}
+
+func newShiftResolver(scheduleClient client.InterfaceScheduleClient, paramClient client.InterfaceParamClient, calendarClient client.InterfaceCalendarClient) *shiftResolver {
+	return &shiftResolver{
+		scheduleClient: scheduleClient,
+		paramClient:    paramClient,
+		calendarClient: calendarClient,
+	}
+}
+
+// defaultWorkdays is used when the workdays param is not set.
+var defaultWorkdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// on returns the user's shift instance for the work date of day.
+func (r *shiftResolver) on(ctx context.Context, username string, day time.Time) (*model.ShiftInstance, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: shiftResolver.on")
+	defer span.Finish()
+
+	date := startOfDay(day)
+	instance := &model.ShiftInstance{WorkDate: date.Format("2006-01-02"), DayType: model.DayTypeOffDay}
+
+	shift, err := r.scheduleClient.GetActiveShift(ctx, username, instance.WorkDate)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if shift != nil {
+		instance.ShiftID = shift.ID
+		instance.ShiftName = shift.Name
+	}
+
+	holiday, err := r.calendarClient.GetUserHoliday(ctx, username, instance.WorkDate)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if holiday != nil {
+		instance.DayType = holiday.Type
+		instance.HolidayName = holiday.Name
+		utils.LogEvent(span, "Response", instance)
+		return instance, nil
+	}
+
+	grace, window := 0, 0
+	if shift != nil {
+		grace, window = shift.GraceMinutes, shift.CheckInWindowMinutes
+	}
+
+	if grace == 0 {
+		if grace, err = r.minutesParam(ctx, "grace-period"); err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+	}
+
+	if window == 0 {
+		if window, err = r.minutesParam(ctx, "checkin-window"); err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+	}
+
+	var start, end string
+	if shift == nil {
+		workdays, err := r.workdays(ctx)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+
+		if !isWorkday(workdays, date.Weekday()) {
+			utils.LogEvent(span, "Response", instance)
+			return instance, nil
+		}
+
+		checkIn, err := r.paramClient.GetParameterByKey(ctx, "checkin-time")
+		if err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+
+		checkOut, err := r.paramClient.GetParameterByKey(ctx, "checkout-time")
+		if err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+
+		start, end = checkIn.Value, checkOut.Value
+	} else {
+		for _, v := range shift.Days {
+			if time.Weekday(v.Weekday) == date.Weekday() {
+				start, end = v.StartTime, v.EndTime
+			}
+		}
+
+		if start == "" {
+			utils.LogEvent(span, "Response", instance)
+			return instance, nil
+		}
+	}
+
+	if instance.Start, err = clockOn(date, start); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if instance.End, err = clockOn(date, end); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if !instance.End.After(instance.Start) {
+		instance.End = instance.End.AddDate(0, 0, 1)
+	}
+
+	instance.DayType = model.DayTypeWorkingDay
+	instance.GraceMinutes = grace
+	if window > 0 {
+		instance.EarliestCheckIn = instance.Start.Add(-time.Duration(window) * time.Minute)
+	}
+
+	utils.LogEvent(span, "Response", instance)
+
+	return instance, nil
+}
+
+// at returns the shift instance a check-in or check-out at the given time
+// belongs to. Taps before the midpoint between the end of the previous day's
+// shift and the start of today's go to the previous day, so night shifts and
+// late check-outs stay on the work date they started on.
+func (r *shiftResolver) at(ctx context.Context, username string, at time.Time) (*model.ShiftInstance, error) {
+	today, err := r.on(ctx, username, at)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := r.on(ctx, username, at.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	if !previous.IsWorkingDay() {
+		return today, nil
+	}
+
+	next := startOfDay(at).AddDate(0, 0, 1)
+	if today.IsWorkingDay() {
+		next = today.Start
+	}
+
+	if at.Before(previous.End.Add(next.Sub(previous.End) / 2)) {
+		return previous, nil
+	}
+
+	return today, nil
+}
+
+// minutesParam reads a whole number of minutes from a param. A missing or
+// empty param counts as 0.
+func (r *shiftResolver) minutesParam(ctx context.Context, key string) (int, error) {
+	param, err := r.paramClient.GetParameterByKey(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	if param == nil || param.Value == "" {
+		return 0, nil
+	}
+
+	minutes, err := strconv.Atoi(param.Value)
+	if err != nil || minutes < 0 {
+		return 0, model.ThrowError(http.StatusInternalServerError, fmt.Errorf("invalid %s param %q, expected minutes", key, param.Value))
+	}
+
+	return minutes, nil
+}
+
+// workdays reads the weekdays (0 = Sunday) users without a schedule work from
+// the comma separated workdays param, Monday to Friday when it is not set.
+func (r *shiftResolver) workdays(ctx context.Context) ([]time.Weekday, error) {
+	param, err := r.paramClient.GetParameterByKey(ctx, "workdays")
+	if err != nil {
+		return nil, err
+	}
+
+	if param == nil || strings.TrimSpace(param.Value) == "" {
+		return defaultWorkdays, nil
+	}
+
+	var workdays []time.Weekday
+	for _, v := range strings.Split(param.Value, ",") {
+		day, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || day < 0 || day > 6 {
+			return nil, model.ThrowError(http.StatusInternalServerError, fmt.Errorf("invalid workdays param %q, expected weekdays 0-6", param.Value))
+		}
+
+		workdays = append(workdays, time.Weekday(day))
+	}
+
+	return workdays, nil
+}
+
+func isWorkday(workdays []time.Weekday, day time.Weekday) bool {
+	for _, v := range workdays {
+		if v == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// clockOn returns the HH:mm (or HH:mm:ss) time of day on date.
+func clockOn(date time.Time, clock string) (time.Time, error) {
+	parsed, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), parsed.Hour(), parsed.Minute(), 0, 0, date.Location()), nil
+}
+
+func parseClock(clock string) (time.Time, error) {
+	if parsed, err := time.Parse("15:04", clock); err == nil {
+		return parsed, nil
+	}
+
+	parsed, err := time.Parse("15:04:05", clock)
+	if err != nil {
+		return time.Time{}, model.ThrowError(http.StatusBadRequest, fmt.Errorf("invalid time %q, expected HH:mm", clock))
+	}
+
+	return parsed, nil
+}
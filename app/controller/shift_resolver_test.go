@@ -0,0 +1,269 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeScheduleClient serves one shift to every user.
+type fakeScheduleClient struct {
+	client.InterfaceScheduleClient
+	shift *model.Shift
+}
+
+func (f *fakeScheduleClient) GetActiveShift(ctx context.Context, username string, date string) (*model.Shift, error) {
+	return f.shift, nil
+}
+
+// fakeCalendarClient serves holidays by date (YYYY-MM-DD).
+type fakeCalendarClient struct {
+	client.InterfaceCalendarClient
+	holidays map[string]*model.CalendarDay
+}
+
+func (f *fakeCalendarClient) GetUserHoliday(ctx context.Context, username string, date string) (*model.CalendarDay, error) {
+	return f.holidays[date], nil
+}
+
+// fakeParamClient serves params by key; missing keys are nil.
+type fakeParamClient struct {
+	client.InterfaceParamClient
+	params map[string]string
+}
+
+func (f *fakeParamClient) GetParameterByKey(ctx context.Context, key string) (*model.Param, error) {
+	value, ok := f.params[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return &model.Param{Key: key, Value: value}, nil
+}
+
+// everyDay returns a shift working from start to end on every weekday but
+// those in off.
+func everyDay(start string, end string, off ...time.Weekday) *model.Shift {
+	shift := &model.Shift{ID: "shift-1", Name: "Shift"}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if isWorkday(off, day) {
+			continue
+		}
+
+		shift.Days = append(shift.Days, &model.ShiftDay{Weekday: int(day), StartTime: start, EndTime: end})
+	}
+
+	return shift
+}
+
+func TestShiftResolverAt(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	// 2024-01-01 is a Monday.
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, 0, 0, wib)
+	}
+
+	tests := []struct {
+		name      string
+		shift     *model.Shift
+		params    map[string]string
+		holidays  map[string]*model.CalendarDay
+		at        time.Time
+		workDate  string
+		working   bool
+		start     time.Time
+		end       time.Time
+		earliest  time.Time
+		graceMins int
+	}{
+		{
+			name:     "day shift check-in before the start",
+			shift:    everyDay("08:00", "16:00"),
+			at:       at(2, 7, 45),
+			workDate: "2024-01-02",
+			working:  true,
+			start:    at(2, 8, 0),
+			end:      at(2, 16, 0),
+		},
+		{
+			name:     "day shift late check-out stays on the day",
+			shift:    everyDay("08:00", "16:00"),
+			at:       at(2, 23, 30),
+			workDate: "2024-01-02",
+			working:  true,
+			start:    at(2, 8, 0),
+			end:      at(2, 16, 0),
+		},
+		{
+			name:     "day shift tap after midnight goes to the next day",
+			shift:    everyDay("08:00", "16:00"),
+			at:       at(3, 0, 30),
+			workDate: "2024-01-03",
+			working:  true,
+			start:    at(3, 8, 0),
+			end:      at(3, 16, 0),
+		},
+		{
+			name:     "night shift ends the next day",
+			shift:    everyDay("22:00", "06:00"),
+			at:       at(2, 21, 50),
+			workDate: "2024-01-02",
+			working:  true,
+			start:    at(2, 22, 0),
+			end:      at(3, 6, 0),
+		},
+		{
+			name:     "night shift check-out after midnight goes to the previous day",
+			shift:    everyDay("22:00", "06:00"),
+			at:       at(3, 6, 5),
+			workDate: "2024-01-02",
+			working:  true,
+			start:    at(2, 22, 0),
+			end:      at(3, 6, 0),
+		},
+		{
+			name:     "night shift tap past the midpoint goes to the day",
+			shift:    everyDay("22:00", "06:00"),
+			at:       at(3, 14, 30),
+			workDate: "2024-01-03",
+			working:  true,
+			start:    at(3, 22, 0),
+			end:      at(4, 6, 0),
+		},
+		{
+			name:     "previous day off keeps the day",
+			shift:    everyDay("22:00", "06:00", time.Sunday),
+			at:       at(1, 5, 0),
+			workDate: "2024-01-01",
+			working:  true,
+			start:    at(1, 22, 0),
+			end:      at(2, 6, 0),
+		},
+		{
+			name:     "previous day holiday keeps the day",
+			shift:    everyDay("22:00", "06:00"),
+			holidays: map[string]*model.CalendarDay{"2024-01-01": {Name: "New Year", Type: model.DayTypeOffDay}},
+			at:       at(2, 5, 0),
+			workDate: "2024-01-02",
+			working:  true,
+			start:    at(2, 22, 0),
+			end:      at(3, 6, 0),
+		},
+		{
+			name:     "day off after a night shift takes the check-out until its midpoint",
+			shift:    everyDay("22:00", "06:00", time.Saturday),
+			at:       at(6, 9, 0),
+			workDate: "2024-01-05",
+			working:  true,
+			start:    at(5, 22, 0),
+			end:      at(6, 6, 0),
+		},
+		{
+			name:     "day off after a night shift past its midpoint",
+			shift:    everyDay("22:00", "06:00", time.Saturday),
+			at:       at(6, 16, 0),
+			workDate: "2024-01-06",
+		},
+		{
+			name:      "shift grace and check-in window",
+			shift:     &model.Shift{ID: "shift-1", GraceMinutes: 10, CheckInWindowMinutes: 30, Days: []*model.ShiftDay{{Weekday: int(time.Tuesday), StartTime: "08:00:00", EndTime: "16:00:00"}}},
+			at:        at(2, 8, 5),
+			workDate:  "2024-01-02",
+			working:   true,
+			start:     at(2, 8, 0),
+			end:       at(2, 16, 0),
+			earliest:  at(2, 7, 30),
+			graceMins: 10,
+		},
+		{
+			name:      "no schedule falls back to the params",
+			params:    map[string]string{"checkin-time": "07:30", "checkout-time": "16:00", "grace-period": "15", "checkin-window": "60"},
+			at:        at(5, 7, 40),
+			workDate:  "2024-01-05",
+			working:   true,
+			start:     at(5, 7, 30),
+			end:       at(5, 16, 0),
+			earliest:  at(5, 6, 30),
+			graceMins: 15,
+		},
+		{
+			name:     "no schedule on a weekend",
+			params:   map[string]string{"checkin-time": "07:30", "checkout-time": "16:00"},
+			at:       at(6, 8, 0),
+			workDate: "2024-01-06",
+		},
+		{
+			name:     "no schedule on a workday from the param",
+			params:   map[string]string{"checkin-time": "07:30", "checkout-time": "16:00", "workdays": "1,2,3,4,5,6"},
+			at:       at(6, 8, 0),
+			workDate: "2024-01-06",
+			working:  true,
+			start:    at(6, 7, 30),
+			end:      at(6, 16, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := newShiftResolver(
+				&fakeScheduleClient{shift: tt.shift},
+				&fakeParamClient{params: tt.params},
+				&fakeCalendarClient{holidays: tt.holidays},
+			)
+
+			got, err := resolver.at(context.Background(), "user", tt.at)
+			if err != nil {
+				t.Fatalf("at() error = %v", err)
+			}
+
+			if got.WorkDate != tt.workDate {
+				t.Errorf("WorkDate = %s, want %s", got.WorkDate, tt.workDate)
+			}
+
+			if got.IsWorkingDay() != tt.working {
+				t.Fatalf("IsWorkingDay() = %v, want %v", got.IsWorkingDay(), tt.working)
+			}
+
+			if !tt.working {
+				return
+			}
+
+			if !got.Start.Equal(tt.start) || !got.End.Equal(tt.end) {
+				t.Errorf("shift = %s - %s, want %s - %s", got.Start, got.End, tt.start, tt.end)
+			}
+
+			if !got.EarliestCheckIn.Equal(tt.earliest) {
+				t.Errorf("EarliestCheckIn = %s, want %s", got.EarliestCheckIn, tt.earliest)
+			}
+
+			if got.GraceMinutes != tt.graceMins {
+				t.Errorf("GraceMinutes = %d, want %d", got.GraceMinutes, tt.graceMins)
+			}
+		})
+	}
+}
+
+func TestShiftResolverAtInvalidParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+	}{
+		{name: "grace period", params: map[string]string{"grace-period": "soon"}},
+		{name: "check-in window", params: map[string]string{"checkin-window": "-5"}},
+		{name: "workdays", params: map[string]string{"workdays": "1,8"}},
+		{name: "check-in time", params: map[string]string{"checkin-time": "8am", "checkout-time": "16:00"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := newShiftResolver(&fakeScheduleClient{}, &fakeParamClient{params: tt.params}, &fakeCalendarClient{})
+
+			// 2024-01-02 is a Tuesday.
+			if _, err := resolver.at(context.Background(), "user", time.Date(2024, time.January, 2, 8, 0, 0, 0, time.UTC)); err == nil {
+				t.Error("at() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/config"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type InterfaceTrainingController interface {
+	TrainModel(ctx context.Context, request *model.RequestTrainModel) (*model.ResponseTrainModel, error)
+	GetModelTrainings(ctx context.Context, filter *model.FilterModelTraining) ([]*model.ModelTraining, error)
+	UpdateModelTrainingStatus(ctx context.Context, request *model.RequestTrainingStatus) error
+	ActivateModelTraining(ctx context.Context, id string) error
+}
+
+type TrainingController struct {
+	cfg            *config.Config
+	trainingClient client.InterfaceTrainingClient
+	scopePolicy    policy.InterfaceScopePolicy
+}
+
+func NewTrainingController(cfg *config.Config, trainingClient client.InterfaceTrainingClient, scopePolicy policy.InterfaceScopePolicy) *TrainingController {
+	return &TrainingController{
+		cfg:            cfg,
+		trainingClient: trainingClient,
+		scopePolicy:    scopePolicy,
+	}
+}
+
+// TrainModel trains a model on the datasets of one institution within the
+// caller's scope, the caller's own by default.
+func (c *TrainingController) TrainModel(ctx context.Context, request *model.RequestTrainModel) (*model.ResponseTrainModel, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: TrainModel")
+	defer span.Finish()
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	// An empty body trains the caller's own institution.
+	if request == nil {
+		request = &model.RequestTrainModel{}
+	}
+
+	if request.InstitutionID == "" {
+		request.InstitutionID = session.InstitutionID
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if scope.Scope == model.ScopeSelf || !scope.AllowsInstitution(request.InstitutionID) {
+		utils.LogEventError(span, errors.New("you are not allowed to train this institution's model"))
+		return nil, model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to train this institution's model"))
+	}
+
+	training := &model.ModelTraining{
+		ID:            uuid.New().String(),
+		InstitutionID: request.InstitutionID,
+		Status:        model.TrainingStatusQueued,
+		IsUsed:        "0",
+		CreatedAt:     utils.LocalTime().Format("2006-01-02 15:04:05"),
+		CreatedBy:     session.Username,
+	}
+
+	err = c.trainingClient.CreateModelTraining(ctx, training)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	_, err = c.trainingClient.RequestTrainModel(ctx, &model.RequestAPITrainModel{
+		BucketName: c.cfg.MinioProfile.Bucket,
+		Prefix:     institutionDatasetPrefix(training.InstitutionID),
+		CreatedBy:  session.Username,
+		ID:         training.ID,
+	})
+	if err != nil {
+		utils.LogEventError(span, err)
+		if errStatus := c.trainingClient.UpdateModelTrainingStatus(ctx, training.ID, model.TrainingStatusFailed); errStatus != nil {
+			utils.LogEventError(span, errStatus)
+		}
+		return nil, model.ThrowError(http.StatusBadGateway, err)
+	}
+
+	// The processing service may report the training running, or even
+	// finished, before this; the training was dispatched either way.
+	err = c.trainingClient.UpdateModelTrainingStatus(ctx, training.ID, model.TrainingStatusRunning)
+	var errResponse *model.ErrorResponse
+	if err != nil && !(errors.As(err, &errResponse) && errResponse.Code == http.StatusConflict) {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	response := &model.ResponseTrainModel{ID: training.ID}
+
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+func (c *TrainingController) GetModelTrainings(ctx context.Context, filter *model.FilterModelTraining) ([]*model.ModelTraining, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetModelTrainings")
+	defer span.Finish()
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if institutionID := scope.InstitutionFilter(); institutionID != "" {
+		filter.InstitutionID = institutionID
+	}
+
+	utils.LogEvent(span, "Request", filter)
+
+	res, err := c.trainingClient.GetModelTrainings(ctx, filter)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return res, nil
+}
+
+// UpdateModelTrainingStatus records the progress reported by the processing
+// service: queued trainings start running or fail, running ones succeed or
+// fail.
+func (c *TrainingController) UpdateModelTrainingStatus(ctx context.Context, request *model.RequestTrainingStatus) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: UpdateModelTrainingStatus")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	training, err := c.trainingClient.GetModelTrainingByID(ctx, request.ID)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	// The processing service may repeat a report, e.g. on a retry.
+	if training.Status == request.Status {
+		return nil
+	}
+
+	if !training.CanMoveTo(request.Status) {
+		utils.LogEventError(span, fmt.Errorf("a %s training can't move to %q", training.Status, request.Status))
+		return model.ThrowError(http.StatusConflict, fmt.Errorf("a %s training can't move to %q", training.Status, request.Status))
+	}
+
+	err = c.trainingClient.UpdateModelTrainingStatus(ctx, request.ID, request.Status)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Update Model Training Status")
+
+	return nil
+}
+
+func (c *TrainingController) ActivateModelTraining(ctx context.Context, id string) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ActivateModelTraining")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", id)
+
+	training, err := c.trainingClient.GetModelTrainingByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if scope.Scope == model.ScopeSelf || !scope.AllowsInstitution(training.InstitutionID) {
+		utils.LogEventError(span, errors.New("you are not allowed to activate this institution's model"))
+		return model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to activate this institution's model"))
+	}
+
+	if training.Status != model.TrainingStatusSucceeded {
+		utils.LogEventError(span, errors.New("only succeeded model can be activated"))
+		return model.ThrowError(http.StatusBadRequest, errors.New("only succeeded model can be activated"))
+	}
+
+	err = c.trainingClient.ActivateModelTraining(ctx, training)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	utils.LogEvent(span, "Response", "Success Activate Model Training")
+
+	return nil
+}
@@ -3,22 +3,32 @@ package controller
 import (
 	"bpkp-svc-portal/app/client"
 	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
 	"bpkp-svc-portal/app/utils"
 	"context"
 	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// minPasswordLength is the shortest password users can change theirs to.
+const minPasswordLength = 8
+
 type InterfaceUserController interface {
 	CreateNewUser(ctx context.Context, request *model.User) error
 	GetUserDetail(ctx context.Context, username string) (*model.User, error)
 	UpdateUser(ctx context.Context, request *model.User) error
 	DeleteUser(ctx context.Context, username string) error
 	Login(ctx context.Context, request *model.RequestLogin) (*model.ResponseLogin, error)
-	GetAllUser(ctx context.Context) ([]*model.User, error)
+	RefreshToken(ctx context.Context, request *model.RequestRefreshToken) (*model.ResponseToken, error)
+	Logout(ctx context.Context, claims *model.JwtCustomClaims, request *model.RequestRefreshToken) error
+	SwitchRole(ctx context.Context, claims *model.JwtCustomClaims, request *model.RequestSwitchRole) (*model.ResponseLogin, error)
+	ChangePassword(ctx context.Context, claims *model.JwtCustomClaims, request *model.RequestChangePassword) (*model.ResponseLogin, error)
+	GetAllUser(ctx context.Context, query *model.ListQuery) ([]*model.User, *model.ListPage, error)
+	SearchUsers(ctx context.Context, term string, query *model.ListQuery) ([]*model.User, *model.ListPage, error)
 	GetInstitutionList(ctx context.Context) ([]string, error)
 
 	UploadProfilePhoto(ctx context.Context, file *model.File) error
@@ -26,27 +36,59 @@ type InterfaceUserController interface {
 }
 
 type UserController struct {
-	userClient    client.InterfaceUserClient
-	roleClient    client.InterfaceRoleClient
-	paramClient   client.InterfaceParamClient
-	storageClient client.InterfaceStorageClient
+	userClient        client.InterfaceUserClient
+	roleClient        client.InterfaceRoleClient
+	paramClient       client.InterfaceParamClient
+	storageClient     client.InterfaceStorageClient
+	eventClient       client.InterfaceEventClient
+	tokenClient       client.InterfaceTokenClient
+	institutionClient client.InterfaceInstitutionClient
+	scopePolicy       policy.InterfaceScopePolicy
 }
 
-func NewUserController(userClient client.InterfaceUserClient, roleClient client.InterfaceRoleClient, paramClient client.InterfaceParamClient, storageClient client.InterfaceStorageClient) *UserController {
+func NewUserController(userClient client.InterfaceUserClient, roleClient client.InterfaceRoleClient, paramClient client.InterfaceParamClient, storageClient client.InterfaceStorageClient, eventClient client.InterfaceEventClient, tokenClient client.InterfaceTokenClient, institutionClient client.InterfaceInstitutionClient, scopePolicy policy.InterfaceScopePolicy) *UserController {
 	return &UserController{
-		userClient:    userClient,
-		roleClient:    roleClient,
-		paramClient:   paramClient,
-		storageClient: storageClient,
+		userClient:        userClient,
+		roleClient:        roleClient,
+		paramClient:       paramClient,
+		storageClient:     storageClient,
+		eventClient:       eventClient,
+		tokenClient:       tokenClient,
+		institutionClient: institutionClient,
+		scopePolicy:       scopePolicy,
 	}
 }
 
+// CreateNewUser registers a user pending review by its institution's admins.
+// The role requested is ignored: registrations get the role of the
+// registration-default-role param until approved with another.
 func (c *UserController) CreateNewUser(ctx context.Context, request *model.User) error {
 	span, ctx := utils.SpanFromContext(ctx, "Controller: CreateNewUser")
 	defer span.Finish()
 
 	utils.LogEvent(span, "Request", request)
 
+	institution, err := c.institutionClient.GetInstitutionByID(ctx, request.InstitutionID)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if institution == nil {
+		return model.ThrowError(http.StatusBadRequest, errors.New("institution not found"))
+	}
+
+	role, err := c.paramClient.GetParameterByKey(ctx, "registration-default-role")
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if role == nil || role.Value == "" {
+		utils.LogEventError(span, errors.New("registration-default-role param is not set"))
+		return model.ThrowError(http.StatusInternalServerError, errors.New("registration is not available"))
+	}
+
 	hashPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 	if err != nil {
 		utils.LogEventError(span, err)
@@ -54,12 +96,16 @@ func (c *UserController) CreateNewUser(ctx context.Context, request *model.User)
 	}
 
 	request.Password = string(hashPassword)
+	request.RoleID = role.Value
+	request.RoleIDs = nil
+	request.Status = model.UserPending
 
 	err = c.userClient.CreateNewUser(ctx, request)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
 	}
+
 	return nil
 }
 
@@ -69,35 +115,30 @@ func (c *UserController) GetUserDetail(ctx context.Context, username string) (*m
 
 	utils.LogEvent(span, "Username", username)
 
-	session, err := utils.GetMetadata(ctx)
+	scope, err := c.scopePolicy.Resolve(ctx)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return nil, err
 	}
 
-	utils.LogEvent(span, "Session", session)
-
-	role, err := c.roleClient.GetRoleByID(ctx, session.RoleID)
+	user, err := c.userClient.GetUserDetail(ctx, username)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return nil, err
 	}
-	if role.Level == 3 {
-		return nil, model.ThrowError(http.StatusUnauthorized, errors.New("you are not allowed to access this data (not authorized role)"))
+
+	utils.LogEvent(span, "Response", user)
+
+	if !scope.Allows(user.Username, user.InstitutionID) {
+		return nil, model.ThrowError(http.StatusUnauthorized, errors.New("you are not allowed to access this data (out of role scope)"))
 	}
 
-	user, err := c.userClient.GetUserDetail(ctx, username)
+	user.Roles, err = c.userClient.GetUserRoles(ctx, user.Username)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return nil, err
 	}
 
-	utils.LogEvent(span, "Response", user)
-
-	if role.Level == 2 && user.InstitutionID != session.InstitutionID {
-		return nil, model.ThrowError(http.StatusUnauthorized, errors.New("you are not allowed to access this data (different institution)"))
-	}
-
 	return user, nil
 }
 
@@ -107,12 +148,70 @@ func (c *UserController) UpdateUser(ctx context.Context, request *model.User) er
 
 	utils.LogEvent(span, "Request", request)
 
-	err := c.userClient.UpdateUser(ctx, request)
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	// The user must be within the caller's scope both where it is and where
+	// the request moves it to.
+	if _, err := c.scopedUser(ctx, scope, request.Username); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if !scope.Allows(request.Username, request.InstitutionID) {
+		utils.LogEventError(span, errors.New("institution is out of your scope"))
+		return model.ThrowError(http.StatusForbidden, errors.New("you are not allowed to move users to this institution"))
+	}
+
+	if err := c.grantableRole(ctx, scope, request.RoleID); err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	// role_ids lists the additional roles; leaving it out keeps them as is.
+	var roleIDs []string
+	for _, roleID := range request.RoleIDs {
+		if roleID == "" || roleID == request.RoleID {
+			continue
+		}
+
+		if err := c.grantableRole(ctx, scope, roleID); err != nil {
+			utils.LogEventError(span, err)
+			return err
+		}
+
+		roleIDs = append(roleIDs, roleID)
+	}
+
+	err = c.userClient.UpdateUser(ctx, request)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
 	}
 
+	if request.RoleIDs != nil {
+		err = c.userClient.SetUserRoles(ctx, request.Username, roleIDs, session.Username)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return err
+		}
+	}
+
+	payload := *request
+	payload.Password = ""
+	if err := c.eventClient.Publish(ctx, model.EventUserUpdated, request.InstitutionID, payload); err != nil {
+		utils.LogEventError(span, err)
+	}
+
 	return nil
 }
 
@@ -122,12 +221,62 @@ func (c *UserController) DeleteUser(ctx context.Context, username string) error
 
 	utils.LogEvent(span, "Request", username)
 
-	err := c.userClient.DeleteUser(ctx, username)
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	user, err := c.scopedUser(ctx, scope, username)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return err
 	}
 
+	err = c.userClient.DeleteUser(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return err
+	}
+
+	if err := c.eventClient.Publish(ctx, model.EventUserDeleted, user.InstitutionID, map[string]string{"username": username}); err != nil {
+		utils.LogEventError(span, err)
+	}
+
+	return nil
+}
+
+// scopedUser loads a user the caller's scope allows; others are reported as
+// missing.
+func (c *UserController) scopedUser(ctx context.Context, scope *model.DataScope, username string) (*model.User, error) {
+	user, err := c.userClient.GetUserDetail(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if !scope.Allows(user.Username, user.InstitutionID) {
+		return nil, model.ThrowError(http.StatusNotFound, errors.New("user not found"))
+	}
+
+	return user, nil
+}
+
+// grantableRole checks that roleID is an active role that sees no more than
+// the caller's scope.
+func (c *UserController) grantableRole(ctx context.Context, scope *model.DataScope, roleID string) error {
+	role, err := c.roleClient.GetRoleByID(ctx, roleID)
+	if err != nil {
+		return err
+	}
+
+	if role == nil || !role.IsActive {
+		return model.ThrowError(http.StatusBadRequest, fmt.Errorf("role %q not found", roleID))
+	}
+
+	if !scope.Covers(role.DataScope()) {
+		return model.ThrowError(http.StatusForbidden, errors.New("you can't grant a role that sees more than yours"))
+	}
+
 	return nil
 }
 
@@ -148,38 +297,70 @@ func (c *UserController) Login(ctx context.Context, request *model.RequestLogin)
 		return nil, model.ThrowError(http.StatusBadRequest, errors.New("invalid username or password "))
 	}
 
-	role, err := c.roleClient.GetMenuRoleMapping(ctx, user.RoleID)
+	switch {
+	case user.Status == model.UserPending:
+		return nil, model.ThrowError(http.StatusForbidden, errors.New("your registration is awaiting approval"))
+	case !user.IsApproved():
+		return nil, model.ThrowError(http.StatusForbidden, fmt.Errorf("your registration was rejected: %s", user.RejectReason))
+	}
+
+	roles, err := c.userClient.GetUserRoles(ctx, user.Username)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return nil, err
 	}
 
-	if len(role) < 1 {
-		utils.LogEventError(span, errors.New("menu role mapping not found"))
-		return nil, model.ThrowError(http.StatusBadRequest, errors.New("menu role mapping not found"))
+	response, err := c.signIn(ctx, user, roles, uuid.New().String())
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
 	}
 
-	menuMapping := make(map[string]string)
-	for _, v := range role {
-		menuMapping[v.MenuID] = v.AccessMethod
+	utils.LogEvent(span, "Response", response)
+
+	return response, nil
+}
+
+// SwitchRole re-issues the caller's tokens for another of its assigned roles.
+// The current access token is revoked so the previous role cannot be used.
+func (c *UserController) SwitchRole(ctx context.Context, claims *model.JwtCustomClaims, request *model.RequestSwitchRole) (*model.ResponseLogin, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: SwitchRole")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", request)
+
+	user, err := c.userClient.GetUserDetail(ctx, claims.Name)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
 	}
 
-	accessToken, _, err := c.userClient.CreateAccessToken(ctx, user, false, menuMapping)
+	roles, err := c.userClient.GetUserRoles(ctx, user.Username)
 	if err != nil {
 		utils.LogEventError(span, err)
-		return nil, model.ThrowError(http.StatusInternalServerError, err)
+		return nil, err
+	}
+
+	if !setActiveRole(user, roles, request.RoleID) {
+		utils.LogEventError(span, errors.New("role is not assigned to user"))
+		return nil, model.ThrowError(http.StatusForbidden, errors.New("role is not assigned to user"))
+	}
+
+	family := claims.Family
+	if family == "" {
+		family = uuid.New().String()
+	}
+
+	response, err := c.signIn(ctx, user, roles, family)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
 	}
 
-	response := &model.ResponseLogin{
-		Username:        user.Username,
-		Fullname:        user.Fullname,
-		Shortname:       user.Shortname,
-		Role:            user.RoleID,
-		RoleName:        user.RoleName,
-		Token:           accessToken,
-		InstitutionID:   user.InstitutionID,
-		InstitutionName: user.InstitutionName,
-		MenuMapping:     role,
+	if claims.ExpiresAt != nil {
+		if err := c.tokenClient.DenyAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			utils.LogEventError(span, err)
+		}
 	}
 
 	utils.LogEvent(span, "Response", response)
@@ -187,38 +368,330 @@ func (c *UserController) Login(ctx context.Context, request *model.RequestLogin)
 	return response, nil
 }
 
-func (c *UserController) GetAllUser(ctx context.Context) ([]*model.User, error) {
-	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllUser")
+// ChangePassword replaces the caller's password and re-issues its tokens, which
+// are no longer limited to changing the password.
+func (c *UserController) ChangePassword(ctx context.Context, claims *model.JwtCustomClaims, request *model.RequestChangePassword) (*model.ResponseLogin, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ChangePassword")
 	defer span.Finish()
 
-	session, err := utils.GetMetadata(ctx)
+	utils.LogEvent(span, "Request", claims.Name)
+
+	if len(request.NewPassword) < minPasswordLength {
+		return nil, model.ThrowError(http.StatusBadRequest, fmt.Errorf("new_password must be at least %d characters", minPasswordLength))
+	}
+
+	if request.NewPassword == request.OldPassword {
+		return nil, model.ThrowError(http.StatusBadRequest, errors.New("new_password must differ from old_password"))
+	}
+
+	user, err := c.userClient.GetUserDetail(ctx, claims.Name)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return nil, err
 	}
 
-	role, err := c.roleClient.GetRoleByID(ctx, session.RoleID)
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.OldPassword)); err != nil {
+		utils.LogEventError(span, errors.New("invalid old password"))
+		return nil, model.ThrowError(http.StatusBadRequest, errors.New("invalid old password"))
+	}
+
+	hashPassword, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, model.ThrowError(http.StatusInternalServerError, err)
+	}
+
+	if err := c.userClient.UpdatePassword(ctx, user.Username, string(hashPassword)); err != nil {
 		utils.LogEventError(span, err)
 		return nil, err
 	}
 
-	users, err := c.userClient.GetAllUser(ctx, role.Level, session.InstitutionID)
+	user.MustChangePassword = false
+
+	roles, err := c.userClient.GetUserRoles(ctx, user.Username)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return nil, err
 	}
 
+	setActiveRole(user, roles, claims.Role)
+
+	family := claims.Family
+	if family == "" {
+		family = uuid.New().String()
+	}
+
+	response, err := c.signIn(ctx, user, roles, family)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if claims.ExpiresAt != nil {
+		if err := c.tokenClient.DenyAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			utils.LogEventError(span, err)
+		}
+	}
+
+	utils.LogEvent(span, "Response", "Success Change Password")
+
+	return response, nil
+}
+
+// signIn issues tokens for the user's active role (user.RoleID) and builds
+// the login response listing every role the user can switch to.
+func (c *UserController) signIn(ctx context.Context, user *model.User, roles []*model.UserRole, family string) (*model.ResponseLogin, error) {
+	role, menuMapping, err := c.getMenuMapping(ctx, user.RoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := c.issueTokens(ctx, user, menuMapping, family, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ResponseLogin{
+		Username:           user.Username,
+		Fullname:           user.Fullname,
+		Shortname:          user.Shortname,
+		Role:               user.RoleID,
+		RoleName:           user.RoleName,
+		Token:              token.Token,
+		RefreshToken:       token.RefreshToken,
+		ExpiresAt:          token.ExpiresAt,
+		InstitutionID:      user.InstitutionID,
+		InstitutionName:    user.InstitutionName,
+		MenuMapping:        role,
+		Roles:              roles,
+		MustChangePassword: user.MustChangePassword,
+	}, nil
+}
+
+// setActiveRole makes roleID the user's active role if it is one of roles.
+func setActiveRole(user *model.User, roles []*model.UserRole, roleID string) bool {
+	for _, v := range roles {
+		if v.RoleID == roleID {
+			user.RoleID = v.RoleID
+			user.RoleName = v.RoleName
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *UserController) RefreshToken(ctx context.Context, request *model.RequestRefreshToken) (*model.ResponseToken, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: RefreshToken")
+	defer span.Finish()
+
+	claims, err := c.userClient.ParseRefreshToken(ctx, request.RefreshToken)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	user, err := c.userClient.GetUserDetail(ctx, claims.Name)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	// Keep the role the session switched to while it is still assigned,
+	// otherwise fall back to the default role.
+	if claims.Role != "" && claims.Role != user.RoleID {
+		roles, err := c.userClient.GetUserRoles(ctx, user.Username)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return nil, err
+		}
+		setActiveRole(user, roles, claims.Role)
+	}
+
+	_, menuMapping, err := c.getMenuMapping(ctx, user.RoleID)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	// The presented token is only rotated out if it is still the family's
+	// valid one; a reused or revoked token fails here.
+	response, err := c.issueTokens(ctx, user, menuMapping, claims.Family, claims.ID)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (c *UserController) Logout(ctx context.Context, claims *model.JwtCustomClaims, request *model.RequestRefreshToken) error {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: Logout")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", claims.Name)
+
+	families := []string{claims.Family}
+
+	if request != nil && request.RefreshToken != "" {
+		refreshClaims, err := c.userClient.ParseRefreshToken(ctx, request.RefreshToken)
+		if err == nil && refreshClaims.Name == claims.Name && refreshClaims.Family != claims.Family {
+			families = append(families, refreshClaims.Family)
+		}
+	}
+
+	for _, family := range families {
+		if family == "" {
+			continue
+		}
+		if err := c.tokenClient.RevokeRefreshFamily(ctx, family); err != nil {
+			utils.LogEventError(span, err)
+			return err
+		}
+	}
+
+	if claims.ExpiresAt != nil {
+		if err := c.tokenClient.DenyAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			utils.LogEventError(span, err)
+			return err
+		}
+	}
+
+	utils.LogEvent(span, "Response", "Success Logout")
+
+	return nil
+}
+
+func (c *UserController) getMenuMapping(ctx context.Context, roleID string) ([]*model.MenuRoleMapping, map[string]string, error) {
+	role, err := c.roleClient.GetMenuRoleMapping(ctx, roleID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(role) < 1 {
+		return nil, nil, model.ThrowError(http.StatusBadRequest, errors.New("menu role mapping not found"))
+	}
+
+	menuMapping := make(map[string]string)
+	for _, v := range role {
+		menuMapping[v.MenuID] = v.AccessMethod
+	}
+
+	return role, menuMapping, nil
+}
+
+// issueTokens signs a short-lived access token and a refresh token for the
+// given family and makes the new refresh token the only valid one of it. A
+// refresh rotates out the token it presented, replaces, which must still be
+// the family's valid one.
+func (c *UserController) issueTokens(ctx context.Context, user *model.User, menuMapping map[string]string, family string, replaces string) (*model.ResponseToken, error) {
+	accessToken, expired, err := c.userClient.CreateAccessToken(ctx, user, false, menuMapping, family)
+	if err != nil {
+		return nil, model.ThrowError(http.StatusInternalServerError, err)
+	}
+
+	refreshToken, refreshClaims, err := c.userClient.CreateRefreshToken(ctx, user, family)
+	if err != nil {
+		return nil, model.ThrowError(http.StatusInternalServerError, err)
+	}
+
+	if replaces == "" {
+		err = c.tokenClient.StoreRefreshToken(ctx, family, refreshClaims.ID, refreshClaims.ExpiresAt.Time)
+	} else {
+		err = c.tokenClient.RotateRefreshToken(ctx, family, replaces, refreshClaims.ID, refreshClaims.ExpiresAt.Time)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ResponseToken{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expired,
+	}, nil
+}
+
+func (c *UserController) GetAllUser(ctx context.Context, query *model.ListQuery) ([]*model.User, *model.ListPage, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: GetAllUser")
+	defer span.Finish()
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	query.Scope = scope
+
+	users, page, err := c.userClient.GetAllUser(ctx, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	usernames := make([]string, 0, len(users))
+	for _, v := range users {
+		usernames = append(usernames, v.Username)
+	}
+
+	roles, err := c.userClient.GetUserRoles(ctx, usernames...)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	userRoles := make(map[string][]*model.UserRole)
+	for _, v := range roles {
+		userRoles[v.Username] = append(userRoles[v.Username], v)
+	}
+
+	for _, v := range users {
+		v.Roles = userRoles[v.Username]
+	}
+
+	utils.LogEvent(span, "Response", users)
+
+	return users, page, nil
+}
+
+// SearchUsers finds the users within the caller's scope whose username, name,
+// email or phone number starts with term.
+func (c *UserController) SearchUsers(ctx context.Context, term string, query *model.ListQuery) ([]*model.User, *model.ListPage, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: SearchUsers")
+	defer span.Finish()
+
+	utils.LogEvent(span, "Request", term)
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
+	query.Scope = scope
+
+	users, page, err := c.userClient.SearchUsers(ctx, term, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, nil, err
+	}
+
 	utils.LogEvent(span, "Response", users)
 
-	return users, nil
+	return users, page, nil
 }
 
 func (c *UserController) GetInstitutionList(ctx context.Context) ([]string, error) {
 	span, ctx := utils.SpanFromContext(ctx, "Controller: GetInstitutionList")
 	defer span.Finish()
 
-	institutionList, err := c.userClient.GetInstitutionList(ctx)
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	institutionList, err := c.userClient.GetInstitutionList(ctx, scope)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return nil, err
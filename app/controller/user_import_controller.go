@@ -0,0 +1,401 @@
+package controller
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/policy"
+	"bpkp-svc-portal/app/utils"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxImportRows is the most users one spreadsheet can import.
+const maxImportRows = 1000
+
+// importBatchSize is how many users are inserted per statement. A batch that
+// fails, e.g. because a user was registered meanwhile, fails all its rows.
+const importBatchSize = 100
+
+// importColumns are the spreadsheet columns an import understands.
+var importColumns = map[string]bool{
+	"username":       true,
+	"email":          true,
+	"password":       true,
+	"fullname":       true,
+	"shortname":      true,
+	"role_id":        true,
+	"institution_id": true,
+	"address":        true,
+	"phone_number":   true,
+	"gender":         true,
+	"religion":       true,
+}
+
+// passwordAlphabet leaves out characters that are easily mistaken for one
+// another, such as 0/O and 1/l.
+const passwordAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// userValidator checks users against their validate tags, naming fields by
+// their JSON names.
+var userValidator = newUserValidator()
+
+type InterfaceUserImportController interface {
+	ImportUsers(ctx context.Context, request *model.RequestUserImport) (*model.UserImportReport, error)
+}
+
+type UserImportController struct {
+	userClient        client.InterfaceUserClient
+	roleClient        client.InterfaceRoleClient
+	institutionClient client.InterfaceInstitutionClient
+	eventClient       client.InterfaceEventClient
+	scopePolicy       policy.InterfaceScopePolicy
+}
+
+func NewUserImportController(userClient client.InterfaceUserClient, roleClient client.InterfaceRoleClient, institutionClient client.InterfaceInstitutionClient, eventClient client.InterfaceEventClient, scopePolicy policy.InterfaceScopePolicy) *UserImportController {
+	return &UserImportController{
+		userClient:        userClient,
+		roleClient:        roleClient,
+		institutionClient: institutionClient,
+		eventClient:       eventClient,
+		scopePolicy:       scopePolicy,
+	}
+}
+
+// importedUser is a spreadsheet row read as a user.
+type importedUser struct {
+	report    *model.UserImportRow
+	user      *model.User
+	generated bool
+}
+
+// ImportUsers validates the users of a spreadsheet and, unless it is a dry
+// run, creates the valid ones. Each user must have an active role that sees
+// no more than the caller does, an institution within the caller's scope,
+// and a username and email not taken by an existing user or an earlier row.
+// Users given a generated password must change it when they first log in.
+func (c *UserImportController) ImportUsers(ctx context.Context, request *model.RequestUserImport) (*model.UserImportReport, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Controller: ImportUsers")
+	defer span.Finish()
+
+	if request.File == nil {
+		return nil, model.ThrowError(http.StatusBadRequest, errors.New("file shouldn't be empty"))
+	}
+
+	utils.LogEvent(span, "Request", request.File.FileName)
+
+	format := strings.ToLower(request.File.Extension)
+	if format != model.ExportCSV && format != model.ExportXLSX {
+		return nil, model.ThrowError(http.StatusBadRequest, errors.New("file must be a csv or xlsx spreadsheet"))
+	}
+
+	rows, err := utils.ReadSheet(bytes.NewReader(request.File.BytesObject), format)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, model.ThrowError(http.StatusBadRequest, fmt.Errorf("can't read the spreadsheet: %w", err))
+	}
+
+	users, err := readImportedUsers(rows)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	scope, err := c.scopePolicy.Resolve(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if err := c.validate(ctx, scope, users); err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	report := &model.UserImportReport{DryRun: request.DryRun, Total: len(users)}
+
+	var valid []*importedUser
+	for _, v := range users {
+		report.Rows = append(report.Rows, v.report)
+
+		if len(v.report.Errors) > 0 {
+			v.report.Status = model.ImportRowFailed
+			report.Failed++
+			continue
+		}
+
+		v.report.Status = model.ImportRowValid
+		report.Valid++
+		valid = append(valid, v)
+	}
+
+	if request.DryRun {
+		return report, nil
+	}
+
+	for start := 0; start < len(valid); start += importBatchSize {
+		batch := valid[start:min(start+importBatchSize, len(valid))]
+
+		if err := c.create(ctx, batch); err != nil {
+			utils.LogEventError(span, err)
+
+			for _, v := range batch {
+				v.report.Status = model.ImportRowFailed
+				v.report.Errors = append(v.report.Errors, err.Error())
+			}
+			report.Failed += len(batch)
+			continue
+		}
+
+		for _, v := range batch {
+			v.report.Status = model.ImportRowCreated
+			if v.generated {
+				v.report.GeneratedPassword = v.user.Password
+			}
+
+			payload := *v.user
+			payload.Password = ""
+			if err := c.eventClient.Publish(ctx, model.EventUserCreated, payload.InstitutionID, payload); err != nil {
+				utils.LogEventError(span, err)
+			}
+		}
+		report.Created += len(batch)
+	}
+
+	utils.LogEvent(span, "Response", fmt.Sprintf("created %d, failed %d", report.Created, report.Failed))
+
+	return report, nil
+}
+
+// readImportedUsers reads the users under the header row, skipping blank
+// rows. Rows without a password get a generated one.
+func readImportedUsers(rows [][]string) ([]*importedUser, error) {
+	if len(rows) < 2 {
+		return nil, model.ThrowError(http.StatusBadRequest, errors.New("the spreadsheet has no users under its header row"))
+	}
+
+	header := make([]string, len(rows[0]))
+	for i, v := range rows[0] {
+		header[i] = strings.ToLower(strings.TrimSpace(v))
+		if header[i] != "" && !importColumns[header[i]] {
+			return nil, model.ThrowError(http.StatusBadRequest, fmt.Errorf("unknown column %q", v))
+		}
+	}
+
+	var users []*importedUser
+	for i, row := range rows[1:] {
+		values := make(map[string]string)
+		for j, v := range row {
+			if j < len(header) && header[j] != "" {
+				values[header[j]] = strings.TrimSpace(v)
+			}
+		}
+
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		user := &model.User{
+			Username:      values["username"],
+			Email:         values["email"],
+			Password:      values["password"],
+			Fullname:      values["fullname"],
+			Shortname:     values["shortname"],
+			RoleID:        values["role_id"],
+			InstitutionID: values["institution_id"],
+			Address:       values["address"],
+			PhoneNumber:   values["phone_number"],
+			Gender:        values["gender"],
+			Religion:      values["religion"],
+		}
+
+		imported := &importedUser{
+			report: &model.UserImportRow{Row: i + 2, Username: user.Username},
+			user:   user,
+		}
+
+		if user.Password == "" {
+			password, err := generatePassword(12)
+			if err != nil {
+				return nil, err
+			}
+
+			user.Password = password
+			user.MustChangePassword = true
+			imported.generated = true
+		}
+
+		users = append(users, imported)
+	}
+
+	if len(users) == 0 {
+		return nil, model.ThrowError(http.StatusBadRequest, errors.New("the spreadsheet has no users under its header row"))
+	}
+
+	if len(users) > maxImportRows {
+		return nil, model.ThrowError(http.StatusBadRequest, fmt.Errorf("a spreadsheet can import at most %d users", maxImportRows))
+	}
+
+	return users, nil
+}
+
+// validate records the errors of each user in its report row.
+func (c *UserImportController) validate(ctx context.Context, scope *model.DataScope, users []*importedUser) error {
+	roles, err := c.roleClient.GetAllRole(ctx)
+	if err != nil {
+		return err
+	}
+
+	activeRoles := make(map[string]*model.Role)
+	for _, v := range roles {
+		if v.IsActive {
+			activeRoles[v.Id] = v
+		}
+	}
+
+	institutions, _, err := c.institutionClient.GetAllInstitutions(ctx, scope.InstitutionFilter(), &model.ListQuery{Unpaged: true})
+	if err != nil {
+		return err
+	}
+
+	scopedInstitutions := make(map[string]bool)
+	for _, v := range institutions {
+		scopedInstitutions[v.ID] = true
+	}
+
+	var usernames, emails []string
+	usernameRows := make(map[string]int)
+	emailRows := make(map[string]int)
+
+	for _, v := range users {
+		errs := validationErrors(userValidator.Struct(v.user))
+
+		if role, ok := activeRoles[v.user.RoleID]; v.user.RoleID != "" && !ok {
+			errs = append(errs, fmt.Sprintf("role %q doesn't exist or isn't active", v.user.RoleID))
+		} else if ok && !scope.Covers(role.DataScope()) {
+			errs = append(errs, fmt.Sprintf("role %q sees more than yours", v.user.RoleID))
+		}
+
+		if v.user.InstitutionID != "" && !scopedInstitutions[v.user.InstitutionID] {
+			errs = append(errs, fmt.Sprintf("institution %q doesn't exist or is out of your scope", v.user.InstitutionID))
+		}
+
+		username := strings.ToLower(v.user.Username)
+		if row, ok := usernameRows[username]; ok && username != "" {
+			errs = append(errs, fmt.Sprintf("username is already used in row %d", row))
+		} else if username != "" {
+			usernameRows[username] = v.report.Row
+			usernames = append(usernames, v.user.Username)
+		}
+
+		email := strings.ToLower(v.user.Email)
+		if row, ok := emailRows[email]; ok && email != "" {
+			errs = append(errs, fmt.Sprintf("email is already used in row %d", row))
+		} else if email != "" {
+			emailRows[email] = v.report.Row
+			emails = append(emails, v.user.Email)
+		}
+
+		v.report.Errors = errs
+	}
+
+	existing, err := c.userClient.GetExistingUsers(ctx, usernames, emails)
+	if err != nil {
+		return err
+	}
+
+	takenUsernames := make(map[string]bool)
+	takenEmails := make(map[string]bool)
+	for _, v := range existing {
+		takenUsernames[strings.ToLower(v.Username)] = true
+		takenEmails[strings.ToLower(v.Email)] = true
+	}
+
+	for _, v := range users {
+		if takenUsernames[strings.ToLower(v.user.Username)] {
+			v.report.Errors = append(v.report.Errors, "username already exists")
+		}
+
+		if takenEmails[strings.ToLower(v.user.Email)] {
+			v.report.Errors = append(v.report.Errors, "email already exists")
+		}
+	}
+
+	return nil
+}
+
+// create hashes the passwords of a batch, keeping the generated ones in
+// clear for the report, and inserts it.
+func (c *UserImportController) create(ctx context.Context, batch []*importedUser) error {
+	users := make([]*model.User, 0, len(batch))
+	for _, v := range batch {
+		hashPassword, err := bcrypt.GenerateFromPassword([]byte(v.user.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+
+		user := *v.user
+		user.Password = string(hashPassword)
+		users = append(users, &user)
+	}
+
+	return c.userClient.CreateUsers(ctx, users)
+}
+
+func newUserValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+
+	return validate
+}
+
+// validationErrors describes the failed validations of err, if any.
+func validationErrors(err error) []string {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	}
+
+	var errs []string
+	for _, v := range fieldErrors {
+		if v.Tag() == "required" {
+			errs = append(errs, v.Field()+" shouldn't be empty")
+			continue
+		}
+
+		errs = append(errs, fmt.Sprintf("%s fails %s validation", v.Field(), v.Tag()))
+	}
+
+	return errs
+}
+
+// generatePassword returns a random password of n characters.
+func generatePassword(n int) (string, error) {
+	max := big.NewInt(int64(len(passwordAlphabet)))
+
+	password := make([]byte, n)
+	for i := range password {
+		index, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+
+		password[i] = passwordAlphabet[index.Int64()]
+	}
+
+	return string(password), nil
+}
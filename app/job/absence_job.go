@@ -0,0 +1,82 @@
+package job
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// absenceRunAt is the time of day (Asia/Jakarta) the absence job runs.
+const absenceRunAt = 23*time.Hour + 55*time.Minute
+
+// absenceLockTTL keeps a run's lock long enough that no other replica starts
+// the same day's run, even with some clock drift between them.
+const absenceLockTTL = 2 * time.Hour
+
+// AbsenceJob marks missed work dates as Absent or Missing Checkout at the end
+// of each day. Every replica schedules it, but a Redis lock per day lets only
+// one of them run it.
+type AbsenceJob struct {
+	redis      *redis.Client
+	attendance controller.InterfaceAttendanceController
+}
+
+func NewAbsenceJob(redis *redis.Client, attendance controller.InterfaceAttendanceController) *AbsenceJob {
+	return &AbsenceJob{
+		redis:      redis,
+		attendance: attendance,
+	}
+}
+
+// Start schedules the job in the background.
+func (j *AbsenceJob) Start() {
+	go func() {
+		for {
+			next := nextRun(utils.LocalTime())
+			logrus.Printf("Absence job scheduled at %s", next.Format(time.RFC3339))
+
+			time.Sleep(time.Until(next))
+			j.run(next)
+		}
+	}()
+}
+
+func (j *AbsenceJob) run(at time.Time) {
+	span, ctx := utils.SpanFromContext(context.Background(), "Job: DetectAbsences")
+	defer span.Finish()
+
+	owner, _ := os.Hostname()
+	key := "absence-job:" + at.Format("2006-01-02")
+
+	locked, err := j.redis.SetNX(ctx, key, owner, absenceLockTTL).Result()
+	if err != nil {
+		utils.LogEventError(span, err)
+		return
+	}
+
+	if !locked {
+		utils.LogEvent(span, "Skipped", key+" is held by another replica")
+		return
+	}
+
+	// A failed run is caught up by the next one, which checks yesterday again.
+	if err := j.attendance.DetectAbsences(ctx, utils.LocalTime()); err != nil {
+		utils.LogEventError(span, err)
+		logrus.Errorf("Absence job failed: %v", err)
+	}
+}
+
+// nextRun returns the first run time after now.
+func nextRun(now time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(absenceRunAt)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
@@ -2,41 +2,185 @@ package model
 
 import "time"
 
+const (
+	StatusOnTime = "On Time"
+	StatusLate   = "Late"
+	StatusEarly  = "Early"
+	StatusNormal = "Normal"
+	// StatusOvertime marks check-ins and check-outs on a non-working day.
+	StatusOvertime = "Overtime"
+	// Statuses of days covered by an approved leave.
+	StatusSickLeave   = "Sick Leave"
+	StatusAnnualLeave = "Annual Leave"
+	StatusDutyTrip    = "Duty Trip"
+	// Statuses the absence job records for work dates that were missed.
+	StatusAbsent          = "Absent"
+	StatusMissingCheckout = "Missing Checkout"
+)
+
 type RequestUserAttendances struct {
 	Username      string `json:"username" validate:"required"`
 	InstitutionID string `json:"institution_id"`
 	RoleID        string `json:"role_id"`
-	RoleLevel     int    `json:"role_level"`
-	Filter        Filter `json:"filter"`
+	// Filter is the paging of older clients, used when the ListQuery sets
+	// neither sort nor size.
+	Filter Filter `json:"filter"`
+	ListQuery
 }
 
 type Attendance struct {
 	ID        string    `json:"id" gorm:"column:id"`
-	Username  string    `json:"username" gorm:"column:username" validate:"required"`
+	Username  string    `json:"username" gorm:"column:username" validate:"required" form:"username"`
 	CheckIn   time.Time `json:"check_in" gorm:"column:check_in"`
 	CheckOut  time.Time `json:"check_out" gorm:"column:check_out"`
 	StatusIn  string    `json:"status_in" gorm:"column:status_in"`
 	StatusOut string    `json:"status_out" gorm:"column:status_out"`
-	RemarkIn  string    `json:"remark_in" gorm:"column:remark_in"`
+	RemarkIn  string    `json:"remark_in" gorm:"column:remark_in" form:"remark_in"`
 	RemarkOut string    `json:"remark_out" gorm:"column:remark_out"`
 	SourceIn  string    `json:"source_in" gorm:"column:source_in"`
 	SourceOut string    `json:"source_out" gorm:"column:source_out"`
+	WorkDate  string    `json:"work_date" gorm:"column:work_date"`
+	ShiftID   string    `json:"shift_id" gorm:"column:shift_id"`
+	// MinutesLate and MinutesEarly are counted from the shift start and end,
+	// and only set when the status is Late or Early.
+	MinutesLate   int `json:"minutes_late" gorm:"column:minutes_late"`
+	MinutesEarly  int `json:"minutes_early" gorm:"column:minutes_early"`
+	WorkedMinutes int `json:"worked_minutes" gorm:"column:worked_minutes"`
+	// OvertimeMinutes are worked past the shift end, or all worked minutes on
+	// a non-working day. They only count once an overtime request confirms
+	// them.
+	OvertimeMinutes int `json:"overtime_minutes" gorm:"column:overtime_minutes"`
+	// CorrectedBy and CorrectedAt are set once an approved correction has
+	// changed the row.
+	CorrectedBy string     `json:"corrected_by" gorm:"column:corrected_by"`
+	CorrectedAt *time.Time `json:"corrected_at" gorm:"column:corrected_at"`
+	// Latitude, Longitude and Accuracy (meters) are where a check-in or
+	// check-out request was made, stored as the In or Out location.
+	Latitude  *float64 `json:"latitude,omitempty" gorm:"-" form:"latitude"`
+	Longitude *float64 `json:"longitude,omitempty" gorm:"-" form:"longitude"`
+	Accuracy  *float64 `json:"accuracy,omitempty" gorm:"-" form:"accuracy"`
+	AttendanceLocation
+	// SelfieIn and FaceScoreIn are the selfie of a selfie-verified check-in
+	// and the confidence the face model matched it to the user with.
+	SelfieIn    string   `json:"selfie_in" gorm:"column:selfie_in"`
+	FaceScoreIn *float64 `json:"face_score_in" gorm:"column:face_score_in"`
+	Selfie      *File    `json:"-" gorm:"-"`
+}
+
+// SourceMobile is the source of check-ins made through the check-in and
+// check-out endpoints, which must carry a location. Clients can't choose it.
+const SourceMobile = "mobile"
+
+// SourceSystem is the source of statuses written by the absence job. Its
+// Absent rows have no check-in and give way to leave approved afterwards.
+const SourceSystem = "system"
+
+// Geofence results stored with a located check-in or check-out.
+const (
+	GeofenceInside    = "inside"
+	GeofenceOutside   = "outside"
+	GeofenceExempt    = "exempt"
+	GeofenceUnchecked = "unchecked"
+)
+
+// AttendanceLocation is where a row was checked in and out, and how each
+// compared against the user's institution geofence.
+type AttendanceLocation struct {
+	LatitudeIn   *float64 `json:"latitude_in" gorm:"column:latitude_in"`
+	LongitudeIn  *float64 `json:"longitude_in" gorm:"column:longitude_in"`
+	AccuracyIn   *float64 `json:"accuracy_in" gorm:"column:accuracy_in"`
+	GeofenceIn   string   `json:"geofence_in" gorm:"column:geofence_in"`
+	LatitudeOut  *float64 `json:"latitude_out" gorm:"column:latitude_out"`
+	LongitudeOut *float64 `json:"longitude_out" gorm:"column:longitude_out"`
+	AccuracyOut  *float64 `json:"accuracy_out" gorm:"column:accuracy_out"`
+	GeofenceOut  string   `json:"geofence_out" gorm:"column:geofence_out"`
 }
 
 type UserAttendance struct {
-	ID          string    `json:"id" gorm:"column:id"`
-	Username    string    `json:"username" gorm:"column:username" validate:"required"`
-	CheckIn     time.Time `json:"check_in" gorm:"column:check_in"`
-	CheckOut    time.Time `json:"check_out" gorm:"column:check_out"`
-	StatusIn    string    `json:"status_in" gorm:"column:status_in"`
-	StatusOut   string    `json:"status_out" gorm:"column:status_out"`
-	RemarkIn    string    `json:"remark_in" gorm:"column:remark_in"`
-	RemarkOut   string    `json:"remark_out" gorm:"column:remark_out"`
-	SourceIn    string    `json:"source_in" gorm:"column:source_in"`
-	SourceOut   string    `json:"source_out" gorm:"column:source_out"`
-	Fullname    string    `json:"fullname" gorm:"column:fullname"`
-	Shortname   string    `json:"shortname" gorm:"column:shortname"`
-	Email       string    `json:"email" gorm:"column:email"`
-	Gender      string    `json:"gender" gorm:"column:gender"`
-	PhoneNumber string    `json:"phone_number" gorm:"column:phone_number"`
+	ID              string     `json:"id" gorm:"column:id"`
+	Username        string     `json:"username" gorm:"column:username" validate:"required"`
+	CheckIn         time.Time  `json:"check_in" gorm:"column:check_in"`
+	CheckOut        time.Time  `json:"check_out" gorm:"column:check_out"`
+	StatusIn        string     `json:"status_in" gorm:"column:status_in"`
+	StatusOut       string     `json:"status_out" gorm:"column:status_out"`
+	RemarkIn        string     `json:"remark_in" gorm:"column:remark_in"`
+	RemarkOut       string     `json:"remark_out" gorm:"column:remark_out"`
+	SourceIn        string     `json:"source_in" gorm:"column:source_in"`
+	SourceOut       string     `json:"source_out" gorm:"column:source_out"`
+	WorkDate        string     `json:"work_date" gorm:"column:work_date"`
+	ShiftID         string     `json:"shift_id" gorm:"column:shift_id"`
+	MinutesLate     int        `json:"minutes_late" gorm:"column:minutes_late"`
+	MinutesEarly    int        `json:"minutes_early" gorm:"column:minutes_early"`
+	WorkedMinutes   int        `json:"worked_minutes" gorm:"column:worked_minutes"`
+	OvertimeMinutes int        `json:"overtime_minutes" gorm:"column:overtime_minutes"`
+	CorrectedBy     string     `json:"corrected_by" gorm:"column:corrected_by"`
+	CorrectedAt     *time.Time `json:"corrected_at" gorm:"column:corrected_at"`
+	AttendanceLocation
+	SelfieIn    string   `json:"selfie_in" gorm:"column:selfie_in"`
+	FaceScoreIn *float64 `json:"face_score_in" gorm:"column:face_score_in"`
+	// DayType is a calendar day type on holidays, otherwise working_day or
+	// off_day.
+	DayType     string `json:"day_type" gorm:"column:day_type"`
+	HolidayName string `json:"holiday_name" gorm:"column:holiday_name"`
+	Fullname    string `json:"fullname" gorm:"column:fullname"`
+	Shortname   string `json:"shortname" gorm:"column:shortname"`
+	Email       string `json:"email" gorm:"column:email"`
+	Gender      string `json:"gender" gorm:"column:gender"`
+	PhoneNumber string `json:"phone_number" gorm:"column:phone_number"`
+}
+
+// RequestAttendanceRecap selects the month (YYYY-MM) to recap and optionally
+// one institution or user within the caller's scope.
+type RequestAttendanceRecap struct {
+	Month         string     `json:"month"`
+	InstitutionID string     `json:"institution_id"`
+	Username      string     `json:"username"`
+	Scope         *DataScope `json:"-"`
+}
+
+// RecapCounts are the attendance totals of a month. NoRecord counts only the
+// workdays the absence job marked Absent, not every workday without a row.
+type RecapCounts struct {
+	OnTime          int     `json:"on_time" gorm:"column:on_time"`
+	Late            int     `json:"late" gorm:"column:late"`
+	EarlyLeave      int     `json:"early_leave" gorm:"column:early_leave"`
+	MissingCheckout int     `json:"missing_checkout" gorm:"column:missing_checkout"`
+	NoRecord        int     `json:"no_record" gorm:"column:no_record"`
+	MinutesLate     int     `json:"minutes_late" gorm:"column:minutes_late"`
+	WorkedMinutes   int     `json:"worked_minutes" gorm:"column:worked_minutes"`
+	WorkedHours     float64 `json:"worked_hours" gorm:"-"`
+}
+
+// Add adds other's totals to c.
+func (c *RecapCounts) Add(other RecapCounts) {
+	c.OnTime += other.OnTime
+	c.Late += other.Late
+	c.EarlyLeave += other.EarlyLeave
+	c.MissingCheckout += other.MissingCheckout
+	c.NoRecord += other.NoRecord
+	c.MinutesLate += other.MinutesLate
+	c.WorkedMinutes += other.WorkedMinutes
+}
+
+type UserRecap struct {
+	Username        string `json:"username" gorm:"column:username"`
+	Fullname        string `json:"fullname" gorm:"column:fullname"`
+	InstitutionID   string `json:"institution_id" gorm:"column:institution_id"`
+	InstitutionName string `json:"institution_name" gorm:"column:institution_name"`
+	RecapCounts
+}
+
+type InstitutionRecap struct {
+	InstitutionID   string `json:"institution_id"`
+	InstitutionName string `json:"institution_name"`
+	Users           int    `json:"users"`
+	RecapCounts
+}
+
+// AttendanceRecap is a month's recap per user and, for callers beyond the
+// self scope, per institution.
+type AttendanceRecap struct {
+	Month        string              `json:"month"`
+	Users        []*UserRecap        `json:"users"`
+	Institutions []*InstitutionRecap `json:"institutions,omitempty"`
 }
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Calendar day types. National holidays apply everywhere, institution
+// closures to one institution, and collective leave to either.
+const (
+	CalendarNationalHoliday    = "national_holiday"
+	CalendarInstitutionClosure = "institution_closure"
+	CalendarCollectiveLeave    = "collective_leave"
+)
+
+// Day types attendance is labelled with, besides the calendar day types above.
+const (
+	DayTypeWorkingDay = "working_day"
+	DayTypeOffDay     = "off_day"
+)
+
+// CalendarDay is a non-working date. An empty InstitutionID applies to every
+// institution.
+type CalendarDay struct {
+	ID            string    `json:"id" gorm:"column:id"`
+	Date          string    `json:"date" gorm:"column:date" validate:"required"`
+	Name          string    `json:"name" gorm:"column:name" validate:"required"`
+	Type          string    `json:"type" gorm:"column:type" validate:"required"`
+	InstitutionID string    `json:"institution_id" gorm:"column:institution_id"`
+	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at"`
+	CreatedBy     string    `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy     string    `json:"updated_by" gorm:"column:updated_by"`
+}
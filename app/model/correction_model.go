@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Attendance correction states. Only pending corrections can be approved,
+// rejected or cancelled.
+const (
+	CorrectionPending   = "pending"
+	CorrectionApproved  = "approved"
+	CorrectionRejected  = "rejected"
+	CorrectionCancelled = "cancelled"
+)
+
+// AttendanceCorrection proposes new check-in and/or check-out times for one
+// of the user's work dates. A nil time keeps the recorded one.
+type AttendanceCorrection struct {
+	ID            string             `json:"id" gorm:"column:id"`
+	Username      string             `json:"username" gorm:"column:username"`
+	Fullname      string             `json:"fullname" gorm:"column:fullname"`
+	InstitutionID string             `json:"institution_id" gorm:"column:institution_id"`
+	WorkDate      string             `json:"work_date" gorm:"column:work_date"`
+	CheckIn       *time.Time         `json:"check_in" gorm:"column:check_in"`
+	CheckOut      *time.Time         `json:"check_out" gorm:"column:check_out"`
+	Reason        string             `json:"reason" gorm:"column:reason"`
+	Status        string             `json:"status" gorm:"column:status"`
+	DecidedBy     string             `json:"decided_by" gorm:"column:decided_by"`
+	DecidedAt     *time.Time         `json:"decided_at" gorm:"column:decided_at"`
+	DecisionNote  string             `json:"decision_note" gorm:"column:decision_note"`
+	CreatedAt     time.Time          `json:"created_at" gorm:"column:created_at"`
+	Original      *AttendanceHistory `json:"original,omitempty" gorm:"-"`
+}
+
+type RequestCorrectionDecision struct {
+	Note string `json:"note"`
+}
+
+// AttendanceHistory is an attendance row as it was before a correction
+// replaced it. Its times are nil when the work date had no row or no time.
+type AttendanceHistory struct {
+	ID            string     `json:"id" gorm:"column:id"`
+	CorrectionID  string     `json:"correction_id" gorm:"column:correction_id"`
+	Username      string     `json:"username" gorm:"column:username"`
+	WorkDate      string     `json:"work_date" gorm:"column:work_date"`
+	CheckIn       *time.Time `json:"check_in" gorm:"column:check_in"`
+	CheckOut      *time.Time `json:"check_out" gorm:"column:check_out"`
+	StatusIn      string     `json:"status_in" gorm:"column:status_in"`
+	StatusOut     string     `json:"status_out" gorm:"column:status_out"`
+	MinutesLate   int        `json:"minutes_late" gorm:"column:minutes_late"`
+	MinutesEarly  int        `json:"minutes_early" gorm:"column:minutes_early"`
+	WorkedMinutes int        `json:"worked_minutes" gorm:"column:worked_minutes"`
+	ChangedAt     time.Time  `json:"changed_at" gorm:"column:changed_at"`
+	ChangedBy     string     `json:"changed_by" gorm:"column:changed_by"`
+}
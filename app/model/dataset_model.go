@@ -18,6 +18,46 @@ type ModelTraining struct {
 	CreatedBy     string `json:"created_by" gorm:"column:created_by"`
 }
 
+const (
+	TrainingStatusQueued    = "queued"
+	TrainingStatusRunning   = "running"
+	TrainingStatusSucceeded = "succeeded"
+	TrainingStatusFailed    = "failed"
+)
+
+// trainingTransitions lists the statuses a training can move to from each
+// status. Succeeded and failed trainings are final.
+var trainingTransitions = map[string][]string{
+	TrainingStatusQueued:  {TrainingStatusRunning, TrainingStatusFailed},
+	TrainingStatusRunning: {TrainingStatusSucceeded, TrainingStatusFailed},
+}
+
+// CanMoveTo reports whether the training can move to status.
+func (t *ModelTraining) CanMoveTo(status string) bool {
+	for _, v := range trainingTransitions[t.Status] {
+		if v == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TrainingStatusesBefore lists the statuses a training can move to status
+// from.
+func TrainingStatusesBefore(status string) []string {
+	var res []string
+	for from, to := range trainingTransitions {
+		for _, v := range to {
+			if v == status {
+				res = append(res, from)
+			}
+		}
+	}
+
+	return res
+}
+
 type FilterModelTraining struct {
 	InstitutionID string `json:"institution_id" gorm:"column:institution_id" validate:"required"`
 	Status        string `json:"status" gorm:"column:status" validate:"required"`
@@ -26,6 +66,11 @@ type FilterModelTraining struct {
 	SortType      string `json:"sort_type" gorm:"column:sort_type" validate:"required"`
 }
 
+type RequestTrainingStatus struct {
+	ID     string `json:"id" validate:"required"`
+	Status string `json:"status" validate:"required"`
+}
+
 type DatasetURL struct {
 	URL string `json:"url"`
 }
@@ -45,6 +90,23 @@ type RequestAPITrainModel struct {
 	ID         string `json:"id"`
 }
 
+// RequestAPIRecognize asks the processing service whose face the image at
+// BucketName/Key shows, using the model training ID.
+type RequestAPIRecognize struct {
+	ID         string `json:"id"`
+	BucketName string `json:"bucket_name"`
+	Key        string `json:"key"`
+}
+
+type ResponseAPIRecognize struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		Username   string  `json:"username"`
+		Confidence float64 `json:"confidence"`
+	}
+}
+
 type ResponseAPITrainModel struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+const (
+	EventCheckIn            = "attendance.checked_in"
+	EventCheckOut           = "attendance.checked_out"
+	EventUserCreated        = "user.created"
+	EventUserUpdated        = "user.updated"
+	EventUserDeleted        = "user.deleted"
+	EventRoleMappingCreated = "role_mapping.created"
+	EventRoleMappingUpdated = "role_mapping.updated"
+	EventRoleMappingDeleted = "role_mapping.deleted"
+	EventParamUpdated       = "param.updated"
+)
+
+type Event struct {
+	Type          string      `json:"type"`
+	ID            string      `json:"id"`
+	OccurredAt    time.Time   `json:"occurred_at"`
+	Actor         string      `json:"actor"`
+	InstitutionID string      `json:"institution_id"`
+	Payload       interface{} `json:"payload"`
+}
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Export formats.
+const (
+	ExportCSV  = "csv"
+	ExportXLSX = "xlsx"
+)
+
+// What an export contains.
+const (
+	ExportAttendance        = "attendance"
+	ExportAttendanceSummary = "attendance-summary"
+	ExportUser              = "user"
+)
+
+// States of a background export job.
+const (
+	ExportStatusRunning   = "running"
+	ExportStatusSucceeded = "succeeded"
+	ExportStatusFailed    = "failed"
+)
+
+// Sheet is a table to export: a header row and the rows under it.
+type Sheet struct {
+	Name    string
+	Headers []string
+	Rows    [][]interface{}
+}
+
+// ExportJob is an export too large to stream, written to object storage in
+// the background. URL is a presigned download link once it succeeded.
+type ExportJob struct {
+	ID         string     `json:"id" gorm:"column:id"`
+	Username   string     `json:"username" gorm:"column:username"`
+	Kind       string     `json:"kind" gorm:"column:kind"`
+	Format     string     `json:"format" gorm:"column:format"`
+	Status     string     `json:"status" gorm:"column:status"`
+	Rows       int        `json:"rows" gorm:"column:rows"`
+	ObjectKey  string     `json:"-" gorm:"column:object_key"`
+	Error      string     `json:"error,omitempty" gorm:"column:error"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at"`
+	FinishedAt *time.Time `json:"finished_at" gorm:"column:finished_at"`
+	URL        string     `json:"url,omitempty" gorm:"-"`
+}
+
+// ExportResult is either the sheets of a small export, to be streamed in the
+// response, or the job writing a large one.
+type ExportResult struct {
+	FileName string
+	Format   string
+	Sheets   []*Sheet
+	Job      *ExportJob
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Institution struct {
 	ID          string `json:"id" gorm:"type:varchar(200);"`
 	Name        string `json:"name" gorm:"type:varchar(200);"`
@@ -10,4 +12,30 @@ type Institution struct {
 	CreatedBy   string `json:"created_by" gorm:"type:varchar(200);"`
 	UpdatedAt   string `json:"updated_at" gorm:"type:varchar(200);"`
 	UpdatedBy   string `json:"updated_by" gorm:"type:varchar(200);"`
+	// Locations are the offices mobile check-ins must be made at. An
+	// institution without locations has no geofence.
+	Locations []*InstitutionLocation `json:"locations" gorm:"-"`
+}
+
+// InstitutionLocation is an office of an institution: a point and the radius
+// around it, in meters, that counts as being there.
+type InstitutionLocation struct {
+	ID            string  `json:"id" gorm:"column:id"`
+	InstitutionID string  `json:"-" gorm:"column:institution_id"`
+	Name          string  `json:"name" gorm:"column:name"`
+	Latitude      float64 `json:"latitude" gorm:"column:latitude"`
+	Longitude     float64 `json:"longitude" gorm:"column:longitude"`
+	RadiusMeters  int     `json:"radius_meters" gorm:"column:radius_meters"`
+}
+
+// GeofenceExemption lets a user, e.g. field staff, check in from anywhere
+// until ValidUntil (YYYY-MM-DD, inclusive), or indefinitely when nil.
+type GeofenceExemption struct {
+	Username      string    `json:"username" gorm:"column:username"`
+	Fullname      string    `json:"fullname" gorm:"column:fullname"`
+	InstitutionID string    `json:"institution_id" gorm:"column:institution_id"`
+	Reason        string    `json:"reason" gorm:"column:reason"`
+	ValidUntil    *string   `json:"valid_until" gorm:"column:valid_until"`
+	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at"`
+	CreatedBy     string    `json:"created_by" gorm:"column:created_by"`
 }
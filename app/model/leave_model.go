@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// Leave types.
+const (
+	LeaveSick     = "sick"
+	LeaveAnnual   = "annual"
+	LeaveDutyTrip = "duty_trip"
+)
+
+// Leave request states. Only pending requests can be approved, rejected or
+// cancelled.
+const (
+	LeavePending   = "pending"
+	LeaveApproved  = "approved"
+	LeaveRejected  = "rejected"
+	LeaveCancelled = "cancelled"
+)
+
+// leaveStatuses is the attendance status recorded for each day of an
+// approved leave.
+var leaveStatuses = map[string]string{
+	LeaveSick:     StatusSickLeave,
+	LeaveAnnual:   StatusAnnualLeave,
+	LeaveDutyTrip: StatusDutyTrip,
+}
+
+// LeaveQuotaParams maps the leave types limited by a yearly balance to the
+// param holding their quota in days.
+var LeaveQuotaParams = map[string]string{
+	LeaveAnnual: "annual-leave-quota",
+}
+
+func IsValidLeaveType(leaveType string) bool {
+	_, ok := leaveStatuses[leaveType]
+	return ok
+}
+
+// LeaveAttendanceStatus returns the attendance status of a day on leave.
+func LeaveAttendanceStatus(leaveType string) string {
+	return leaveStatuses[leaveType]
+}
+
+type LeaveRequest struct {
+	ID            string     `json:"id" gorm:"column:id"`
+	Username      string     `json:"username" gorm:"column:username"`
+	Fullname      string     `json:"fullname" gorm:"column:fullname"`
+	InstitutionID string     `json:"institution_id" gorm:"column:institution_id"`
+	Type          string     `json:"type" gorm:"column:leave_type" form:"type"`
+	StartDate     string     `json:"start_date" gorm:"column:start_date" form:"start_date"`
+	EndDate       string     `json:"end_date" gorm:"column:end_date" form:"end_date"`
+	Days          int        `json:"days" gorm:"column:days"`
+	Reason        string     `json:"reason" gorm:"column:reason" form:"reason"`
+	Attachment    string     `json:"attachment" gorm:"column:attachment"`
+	Status        string     `json:"status" gorm:"column:status"`
+	DecidedBy     string     `json:"decided_by" gorm:"column:decided_by"`
+	DecidedAt     *time.Time `json:"decided_at" gorm:"column:decided_at"`
+	DecisionNote  string     `json:"decision_note" gorm:"column:decision_note"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at"`
+	File          *File      `json:"-" gorm:"-"`
+	// WorkDates are the working days the leave covers, filled on approval.
+	WorkDates []string `json:"-" gorm:"-"`
+}
+
+type RequestLeaveDecision struct {
+	Note string `json:"note"`
+}
+
+// LeaveBalance is a user's quota of one leave type in a year.
+type LeaveBalance struct {
+	Username  string `json:"username" gorm:"column:username"`
+	Year      int    `json:"year" gorm:"column:year"`
+	Type      string `json:"type" gorm:"column:leave_type"`
+	Quota     int    `json:"quota" gorm:"column:quota"`
+	Used      int    `json:"used" gorm:"column:used"`
+	Remaining int    `json:"remaining" gorm:"column:remaining"`
+}
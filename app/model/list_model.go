@@ -0,0 +1,34 @@
+package model
+
+// Page sizes of list endpoints.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 200
+)
+
+// ListQuery pages, sorts and filters a list endpoint. Pages are selected by
+// Page, or by the Cursor of the previous page, which stays fast deep into
+// large tables. Sort is a field name, prefixed with "-" to sort descending.
+// From and To (YYYY-MM-DD, inclusive) limit the list's date field, and
+// Filters match fields exactly. Each list whitelists the fields it sorts and
+// filters by.
+type ListQuery struct {
+	Page    int               `json:"page"`
+	Size    int               `json:"size"`
+	Cursor  string            `json:"cursor"`
+	Sort    string            `json:"sort"`
+	From    string            `json:"from"`
+	To      string            `json:"to"`
+	Filters map[string]string `json:"filters"`
+	// Scope limits the list to a data scope when set.
+	Scope *DataScope `json:"-"`
+	// Unpaged lists every matching row, for internal callers such as exports.
+	Unpaged bool `json:"-"`
+}
+
+// ListPage describes the page a list endpoint returned: the number of rows
+// matching the query and, when there are more, the cursor of the next page.
+type ListPage struct {
+	Total      int64  `json:"total"`
+	NextCursor string `json:"next_cursor"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// Overtime request states. Only pending requests can be confirmed, rejected
+// or cancelled.
+const (
+	OvertimePending   = "pending"
+	OvertimeConfirmed = "confirmed"
+	OvertimeRejected  = "rejected"
+	OvertimeCancelled = "cancelled"
+)
+
+// Overtime day types: weekend overtime is worked on a non-working day, i.e. a
+// day off of the user's shift or a calendar day.
+const (
+	OvertimeWeekday = "weekday"
+	OvertimeWeekend = "weekend"
+)
+
+// OvertimeRequest claims overtime worked on a work date. Minutes can't exceed
+// the overtime recorded on the date's attendance.
+type OvertimeRequest struct {
+	ID            string     `json:"id" gorm:"column:id"`
+	Username      string     `json:"username" gorm:"column:username"`
+	Fullname      string     `json:"fullname" gorm:"column:fullname"`
+	InstitutionID string     `json:"institution_id" gorm:"column:institution_id"`
+	WorkDate      string     `json:"work_date" gorm:"column:work_date"`
+	DayType       string     `json:"day_type" gorm:"column:day_type"`
+	Minutes       int        `json:"minutes" gorm:"column:minutes"`
+	Reason        string     `json:"reason" gorm:"column:reason"`
+	Status        string     `json:"status" gorm:"column:status"`
+	DecidedBy     string     `json:"decided_by" gorm:"column:decided_by"`
+	DecidedAt     *time.Time `json:"decided_at" gorm:"column:decided_at"`
+	DecisionNote  string     `json:"decision_note" gorm:"column:decision_note"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at"`
+}
+
+type RequestOvertimeDecision struct {
+	Note string `json:"note"`
+}
+
+// OvertimeTotal is a user's confirmed overtime in a month.
+type OvertimeTotal struct {
+	Username       string  `json:"username" gorm:"column:username"`
+	Fullname       string  `json:"fullname" gorm:"column:fullname"`
+	InstitutionID  string  `json:"institution_id" gorm:"column:institution_id"`
+	WeekdayMinutes int     `json:"weekday_minutes" gorm:"column:weekday_minutes"`
+	WeekendMinutes int     `json:"weekend_minutes" gorm:"column:weekend_minutes"`
+	TotalMinutes   int     `json:"total_minutes" gorm:"column:total_minutes"`
+	WeekdayHours   float64 `json:"weekday_hours" gorm:"-"`
+	WeekendHours   float64 `json:"weekend_hours" gorm:"-"`
+	TotalHours     float64 `json:"total_hours" gorm:"-"`
+}
@@ -4,6 +4,10 @@ type Response struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
+	// Total and NextCursor are set by list endpoints: the number of rows
+	// matching the query and the cursor of the next page, if any.
+	Total      *int64 `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type ResponseAPI struct {
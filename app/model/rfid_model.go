@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+const (
+	RFIDCardActive  = "active"
+	RFIDCardRevoked = "revoked"
+	RFIDCardLost    = "lost"
+)
+
+type RFIDCard struct {
+	CardUID    string     `json:"card_uid" gorm:"column:card_uid" validate:"required"`
+	Username   string     `json:"username" gorm:"column:username" validate:"required"`
+	Fullname   string     `json:"fullname" gorm:"column:fullname"`
+	Status     string     `json:"status" gorm:"column:status"`
+	Remark     string     `json:"remark" gorm:"column:remark"`
+	AssignedAt time.Time  `json:"assigned_at" gorm:"column:assigned_at"`
+	AssignedBy string     `json:"assigned_by" gorm:"column:assigned_by"`
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"column:revoked_at"`
+	RevokedBy  string     `json:"revoked_by" gorm:"column:revoked_by"`
+	// InstitutionID is the holder's institution, set by GetActiveCard.
+	InstitutionID string `json:"institution_id,omitempty" gorm:"column:institution_id"`
+}
+
+type RFIDDevice struct {
+	ID            string    `json:"id" gorm:"column:id"`
+	Name          string    `json:"name" gorm:"column:name" validate:"required"`
+	InstitutionID string    `json:"institution_id" gorm:"column:institution_id" validate:"required"`
+	Secret        string    `json:"-" gorm:"column:secret"`
+	IsActive      bool      `json:"is_active" gorm:"column:is_active"`
+	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at"`
+	CreatedBy     string    `json:"created_by" gorm:"column:created_by"`
+}
+
+type ResponseRegisterDevice struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+}
+
+type RequestRFIDCardStatus struct {
+	CardUID string `json:"card_uid" validate:"required"`
+	Remark  string `json:"remark"`
+}
+
+// RequestRFIDTap is a tap sent by a registered device. The device
+// authenticates with either its API key or an HMAC-SHA256 signature of
+// "<timestamp>.<body>" keyed by the same secret.
+type RequestRFIDTap struct {
+	CardUID   string `json:"card_uid" validate:"required"`
+	DeviceID  string `json:"-"`
+	DeviceKey string `json:"-"`
+	Signature string `json:"-"`
+	Timestamp string `json:"-"`
+	Body      []byte `json:"-"`
+}
+
+type RFIDTapBatch struct {
+	DeviceID string     `json:"device_id"`
+	Taps     []*RFIDTap `json:"taps"`
+}
+
+type RFIDTap struct {
+	CardUID  string    `json:"card_uid" validate:"required"`
+	TappedAt time.Time `json:"tapped_at" validate:"required"`
+}
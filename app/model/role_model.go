@@ -36,4 +36,132 @@ type Role struct {
 	UpdatedBy string    `gorm:"column:updated_by" json:"updated_by"`
 	IsActive  bool      `gorm:"column:is_active" json:"is_active"`
 	Level     int       `gorm:"column:level" json:"level"`
+	Scope     string    `gorm:"column:scope" json:"scope"`
+}
+
+// Data scopes a role can be granted, from narrowest to widest.
+const (
+	ScopeSelf        = "self"
+	ScopeInstitution = "institution"
+	ScopeAll         = "all"
+)
+
+// levelScopes is the scope of roles created before scopes were stored.
+var levelScopes = map[int]string{
+	1: ScopeAll,
+	2: ScopeInstitution,
+	3: ScopeSelf,
+}
+
+func IsValidScope(scope string) bool {
+	return scope == ScopeSelf || scope == ScopeInstitution || scope == ScopeAll
+}
+
+// DataScope returns the role's scope, derived from its level when unset.
+// Unknown values fall back to the narrowest scope.
+func (r *Role) DataScope() string {
+	scope := r.Scope
+	if scope == "" {
+		scope = levelScopes[r.Level]
+	}
+
+	if !IsValidScope(scope) {
+		return ScopeSelf
+	}
+
+	return scope
+}
+
+// DataScope is the set of records a session may read: its own, its
+// institution's or all of them.
+type DataScope struct {
+	Scope         string `json:"scope"`
+	Username      string `json:"username"`
+	InstitutionID string `json:"institution_id"`
+}
+
+// Allows reports whether a record owned by username in institutionID is
+// within the scope.
+func (s *DataScope) Allows(username string, institutionID string) bool {
+	switch s.Scope {
+	case ScopeAll:
+		return true
+	case ScopeInstitution:
+		return institutionID == s.InstitutionID
+	default:
+		return username == s.Username
+	}
+}
+
+// scopeWidths orders the scopes from narrowest to widest.
+var scopeWidths = map[string]int{
+	ScopeSelf:        0,
+	ScopeInstitution: 1,
+	ScopeAll:         2,
+}
+
+// Covers reports whether the scope is at least as wide as scope, e.g. so a
+// session only grants roles that see no more than it does.
+func (s *DataScope) Covers(scope string) bool {
+	return scopeWidths[s.Scope] >= scopeWidths[scope]
+}
+
+// AllowsInstitution reports whether records of institutionID as a whole, such
+// as its shifts or settings, are within the scope. A self scope only covers
+// the session's own records, so it allows no institution.
+func (s *DataScope) AllowsInstitution(institutionID string) bool {
+	switch s.Scope {
+	case ScopeAll:
+		return true
+	case ScopeInstitution:
+		return institutionID == s.InstitutionID
+	default:
+		return false
+	}
+}
+
+// CanApprove reports whether the scope's user may approve a request made by
+// username in institutionID: approvers need at least institution scope over
+// the requester and can't approve their own requests.
+func (s *DataScope) CanApprove(username string, institutionID string) bool {
+	return s.Scope != ScopeSelf && s.AllowsInstitution(institutionID) && username != s.Username
+}
+
+// InstitutionFilter returns the institution lists are limited to, or an empty
+// string when the scope spans every institution.
+func (s *DataScope) InstitutionFilter() string {
+	if s.Scope == ScopeAll {
+		return ""
+	}
+
+	return s.InstitutionID
+}
+
+// Menu IDs that routes are registered under. migrations/002_menu_ids.sql
+// gives the seeded menus these ids so role mappings apply to the right
+// endpoints.
+const (
+	MenuUser        = "user"
+	MenuRole        = "role"
+	MenuParam       = "param"
+	MenuAttendance  = "attendance"
+	MenuInstitution = "institution"
+	MenuDataset     = "dataset"
+	MenuModel       = "model"
+	MenuRFID        = "rfid"
+	MenuSchedule    = "schedule"
+	MenuCalendar    = "calendar"
+	MenuLeave       = "leave"
+	MenuOvertime    = "overtime"
+)
+
+// RoutePermission is the menu and access method a role needs to call a route.
+// An empty MenuID only requires a valid session. Only routes with
+// PasswordChange can be called by users who must change their password.
+type RoutePermission struct {
+	Method         string `json:"method"`
+	Path           string `json:"path"`
+	MenuID         string `json:"menu_id"`
+	Action         string `json:"action"`
+	PasswordChange bool   `json:"password_change"`
 }
@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// Schedule assignment targets, in order of precedence.
+const (
+	ScheduleTargetUser        = "user"
+	ScheduleTargetRole        = "role"
+	ScheduleTargetInstitution = "institution"
+)
+
+type Shift struct {
+	ID            string `json:"id" gorm:"column:id"`
+	Name          string `json:"name" gorm:"column:name" validate:"required"`
+	Description   string `json:"description" gorm:"column:description"`
+	InstitutionID string `json:"institution_id" gorm:"column:institution_id"`
+	// GraceMinutes is how late a check-in (or how early a check-out) may be
+	// and still count as on time. CheckInWindowMinutes is how long before the
+	// start check-in opens; 0 leaves it open. Either falls back to the
+	// grace-period and checkin-window params when 0.
+	GraceMinutes         int         `json:"grace_minutes" gorm:"column:grace_minutes"`
+	CheckInWindowMinutes int         `json:"checkin_window_minutes" gorm:"column:checkin_window_minutes"`
+	CreatedAt            time.Time   `json:"created_at" gorm:"column:created_at"`
+	CreatedBy            string      `json:"created_by" gorm:"column:created_by"`
+	UpdatedAt            time.Time   `json:"updated_at" gorm:"column:updated_at"`
+	UpdatedBy            string      `json:"updated_by" gorm:"column:updated_by"`
+	Days                 []*ShiftDay `json:"days" gorm:"-"`
+}
+
+// ShiftDay is the working time of a shift on one weekday (0 = Sunday). A
+// weekday without a ShiftDay is a day off. EndTime at or before StartTime
+// means the shift ends on the next day.
+type ShiftDay struct {
+	ShiftID   string `json:"-" gorm:"column:shift_id"`
+	Weekday   int    `json:"weekday" gorm:"column:weekday"`
+	StartTime string `json:"start_time" gorm:"column:start_time"`
+	EndTime   string `json:"end_time" gorm:"column:end_time"`
+}
+
+type ScheduleAssignment struct {
+	ID            string    `json:"id" gorm:"column:id"`
+	ShiftID       string    `json:"shift_id" gorm:"column:shift_id" validate:"required"`
+	ShiftName     string    `json:"shift_name" gorm:"column:shift_name"`
+	TargetType    string    `json:"target_type" gorm:"column:target_type" validate:"required"`
+	TargetID      string    `json:"target_id" gorm:"column:target_id" validate:"required"`
+	EffectiveFrom string    `json:"effective_from" gorm:"column:effective_from" validate:"required"`
+	EffectiveTo   *string   `json:"effective_to" gorm:"column:effective_to"`
+	CreatedAt     time.Time `json:"created_at" gorm:"column:created_at"`
+	CreatedBy     string    `json:"created_by" gorm:"column:created_by"`
+}
+
+// ShiftInstance is a shift as worked on a given work date. Start and End are
+// zero on a day off or holiday, and EarliestCheckIn is zero when check-in is
+// always open.
+type ShiftInstance struct {
+	ShiftID         string    `json:"shift_id"`
+	ShiftName       string    `json:"shift_name"`
+	WorkDate        string    `json:"work_date"`
+	DayType         string    `json:"day_type"`
+	HolidayName     string    `json:"holiday_name,omitempty"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	GraceMinutes    int       `json:"grace_minutes"`
+	EarliestCheckIn time.Time `json:"earliest_check_in"`
+}
+
+func (s *ShiftInstance) IsWorkingDay() bool {
+	return !s.Start.IsZero()
+}
@@ -0,0 +1,38 @@
+package model
+
+// Outcomes of an imported row.
+const (
+	ImportRowValid   = "valid"
+	ImportRowCreated = "created"
+	ImportRowFailed  = "failed"
+)
+
+// RequestUserImport is a spreadsheet of users to create. Its first row names
+// the columns by the JSON fields of User; a row without a password gets a
+// generated one. A dry run only validates the rows.
+type RequestUserImport struct {
+	File   *File
+	DryRun bool
+}
+
+// UserImportRow is the outcome of one spreadsheet row, numbered as in the
+// spreadsheet.
+type UserImportRow struct {
+	Row      int      `json:"row"`
+	Username string   `json:"username"`
+	Status   string   `json:"status"`
+	Errors   []string `json:"errors,omitempty"`
+	// GeneratedPassword is the one-time password of a created user whose row
+	// had none. It is only ever shown in this report.
+	GeneratedPassword string `json:"generated_password,omitempty"`
+}
+
+// UserImportReport is the outcome of an import, row by row.
+type UserImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Total   int              `json:"total"`
+	Valid   int              `json:"valid"`
+	Created int              `json:"created"`
+	Failed  int              `json:"failed"`
+	Rows    []*UserImportRow `json:"rows"`
+}
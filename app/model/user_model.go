@@ -1,15 +1,56 @@
 package model
 
 import (
+	"errors"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Token types carried in the typ claim, so a refresh token can't be used as an
+// access token or the other way round.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 type JwtCustomClaims struct {
 	Name        string            `json:"name"`
 	Role        string            `json:"role"`
 	MenuMapping map[string]string `json:"menu_mapping"`
 	jwt.RegisteredClaims
 	InstitutionID string `json:"institution_id"`
+	Family        string `json:"fid"`
+	// MustChangePassword limits the token to changing the password.
+	MustChangePassword bool   `json:"mcp,omitempty"`
+	Type               string `json:"typ"`
+}
+
+// Validate is called by the JWT parser once the token's signature and expiry
+// are verified.
+func (c *JwtCustomClaims) Validate() error {
+	if c.Type != TokenTypeAccess {
+		return errors.New("not an access token")
+	}
+
+	return nil
+}
+
+type JwtRefreshClaims struct {
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Family string `json:"fid"`
+	Type   string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// Validate is called by the JWT parser once the token's signature and expiry
+// are verified.
+func (c *JwtRefreshClaims) Validate() error {
+	if c.Type != TokenTypeRefresh {
+		return errors.New("not a refresh token")
+	}
+
+	return nil
 }
 
 type MetadataUser struct {
@@ -19,23 +60,71 @@ type MetadataUser struct {
 }
 
 type User struct {
-	Username        string `json:"username" gorm:"column:username" validate:"required"`
-	Email           string `json:"email" gorm:"column:email" validate:"required"`
-	Password        string `json:"password" gorm:"column:password" validate:"required"`
-	Fullname        string `json:"fullname" gorm:"column:fullname" validate:"required"`
-	Shortname       string `json:"shortname" gorm:"column:shortname" validate:"required"`
-	RoleID          string `json:"role_id" gorm:"column:role_id" validate:"required"`
-	RoleName        string `json:"role_name" gorm:"column:role_name"`
-	InstitutionID   string `json:"institution_id" gorm:"column:institution_id" validate:"required"`
-	InstitutionName string `json:"institution_name" gorm:"column:institution_name"`
-	PhoneNumber     string `json:"phone_number" gorm:"column:phone_number"`
-	Address         string `json:"address" gorm:"column:address"`
-	Gender          string `json:"gender" gorm:"column:gender"`
-	Religion        string `json:"religion" gorm:"column:religion"`
-	CreatedAt       string `json:"created_at" gorm:"column:created_at"`
-	RoleLevel       int    `json:"role_level" gorm:"-"`
-	ProfilePhoto    string `json:"profile_photo" gorm:"column:profile_photo"`
-	CoverPhoto      string `json:"cover_photo" gorm:"column:cover_photo"`
+	Username        string      `json:"username" gorm:"column:username" validate:"required"`
+	Email           string      `json:"email" gorm:"column:email" validate:"required"`
+	Password        string      `json:"password" gorm:"column:password" validate:"required"`
+	Fullname        string      `json:"fullname" gorm:"column:fullname" validate:"required"`
+	Shortname       string      `json:"shortname" gorm:"column:shortname" validate:"required"`
+	RoleID          string      `json:"role_id" gorm:"column:role_id" validate:"required"`
+	RoleName        string      `json:"role_name" gorm:"column:role_name"`
+	InstitutionID   string      `json:"institution_id" gorm:"column:institution_id" validate:"required"`
+	InstitutionName string      `json:"institution_name" gorm:"column:institution_name"`
+	PhoneNumber     string      `json:"phone_number" gorm:"column:phone_number"`
+	Address         string      `json:"address" gorm:"column:address"`
+	Gender          string      `json:"gender" gorm:"column:gender"`
+	Religion        string      `json:"religion" gorm:"column:religion"`
+	CreatedAt       string      `json:"created_at" gorm:"column:created_at"`
+	RoleLevel       int         `json:"role_level" gorm:"-"`
+	ProfilePhoto    string      `json:"profile_photo" gorm:"column:profile_photo"`
+	CoverPhoto      string      `json:"cover_photo" gorm:"column:cover_photo"`
+	Roles           []*UserRole `json:"roles" gorm:"-"`
+	RoleIDs         []string    `json:"role_ids,omitempty" gorm:"-"`
+	// Status is the review state of a self-registered user. Only approved
+	// users can log in; users created before registrations were reviewed
+	// have none and count as approved.
+	Status       string `json:"status" gorm:"column:status"`
+	RejectReason string `json:"reject_reason,omitempty" gorm:"column:reject_reason"`
+	// MustChangePassword is set for users given a generated password, who
+	// can do nothing but change it until they do.
+	MustChangePassword bool `json:"must_change_password" gorm:"column:must_change_password"`
+}
+
+// Review states of a self-registered user.
+const (
+	UserPending  = "pending"
+	UserApproved = "approved"
+	UserRejected = "rejected"
+)
+
+// IsApproved reports whether the user may log in.
+func (u *User) IsApproved() bool {
+	return u.Status == "" || u.Status == UserApproved
+}
+
+// RequestRegistrationDecision approves a registration with RoleID, or
+// rejects it for Reason.
+type RequestRegistrationDecision struct {
+	RoleID string `json:"role_id"`
+	Reason string `json:"reason"`
+}
+
+// UserRole is a role held by a user, either its default role (users.role_id)
+// or an additional one from user_roles.
+type UserRole struct {
+	Username string `json:"-" gorm:"column:username"`
+	RoleID   string `json:"role_id" gorm:"column:role_id"`
+	RoleName string `json:"role_name" gorm:"column:role_name"`
+	Level    int    `json:"level" gorm:"column:level"`
+}
+
+type RequestSwitchRole struct {
+	RoleID string `json:"role_id" validate:"required"`
+}
+
+// RequestChangePassword replaces the caller's password.
+type RequestChangePassword struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
 }
 
 type RequestLogin struct {
@@ -50,9 +139,24 @@ type ResponseLogin struct {
 	Role            string             `json:"role" gorm:"type:varchar(200);"`
 	RoleName        string             `json:"role_name" gorm:"type:varchar(200);"`
 	Token           string             `json:"token" gorm:"type:varchar(200);"`
+	RefreshToken    string             `json:"refresh_token" gorm:"-"`
+	ExpiresAt       int64              `json:"expires_at" gorm:"-"`
 	InstitutionID   string             `json:"institution_id" gorm:"type:varchar(200);"`
 	InstitutionName string             `json:"institution_name" gorm:"type:varchar(200);"`
 	MenuMapping     []*MenuRoleMapping `json:"menu_mapping" gorm:"-"`
+	Roles           []*UserRole        `json:"roles" gorm:"-"`
+	// MustChangePassword tells the app to ask for a new password first.
+	MustChangePassword bool `json:"must_change_password" gorm:"-"`
+}
+
+type RequestRefreshToken struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ResponseToken struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresAt    int64  `json:"expires_at"`
 }
 
 type UploadPhoto struct {
@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"bpkp-svc-portal/app/client"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"context"
+	"errors"
+	"net/http"
+)
+
+// InterfaceScopePolicy resolves which records the caller may read. List and
+// detail queries take their filters from it instead of checking role levels.
+type InterfaceScopePolicy interface {
+	Resolve(ctx context.Context) (*model.DataScope, error)
+}
+
+type ScopePolicy struct {
+	roleClient client.InterfaceRoleClient
+}
+
+func NewScopePolicy(roleClient client.InterfaceRoleClient) *ScopePolicy {
+	return &ScopePolicy{roleClient: roleClient}
+}
+
+// Resolve returns the data scope of the session's active role.
+func (p *ScopePolicy) Resolve(ctx context.Context) (*model.DataScope, error) {
+	span, ctx := utils.SpanFromContext(ctx, "Policy: Resolve")
+	defer span.Finish()
+
+	session, err := utils.GetMetadata(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	role, err := p.roleClient.GetRoleByID(ctx, session.RoleID)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return nil, err
+	}
+
+	if role == nil {
+		utils.LogEventError(span, errors.New("role not found"))
+		return nil, model.ThrowError(http.StatusForbidden, errors.New("role not found"))
+	}
+
+	scope := &model.DataScope{
+		Scope:         role.DataScope(),
+		Username:      session.Username,
+		InstitutionID: session.InstitutionID,
+	}
+
+	utils.LogEvent(span, "Response", scope)
+
+	return scope, nil
+}
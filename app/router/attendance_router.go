@@ -1,13 +1,31 @@
 package router
 
-import "github.com/labstack/echo/v4"
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
 
 func InitAttendanceRoute(prefix string, e *echo.Group) {
 	route := e.Group(prefix)
 	service := factory.Service.attendance
 
-	route.GET("", service.GetTodayAttendances)
-	route.POST("", service.GetUserAttendances)
-	route.POST("/checkin", service.CheckIn)
-	route.POST("/checkout", service.CheckOut)
+	permit(route.GET("", service.GetTodayAttendances), model.MenuAttendance, http.MethodGet)
+	permit(route.POST("", service.GetUserAttendances), model.MenuAttendance, http.MethodGet)
+	permit(route.GET("/recap", service.GetAttendanceRecap), model.MenuAttendance, http.MethodGet)
+	permit(route.POST("/checkin", service.CheckIn), model.MenuAttendance, http.MethodPost)
+	permit(route.POST("/checkin/selfie", service.CheckInSelfie), model.MenuAttendance, http.MethodPost)
+	permit(route.POST("/checkout", service.CheckOut), model.MenuAttendance, http.MethodPost)
+
+	correction := factory.Service.correction
+
+	authenticated(route.POST("/correction", correction.SubmitCorrection))
+	authenticated(route.GET("/correction/me", correction.GetMyCorrections))
+	authenticated(route.DELETE("/correction/:id", correction.CancelCorrection))
+
+	permit(route.GET("/correction", correction.GetAllCorrections), model.MenuAttendance, http.MethodGet)
+	permit(route.GET("/correction/:id", correction.GetCorrectionByID), model.MenuAttendance, http.MethodGet)
+	permit(route.POST("/correction/:id/approve", correction.ApproveCorrection), model.MenuAttendance, http.MethodPut)
+	permit(route.POST("/correction/:id/reject", correction.RejectCorrection), model.MenuAttendance, http.MethodPut)
 }
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func InitCalendarRoute(prefix string, e *echo.Group) {
+	route := e.Group(prefix)
+	service := factory.Service.calendar
+
+	permit(route.GET("", service.GetAllCalendarDays), model.MenuCalendar, http.MethodGet)
+	permit(route.GET("/:id", service.GetCalendarDayByID), model.MenuCalendar, http.MethodGet)
+	permit(route.POST("", service.CreateNewCalendarDay), model.MenuCalendar, http.MethodPost)
+	permit(route.PUT("", service.UpdateCalendarDay), model.MenuCalendar, http.MethodPut)
+	permit(route.DELETE("/:id", service.DeleteCalendarDay), model.MenuCalendar, http.MethodDelete)
+}
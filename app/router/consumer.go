@@ -0,0 +1,17 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/config"
+	"bpkp-svc-portal/app/consumer"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+func InitConsumer(cfg *config.Config, mq *amqp.Channel, redis *redis.Client) {
+	rfid := consumer.NewRFIDConsumer(cfg, mq, redis, factory.Controller.attendance)
+	if err := rfid.Start(); err != nil {
+		logrus.Panicf("Cannot Start RFID Consumer: %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func InitDatasetRoute(prefix string, e *echo.Group) {
+	route := e.Group(prefix)
+	service := factory.Service.dataset
+
+	permit(route.GET("", service.GetAllDatasets), model.MenuDataset, http.MethodGet)
+	permit(route.GET("/:id", service.GetDatasetByUsername), model.MenuDataset, http.MethodGet)
+	permit(route.POST("", service.UploadDataset), model.MenuDataset, http.MethodPost)
+	permit(route.DELETE("/:id", service.DeleteDataset), model.MenuDataset, http.MethodDelete)
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func InitExportRoute(prefix string, e *echo.Group) {
+	route := e.Group(prefix)
+	service := factory.Service.export
+
+	permit(route.POST("/attendance", service.ExportAttendances), model.MenuAttendance, http.MethodGet)
+	permit(route.POST("/attendance/summary", service.ExportAttendanceSummary), model.MenuAttendance, http.MethodGet)
+	permit(route.GET("/user", service.ExportUsers), model.MenuUser, http.MethodGet)
+
+	authenticated(route.GET("/:id", service.GetExportJob))
+}
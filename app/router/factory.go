@@ -4,7 +4,9 @@ import (
 	"bpkp-svc-portal/app/client"
 	"bpkp-svc-portal/app/config"
 	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/policy"
 	"bpkp-svc-portal/app/service"
+	"bpkp-svc-portal/app/utils"
 
 	"github.com/aws/aws-sdk-go/service/s3"
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -14,19 +16,43 @@ import (
 )
 
 type ServiceFactory struct {
-	user        service.InterfaceUserService
-	role        service.InterfaceRoleService
-	param       service.InterfaceParamService
-	attendance  service.InterfaceAttendanceService
-	institution service.InterfaceInstitutionService
+	user         service.InterfaceUserService
+	role         service.InterfaceRoleService
+	param        service.InterfaceParamService
+	attendance   service.InterfaceAttendanceService
+	institution  service.InterfaceInstitutionService
+	dataset      service.InterfaceDatasetService
+	training     service.InterfaceTrainingService
+	rfid         service.InterfaceRFIDService
+	schedule     service.InterfaceScheduleService
+	calendar     service.InterfaceCalendarService
+	leave        service.InterfaceLeaveService
+	correction   service.InterfaceCorrectionService
+	geofence     service.InterfaceGeofenceService
+	export       service.InterfaceExportService
+	overtime     service.InterfaceOvertimeService
+	userImport   service.InterfaceUserImportService
+	registration service.InterfaceRegistrationService
 }
 
 type ControllerFactory struct {
-	user        controller.InterfaceUserController
-	role        controller.InterfaceRoleController
-	param       controller.InterfaceParamController
-	attendance  controller.InterfaceAttendanceController
-	institution controller.InterfaceInstitutionController
+	user         controller.InterfaceUserController
+	role         controller.InterfaceRoleController
+	param        controller.InterfaceParamController
+	attendance   controller.InterfaceAttendanceController
+	institution  controller.InterfaceInstitutionController
+	dataset      controller.InterfaceDatasetController
+	training     controller.InterfaceTrainingController
+	rfid         controller.InterfaceRFIDController
+	schedule     controller.InterfaceScheduleController
+	calendar     controller.InterfaceCalendarController
+	leave        controller.InterfaceLeaveController
+	correction   controller.InterfaceCorrectionController
+	geofence     controller.InterfaceGeofenceController
+	export       controller.InterfaceExportController
+	overtime     controller.InterfaceOvertimeController
+	userImport   controller.InterfaceUserImportController
+	registration controller.InterfaceRegistrationController
 }
 
 type ClientFactory struct {
@@ -36,6 +62,17 @@ type ClientFactory struct {
 	param       client.InterfaceParamClient
 	attendance  client.InterfaceAttendanceClient
 	institution client.InterfaceInstitutionClient
+	training    client.InterfaceTrainingClient
+	event       client.InterfaceEventClient
+	rfid        client.InterfaceRFIDClient
+	token       client.InterfaceTokenClient
+	schedule    client.InterfaceScheduleClient
+	calendar    client.InterfaceCalendarClient
+	leave       client.InterfaceLeaveClient
+	correction  client.InterfaceCorrectionClient
+	geofence    client.InterfaceGeofenceClient
+	export      client.InterfaceExportClient
+	overtime    client.InterfaceOvertimeClient
 }
 
 type Factory struct {
@@ -50,24 +87,61 @@ func InitFactory(cfg *config.Config, db *gorm.DB, s3 *s3.S3, redis *redis.Client
 	client := ClientFactory{
 		user:        client.NewUserClient(db, cfg),
 		storage:     client.NewStorageClient(s3, db),
-		role:        client.NewRoleClient(db),
+		role:        client.NewRoleClient(db, redis),
 		param:       client.NewParamClient(db, redis),
 		attendance:  client.NewAttendanceClient(db),
 		institution: client.NewInstitutionClient(db),
+		training:    client.NewTrainingClient(db, cfg),
+		event:       client.NewEventClient(mq, cfg),
+		rfid:        client.NewRFIDClient(db),
+		token:       client.NewTokenClient(redis),
+		schedule:    client.NewScheduleClient(db),
+		calendar:    client.NewCalendarClient(db),
+		leave:       client.NewLeaveClient(db),
+		correction:  client.NewCorrectionClient(db),
+		geofence:    client.NewGeofenceClient(db),
+		export:      client.NewExportClient(db),
+		overtime:    client.NewOvertimeClient(db),
 	}
+	scopePolicy := policy.NewScopePolicy(client.role)
+
 	controller := ControllerFactory{
-		user:        controller.NewUserController(client.user, client.role, client.param, client.storage),
-		role:        controller.NewRoleController(client.role),
-		param:       controller.NewParamController(redis, client.param),
-		attendance:  controller.NewAttendanceController(client.attendance, client.param),
-		institution: controller.NewInstitutionController(client.institution),
+		user:         controller.NewUserController(client.user, client.role, client.param, client.storage, client.event, client.token, client.institution, scopePolicy),
+		role:         controller.NewRoleController(client.role, client.event),
+		param:        controller.NewParamController(redis, client.param, client.event),
+		attendance:   controller.NewAttendanceController(cfg, client.attendance, client.param, client.event, client.rfid, client.token, client.schedule, client.calendar, client.geofence, client.user, client.training, client.storage, scopePolicy),
+		institution:  controller.NewInstitutionController(client.institution, scopePolicy),
+		dataset:      controller.NewDatasetController(cfg, client.storage, client.user, scopePolicy),
+		training:     controller.NewTrainingController(cfg, client.training, scopePolicy),
+		rfid:         controller.NewRFIDController(client.rfid, client.user, scopePolicy),
+		schedule:     controller.NewScheduleController(client.schedule, client.user, client.param, client.calendar, scopePolicy),
+		calendar:     controller.NewCalendarController(client.calendar, scopePolicy),
+		leave:        controller.NewLeaveController(client.leave, client.user, client.storage, client.param, client.schedule, client.calendar, scopePolicy),
+		correction:   controller.NewCorrectionController(client.correction, client.attendance, client.param, client.schedule, client.calendar, scopePolicy),
+		geofence:     controller.NewGeofenceController(client.geofence, client.user, scopePolicy),
+		export:       controller.NewExportController(client.attendance, client.user, client.storage, client.param, client.export, scopePolicy),
+		overtime:     controller.NewOvertimeController(client.overtime, client.attendance, scopePolicy),
+		userImport:   controller.NewUserImportController(client.user, client.role, client.institution, client.event, scopePolicy),
+		registration: controller.NewRegistrationController(client.user, client.role, client.event, scopePolicy),
 	}
 	service := ServiceFactory{
-		user:        service.NewUserService(controller.user),
-		role:        service.NewRoleService(controller.role),
-		param:       service.NewParamService(controller.param),
-		attendance:  service.NewAttendanceService(controller.attendance),
-		institution: service.NewInstitutionService(controller.institution),
+		user:         service.NewUserService(controller.user),
+		role:         service.NewRoleService(controller.role),
+		param:        service.NewParamService(controller.param),
+		attendance:   service.NewAttendanceService(controller.attendance),
+		institution:  service.NewInstitutionService(controller.institution),
+		dataset:      service.NewDatasetService(controller.dataset),
+		training:     service.NewTrainingService(controller.training),
+		rfid:         service.NewRFIDService(controller.rfid),
+		schedule:     service.NewScheduleService(controller.schedule),
+		calendar:     service.NewCalendarService(controller.calendar),
+		leave:        service.NewLeaveService(controller.leave),
+		correction:   service.NewCorrectionService(controller.correction),
+		geofence:     service.NewGeofenceService(controller.geofence),
+		export:       service.NewExportService(controller.export),
+		overtime:     service.NewOvertimeService(controller.overtime),
+		userImport:   service.NewUserImportService(controller.userImport),
+		registration: service.NewRegistrationService(controller.registration),
 	}
 	factory = &Factory{
 		Service:    service,
@@ -75,3 +149,8 @@ func InitFactory(cfg *config.Config, db *gorm.DB, s3 *s3.S3, redis *redis.Client
 		Client:     client,
 	}
 }
+
+// MenuMappingResolver exposes the role client to the authorization middleware.
+func MenuMappingResolver() utils.MenuMappingResolver {
+	return factory.Client.role
+}
@@ -1,14 +1,25 @@
 package router
 
-import "github.com/labstack/echo/v4"
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
 
 func InitInstitutionRoute(prefix string, e *echo.Group) {
 	route := e.Group(prefix)
 	service := factory.Service.institution
 
-	route.GET("", service.GetAllInstitution)
-	route.GET("/:id", service.GetInstitutionByID)
-	route.POST("", service.CreateNewInstitution)
-	route.PUT("", service.UpdateInstitution)
-	route.DELETE("/:id", service.DeleteInstitution)
+	permit(route.GET("", service.GetAllInstitution), model.MenuInstitution, http.MethodGet)
+	permit(route.GET("/:id", service.GetInstitutionByID), model.MenuInstitution, http.MethodGet)
+	permit(route.POST("", service.CreateNewInstitution), model.MenuInstitution, http.MethodPost)
+	permit(route.PUT("", service.UpdateInstitution), model.MenuInstitution, http.MethodPut)
+	permit(route.DELETE("/:id", service.DeleteInstitution), model.MenuInstitution, http.MethodDelete)
+
+	geofence := factory.Service.geofence
+
+	permit(route.GET("/geofence-exemption", geofence.GetAllExemptions), model.MenuInstitution, http.MethodGet)
+	permit(route.POST("/geofence-exemption", geofence.SaveExemption), model.MenuInstitution, http.MethodPut)
+	permit(route.DELETE("/geofence-exemption/:username", geofence.DeleteExemption), model.MenuInstitution, http.MethodPut)
 }
@@ -0,0 +1,11 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/job"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func InitJob(redis *redis.Client) {
+	job.NewAbsenceJob(redis, factory.Controller.attendance).Start()
+}
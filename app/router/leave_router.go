@@ -0,0 +1,24 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func InitLeaveRoute(prefix string, e *echo.Group) {
+	route := e.Group(prefix)
+	service := factory.Service.leave
+
+	authenticated(route.GET("/me", service.GetMyLeaves))
+	authenticated(route.POST("", service.SubmitLeave))
+	authenticated(route.DELETE("/:id", service.CancelLeave))
+	authenticated(route.GET("/balance", service.GetMyLeaveBalances))
+
+	permit(route.GET("", service.GetAllLeaves), model.MenuLeave, http.MethodGet)
+	permit(route.GET("/:id", service.GetLeaveByID), model.MenuLeave, http.MethodGet)
+	permit(route.POST("/:id/approve", service.ApproveLeave), model.MenuLeave, http.MethodPut)
+	permit(route.POST("/:id/reject", service.RejectLeave), model.MenuLeave, http.MethodPut)
+	permit(route.GET("/balance/:id", service.GetLeaveBalances), model.MenuLeave, http.MethodGet)
+}
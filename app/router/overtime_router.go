@@ -0,0 +1,23 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func InitOvertimeRoute(prefix string, e *echo.Group) {
+	route := e.Group(prefix)
+	service := factory.Service.overtime
+
+	authenticated(route.GET("/me", service.GetMyOvertimes))
+	authenticated(route.POST("", service.SubmitOvertime))
+	authenticated(route.DELETE("/:id", service.CancelOvertime))
+
+	permit(route.GET("", service.GetAllOvertimes), model.MenuOvertime, http.MethodGet)
+	permit(route.GET("/total", service.GetOvertimeTotals), model.MenuOvertime, http.MethodGet)
+	permit(route.GET("/:id", service.GetOvertimeByID), model.MenuOvertime, http.MethodGet)
+	permit(route.POST("/:id/confirm", service.ConfirmOvertime), model.MenuOvertime, http.MethodPut)
+	permit(route.POST("/:id/reject", service.RejectOvertime), model.MenuOvertime, http.MethodPut)
+}
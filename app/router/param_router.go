@@ -1,14 +1,19 @@
 package router
 
-import "github.com/labstack/echo/v4"
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
 
 func InitParamRoute(prefix string, e *echo.Group) {
 	route := e.Group(prefix)
 	service := factory.Service.param
 
-	route.GET("/:id", service.GetParameterByKey)
-	route.GET("", service.GetAllParam)
-	route.POST("", service.InsertNewParam)
-	route.PUT("", service.UpdateParam)
-	route.DELETE("/:id", service.DeleteParam)
+	permit(route.GET("/:id", service.GetParameterByKey), model.MenuParam, http.MethodGet)
+	permit(route.GET("", service.GetAllParam), model.MenuParam, http.MethodGet)
+	permit(route.POST("", service.InsertNewParam), model.MenuParam, http.MethodPost)
+	permit(route.PUT("", service.UpdateParam), model.MenuParam, http.MethodPut)
+	permit(route.DELETE("/:id", service.DeleteParam), model.MenuParam, http.MethodDelete)
 }
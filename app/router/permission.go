@@ -0,0 +1,25 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// permit registers the menu and action a role needs on its mapping to call r.
+func permit(r *echo.Route, menuID, action string) {
+	utils.RegisterRoutePermission(r.Method, r.Path, menuID, action)
+}
+
+// authenticated registers r as open to any signed-in user, e.g. self-service
+// endpoints that act on the caller's own account.
+func authenticated(r *echo.Route) {
+	utils.RegisterRoutePermission(r.Method, r.Path, "", "")
+}
+
+// passwordChange registers r as open to any signed-in user, including users
+// who must change their password before anything else.
+func passwordChange(r *echo.Route) {
+	authenticated(r)
+	utils.AllowBeforePasswordChange(r.Method, r.Path)
+}
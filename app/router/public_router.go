@@ -7,7 +7,7 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-func InitPublicRoute(prefix string, e *echo.Group) {
+func InitPublicRoute(prefix string, e *echo.Group, auth echo.MiddlewareFunc) {
 	route := e.Group(prefix)
 	service := factory.Service.user
 	attendance := factory.Service.attendance
@@ -22,6 +22,8 @@ func InitPublicRoute(prefix string, e *echo.Group) {
 
 	route.POST("/register", service.CreateNewUser)
 	route.POST("/login", service.Login)
+	route.POST("/refresh", service.RefreshToken)
+	route.POST("/logout", service.Logout, auth)
 	route.GET("/metabase", service.EmbedMetabase)
 
 	route.POST("/checkinout-rfid", attendance.CheckInOutRFID)
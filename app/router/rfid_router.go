@@ -0,0 +1,22 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func InitRFIDRoute(prefix string, e *echo.Group) {
+	route := e.Group(prefix)
+	service := factory.Service.rfid
+
+	permit(route.GET("/card", service.GetAllCards), model.MenuRFID, http.MethodGet)
+	permit(route.POST("/card", service.AssignCard), model.MenuRFID, http.MethodPost)
+	permit(route.PUT("/card/revoke", service.RevokeCard), model.MenuRFID, http.MethodPut)
+	permit(route.PUT("/card/lost", service.ReportLostCard), model.MenuRFID, http.MethodPut)
+
+	permit(route.GET("/device", service.GetAllDevices), model.MenuRFID, http.MethodGet)
+	permit(route.POST("/device", service.RegisterDevice), model.MenuRFID, http.MethodPost)
+	permit(route.DELETE("/device/:id", service.DeactivateDevice), model.MenuRFID, http.MethodDelete)
+}
@@ -1,21 +1,28 @@
 package router
 
-import "github.com/labstack/echo/v4"
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
 
 func InitRoleRoute(prefix string, e *echo.Group) {
 	route := e.Group(prefix)
 	service := factory.Service.role
 
-	route.GET("", service.GetAllRole)
+	permit(route.GET("", service.GetAllRole), model.MenuRole, http.MethodGet)
+
+	permit(route.GET("/mapping", service.GetAllRoleMapping), model.MenuRole, http.MethodGet)
+	permit(route.POST("/create", service.CreateNewRole), model.MenuRole, http.MethodPost)
+	permit(route.PUT("/mapping", service.UpdateRoleMapping), model.MenuRole, http.MethodPut)
+	permit(route.POST("/mapping/create", service.CreateNewRoleMapping), model.MenuRole, http.MethodPost)
+	permit(route.DELETE("/mapping/:id", service.DeleteRoleMapping), model.MenuRole, http.MethodDelete)
 
-	route.GET("/mapping", service.GetAllRoleMapping)
-	route.POST("/create", service.CreateNewRole)
-	route.PUT("/mapping", service.UpdateRoleMapping)
-	route.POST("/mapping/create", service.CreateNewRoleMapping)
-	route.DELETE("/mapping/:id", service.DeleteRoleMapping)
+	permit(route.GET("/menu", service.GetAllMenu), model.MenuRole, http.MethodGet)
+	permit(route.PUT("/menu", service.UpdateMenu), model.MenuRole, http.MethodPut)
+	permit(route.POST("/menu/create", service.CreateNewMenu), model.MenuRole, http.MethodPost)
+	permit(route.DELETE("/menu/:id", service.DeleteMenu), model.MenuRole, http.MethodDelete)
 
-	route.GET("/menu", service.GetAllMenu)
-	route.PUT("/menu", service.UpdateMenu)
-	route.POST("/menu/create", service.CreateNewMenu)
-	route.DELETE("/menu/:id", service.DeleteMenu)
+	permit(route.GET("/routes", service.GetAllRoutePermission), model.MenuRole, http.MethodGet)
 }
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func InitScheduleRoute(prefix string, e *echo.Group) {
+	route := e.Group(prefix)
+	service := factory.Service.schedule
+
+	permit(route.GET("/shift", service.GetAllShifts), model.MenuSchedule, http.MethodGet)
+	permit(route.GET("/shift/:id", service.GetShiftByID), model.MenuSchedule, http.MethodGet)
+	permit(route.POST("/shift", service.CreateNewShift), model.MenuSchedule, http.MethodPost)
+	permit(route.PUT("/shift", service.UpdateShift), model.MenuSchedule, http.MethodPut)
+	permit(route.DELETE("/shift/:id", service.DeleteShift), model.MenuSchedule, http.MethodDelete)
+
+	permit(route.GET("/assignment", service.GetAllAssignments), model.MenuSchedule, http.MethodGet)
+	permit(route.POST("/assignment", service.CreateNewAssignment), model.MenuSchedule, http.MethodPost)
+	permit(route.DELETE("/assignment/:id", service.DeleteAssignment), model.MenuSchedule, http.MethodDelete)
+
+	permit(route.GET("/user/:id", service.GetUserShift), model.MenuSchedule, http.MethodGet)
+}
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func InitTrainingRoute(prefix string, e *echo.Group) {
+	route := e.Group(prefix)
+	service := factory.Service.training
+
+	permit(route.POST("", service.GetModelTrainings), model.MenuModel, http.MethodGet)
+	permit(route.POST("/train", service.TrainModel), model.MenuModel, http.MethodPost)
+	permit(route.PUT("/activate/:id", service.ActivateModelTraining), model.MenuModel, http.MethodPut)
+}
+
+// InitCallbackRoute registers the routes internal services call back, which
+// are authenticated by the service key rather than a user session.
+func InitCallbackRoute(prefix string, e *echo.Group) {
+	route := e.Group(prefix)
+	training := factory.Service.training
+
+	route.PUT("/status", training.UpdateModelTrainingStatus)
+}
@@ -1,18 +1,36 @@
 package router
 
-import "github.com/labstack/echo/v4"
+import (
+	"bpkp-svc-portal/app/model"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
 
 func InitUserRoute(prefix string, e *echo.Group) {
 	route := e.Group(prefix)
 	service := factory.Service.user
 
-	route.GET("", service.GetAllUser)
-	route.GET("/detail/:id", service.GetUserDetail)
-	route.PUT("", service.UpdateUser)
-	route.DELETE("/:id", service.DeleteUser)
-	route.GET("/institutions", service.GetInstitutionList)
+	permit(route.GET("", service.GetAllUser), model.MenuUser, http.MethodGet)
+	permit(route.GET("/search", service.SearchUsers), model.MenuUser, http.MethodGet)
+	permit(route.GET("/detail/:id", service.GetUserDetail), model.MenuUser, http.MethodGet)
+	permit(route.PUT("", service.UpdateUser), model.MenuUser, http.MethodPut)
+	permit(route.DELETE("/:id", service.DeleteUser), model.MenuUser, http.MethodDelete)
+	permit(route.GET("/institutions", service.GetInstitutionList), model.MenuUser, http.MethodGet)
+
+	userImport := factory.Service.userImport
+
+	permit(route.POST("/import", userImport.ImportUsers), model.MenuUser, http.MethodPost)
+
+	registration := factory.Service.registration
+
+	permit(route.GET("/registration", registration.GetRegistrations), model.MenuUser, http.MethodGet)
+	permit(route.POST("/registration/:username/approve", registration.ApproveRegistration), model.MenuUser, http.MethodPut)
+	permit(route.POST("/registration/:username/reject", registration.RejectRegistration), model.MenuUser, http.MethodPut)
 
-	route.POST("/profile-photo", service.UploadProfilePhoto)
-	route.POST("/cover-photo", service.UploadCoverPhoto)
+	authenticated(route.POST("/switch-role", service.SwitchRole))
+	authenticated(route.POST("/profile-photo", service.UploadProfilePhoto))
+	authenticated(route.POST("/cover-photo", service.UploadCoverPhoto))
+	passwordChange(route.PUT("/password", service.ChangePassword))
 
 }
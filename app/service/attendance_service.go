@@ -4,7 +4,12 @@ import (
 	"bpkp-svc-portal/app/controller"
 	"bpkp-svc-portal/app/model"
 	"bpkp-svc-portal/app/utils"
+	"bytes"
+	"encoding/json"
+	"io"
 	"net/http"
+	"path/filepath"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
@@ -12,7 +17,9 @@ import (
 type InterfaceAttendanceService interface {
 	GetUserAttendances(e echo.Context) error
 	GetTodayAttendances(e echo.Context) error
+	GetAttendanceRecap(e echo.Context) error
 	CheckIn(e echo.Context) error
+	CheckInSelfie(e echo.Context) error
 	CheckOut(e echo.Context) error
 	CheckInOutRFID(e echo.Context) error
 }
@@ -40,17 +47,13 @@ func (s *AttendanceService) GetUserAttendances(e echo.Context) error {
 
 	utils.LogEvent(span, "Request", request)
 
-	response, err := s.uc.GetUserAttendances(ctx, request)
+	response, page, err := s.uc.GetUserAttendances(ctx, request)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return utils.LogError(e, err, nil)
 	}
 
-	return e.JSON(http.StatusOK, model.Response{
-		Code:    200,
-		Message: "Success Get User Attendances",
-		Data:    response,
-	})
+	return e.JSON(http.StatusOK, listResponse("Success Get User Attendances", response, page))
 }
 
 func (s *AttendanceService) CheckIn(e echo.Context) error {
@@ -79,6 +82,55 @@ func (s *AttendanceService) CheckIn(e echo.Context) error {
 	})
 }
 
+// CheckInSelfie takes the check-in as a multipart form with a "selfie" image.
+func (s *AttendanceService) CheckInSelfie(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "CheckInSelfie")
+	defer span.Finish()
+
+	var request *model.Attendance
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	if file, err := e.FormFile("selfie"); err == nil {
+		src, err := file.Open()
+		if err != nil {
+			utils.LogEventError(span, err)
+			return utils.LogError(e, err, nil)
+		}
+		defer src.Close()
+
+		var buffer bytes.Buffer
+		_, err = io.Copy(&buffer, src)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return utils.LogError(e, err, nil)
+		}
+
+		request.Selfie = &model.File{
+			FileName:    file.Filename,
+			BytesObject: buffer.Bytes(),
+			Extension:   strings.TrimPrefix(filepath.Ext(file.Filename), "."),
+		}
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.CheckInSelfie(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Check In",
+		Data:    map[string]interface{}{"face_score": request.FaceScoreIn},
+	})
+}
+
 func (s *AttendanceService) CheckOut(e echo.Context) error {
 	ctx, span := utils.StartSpan(e, "CheckOut")
 	defer span.Finish()
@@ -122,17 +174,55 @@ func (s *AttendanceService) GetTodayAttendances(e echo.Context) error {
 	})
 }
 
+func (s *AttendanceService) GetAttendanceRecap(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAttendanceRecap")
+	defer span.Finish()
+
+	request := &model.RequestAttendanceRecap{
+		Month:         e.QueryParam("month"),
+		InstitutionID: e.QueryParam("institution_id"),
+		Username:      e.QueryParam("username"),
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	res, err := s.uc.GetAttendanceRecap(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Attendance Recap",
+		Data:    res,
+	})
+}
+
 func (s *AttendanceService) CheckInOutRFID(e echo.Context) error {
 	ctx, span := utils.StartSpan(e, "CheckInOutRFID")
 	defer span.Finish()
 
-	var request *model.Attendance
-
-	if err := e.Bind(&request); err != nil {
+	// The raw body is kept so the device signature can be checked against it.
+	body, err := io.ReadAll(e.Request().Body)
+	if err != nil {
 		utils.LogEventError(span, err)
 		return utils.LogError(e, err, nil)
 	}
 
+	request := &model.RequestRFIDTap{
+		DeviceID:  e.Request().Header.Get("x-device-id"),
+		DeviceKey: e.Request().Header.Get("x-device-key"),
+		Signature: e.Request().Header.Get("x-signature"),
+		Timestamp: e.Request().Header.Get("x-timestamp"),
+		Body:      body,
+	}
+
+	if err := json.Unmarshal(body, request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, model.ThrowError(http.StatusBadRequest, err), nil)
+	}
+
 	utils.LogEvent(span, "Request", request)
 
 	res, err := s.uc.CheckInOutRFID(ctx, request)
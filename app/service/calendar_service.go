@@ -0,0 +1,152 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceCalendarService interface {
+	GetAllCalendarDays(e echo.Context) error
+	GetCalendarDayByID(e echo.Context) error
+	CreateNewCalendarDay(e echo.Context) error
+	UpdateCalendarDay(e echo.Context) error
+	DeleteCalendarDay(e echo.Context) error
+}
+
+type CalendarService struct {
+	uc controller.InterfaceCalendarController
+}
+
+func NewCalendarService(uc controller.InterfaceCalendarController) *CalendarService {
+	return &CalendarService{uc: uc}
+}
+
+func (s *CalendarService) GetAllCalendarDays(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllCalendarDays")
+	defer span.Finish()
+
+	year := 0
+	if v := e.QueryParam("year"); v != "" {
+		var err error
+		if year, err = strconv.Atoi(v); err != nil {
+			utils.LogEventError(span, err)
+			return utils.LogError(e, model.ThrowError(http.StatusBadRequest, errors.New("year must be a number")), nil)
+		}
+	}
+
+	res, err := s.uc.GetAllCalendarDays(ctx, year)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Calendar Days",
+		Data:    res,
+	})
+}
+
+func (s *CalendarService) GetCalendarDayByID(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetCalendarDayByID")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	res, err := s.uc.GetCalendarDayByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Calendar Day",
+		Data:    res,
+	})
+}
+
+func (s *CalendarService) CreateNewCalendarDay(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "CreateNewCalendarDay")
+	defer span.Finish()
+
+	var request *model.CalendarDay
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.CreateNewCalendarDay(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Create New Calendar Day",
+		Data:    nil,
+	})
+}
+
+func (s *CalendarService) UpdateCalendarDay(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "UpdateCalendarDay")
+	defer span.Finish()
+
+	var request *model.CalendarDay
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.UpdateCalendarDay(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Update Calendar Day",
+		Data:    nil,
+	})
+}
+
+func (s *CalendarService) DeleteCalendarDay(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "DeleteCalendarDay")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	err := s.uc.DeleteCalendarDay(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Delete Calendar Day",
+		Data:    nil,
+	})
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceCorrectionService interface {
+	GetAllCorrections(e echo.Context) error
+	GetMyCorrections(e echo.Context) error
+	GetCorrectionByID(e echo.Context) error
+	SubmitCorrection(e echo.Context) error
+	ApproveCorrection(e echo.Context) error
+	RejectCorrection(e echo.Context) error
+	CancelCorrection(e echo.Context) error
+}
+
+type CorrectionService struct {
+	uc controller.InterfaceCorrectionController
+}
+
+func NewCorrectionService(uc controller.InterfaceCorrectionController) *CorrectionService {
+	return &CorrectionService{uc: uc}
+}
+
+func (s *CorrectionService) GetAllCorrections(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllCorrections")
+	defer span.Finish()
+
+	res, err := s.uc.GetAllCorrections(ctx, e.QueryParam("status"))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Corrections",
+		Data:    res,
+	})
+}
+
+func (s *CorrectionService) GetMyCorrections(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetMyCorrections")
+	defer span.Finish()
+
+	res, err := s.uc.GetMyCorrections(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get My Corrections",
+		Data:    res,
+	})
+}
+
+func (s *CorrectionService) GetCorrectionByID(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetCorrectionByID")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	res, err := s.uc.GetCorrectionByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Correction",
+		Data:    res,
+	})
+}
+
+func (s *CorrectionService) SubmitCorrection(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "SubmitCorrection")
+	defer span.Finish()
+
+	var request *model.AttendanceCorrection
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.SubmitCorrection(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Submit Correction",
+		Data:    request,
+	})
+}
+
+func (s *CorrectionService) ApproveCorrection(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ApproveCorrection")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	request := &model.RequestCorrectionDecision{}
+
+	if err := e.Bind(request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	err := s.uc.ApproveCorrection(ctx, id, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Approve Correction",
+		Data:    nil,
+	})
+}
+
+func (s *CorrectionService) RejectCorrection(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "RejectCorrection")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	request := &model.RequestCorrectionDecision{}
+
+	if err := e.Bind(request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	err := s.uc.RejectCorrection(ctx, id, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Reject Correction",
+		Data:    nil,
+	})
+}
+
+func (s *CorrectionService) CancelCorrection(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "CancelCorrection")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	err := s.uc.CancelCorrection(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Cancel Correction",
+		Data:    nil,
+	})
+}
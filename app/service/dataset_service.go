@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceDatasetService interface {
+	GetAllDatasets(e echo.Context) error
+	GetDatasetByUsername(e echo.Context) error
+	UploadDataset(e echo.Context) error
+	DeleteDataset(e echo.Context) error
+}
+
+type DatasetService struct {
+	uc controller.InterfaceDatasetController
+}
+
+func NewDatasetService(uc controller.InterfaceDatasetController) *DatasetService {
+	return &DatasetService{uc: uc}
+}
+
+func (s *DatasetService) GetAllDatasets(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllDatasets")
+	defer span.Finish()
+
+	res, err := s.uc.GetAllDatasets(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Datasets",
+		Data:    res,
+	})
+}
+
+func (s *DatasetService) GetDatasetByUsername(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetDatasetByUsername")
+	defer span.Finish()
+
+	username := e.Param("id")
+	if username == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	utils.LogEvent(span, "Request", username)
+
+	res, err := s.uc.GetDatasetByUsername(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Dataset By Username",
+		Data:    res,
+	})
+}
+
+func (s *DatasetService) UploadDataset(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "UploadDataset")
+	defer span.Finish()
+
+	form, err := e.MultipartForm()
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	request := &model.Dataset{
+		Username: e.FormValue("username"),
+	}
+
+	for _, file := range form.File["file"] {
+		src, err := file.Open()
+		if err != nil {
+			utils.LogEventError(span, err)
+			return utils.LogError(e, err, nil)
+		}
+
+		var buffer bytes.Buffer
+		_, err = io.Copy(&buffer, src)
+		src.Close()
+		if err != nil {
+			utils.LogEventError(span, err)
+			return utils.LogError(e, err, nil)
+		}
+
+		request.File = append(request.File, &model.File{
+			FileName:    file.Filename,
+			BytesObject: buffer.Bytes(),
+			Extension:   strings.TrimPrefix(filepath.Ext(file.Filename), "."),
+		})
+	}
+
+	utils.LogEvent(span, "Request", request.Username)
+
+	err = s.uc.UploadDataset(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Upload Dataset",
+		Data:    nil,
+	})
+}
+
+func (s *DatasetService) DeleteDataset(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "DeleteDataset")
+	defer span.Finish()
+
+	username := e.Param("id")
+	if username == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	utils.LogEvent(span, "Request", username)
+
+	err := s.uc.DeleteDataset(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Delete Dataset",
+		Data:    nil,
+	})
+}
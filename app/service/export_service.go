@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceExportService interface {
+	ExportAttendances(e echo.Context) error
+	ExportAttendanceSummary(e echo.Context) error
+	ExportUsers(e echo.Context) error
+	GetExportJob(e echo.Context) error
+}
+
+type ExportService struct {
+	uc controller.InterfaceExportController
+}
+
+func NewExportService(uc controller.InterfaceExportController) *ExportService {
+	return &ExportService{uc: uc}
+}
+
+func (s *ExportService) ExportAttendances(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ExportAttendances")
+	defer span.Finish()
+
+	var request *model.RequestUserAttendances
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	request.RoleID = e.Request().Header.Get("app-role-id")
+
+	utils.LogEvent(span, "Request", request)
+
+	res, err := s.uc.ExportAttendances(ctx, request, exportFormat(e))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return writeExport(e, res)
+}
+
+func (s *ExportService) ExportAttendanceSummary(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ExportAttendanceSummary")
+	defer span.Finish()
+
+	var request *model.RequestUserAttendances
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	request.RoleID = e.Request().Header.Get("app-role-id")
+
+	utils.LogEvent(span, "Request", request)
+
+	res, err := s.uc.ExportAttendanceSummary(ctx, request, exportFormat(e))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return writeExport(e, res)
+}
+
+func (s *ExportService) ExportUsers(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ExportUsers")
+	defer span.Finish()
+
+	res, err := s.uc.ExportUsers(ctx, exportFormat(e))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return writeExport(e, res)
+}
+
+func (s *ExportService) GetExportJob(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetExportJob")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	res, err := s.uc.GetExportJob(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Export",
+		Data:    res,
+	})
+}
+
+// exportFormat is the format query param, CSV by default.
+func exportFormat(e echo.Context) string {
+	if format := e.QueryParam("format"); format != "" {
+		return format
+	}
+
+	return model.ExportCSV
+}
+
+// writeExport streams a small export as a file download, or answers 202 with
+// the job writing a large one.
+func writeExport(e echo.Context, res *model.ExportResult) error {
+	if res.Job != nil {
+		return e.JSON(http.StatusAccepted, model.Response{
+			Code:    http.StatusAccepted,
+			Message: "Export Is Running In The Background",
+			Data:    res.Job,
+		})
+	}
+
+	e.Response().Header().Set(echo.HeaderContentType, utils.ContentType(res.Format))
+	e.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", res.FileName))
+	e.Response().WriteHeader(http.StatusOK)
+
+	return utils.WriteSheets(e.Response(), res.Format, res.Sheets)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceGeofenceService interface {
+	GetAllExemptions(e echo.Context) error
+	SaveExemption(e echo.Context) error
+	DeleteExemption(e echo.Context) error
+}
+
+type GeofenceService struct {
+	uc controller.InterfaceGeofenceController
+}
+
+func NewGeofenceService(uc controller.InterfaceGeofenceController) *GeofenceService {
+	return &GeofenceService{uc: uc}
+}
+
+func (c *GeofenceService) GetAllExemptions(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllExemptions")
+	defer span.Finish()
+
+	res, err := c.uc.GetAllExemptions(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Response", res)
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Exemptions",
+		Data:    res,
+	})
+}
+
+func (c *GeofenceService) SaveExemption(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "SaveExemption")
+	defer span.Finish()
+
+	var exemption *model.GeofenceExemption
+	if err := e.Bind(&exemption); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	err := c.uc.SaveExemption(ctx, exemption)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Response", exemption)
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Save Exemption",
+		Data:    nil,
+	})
+}
+
+func (c *GeofenceService) DeleteExemption(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "DeleteExemption")
+	defer span.Finish()
+
+	username := e.Param("username")
+	if username == "" {
+		utils.LogEventError(span, errors.New("username shouldn't be empty"))
+		return utils.LogError(e, errors.New("username shouldn't be empty"), nil)
+	}
+
+	err := c.uc.DeleteExemption(ctx, username)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Response", "Success Delete Exemption")
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Delete Exemption",
+		Data:    nil,
+	})
+}
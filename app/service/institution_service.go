@@ -30,7 +30,13 @@ func (c *InstitutionService) GetAllInstitution(e echo.Context) error {
 	ctx, span := utils.StartSpan(e, "GetAllInstitution")
 	defer span.Finish()
 
-	res, err := c.uc.GetAllInstitution(ctx)
+	query, err := bindListQuery(e)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	res, page, err := c.uc.GetAllInstitution(ctx, query)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return utils.LogError(e, err, nil)
@@ -38,11 +44,7 @@ func (c *InstitutionService) GetAllInstitution(e echo.Context) error {
 
 	utils.LogEvent(span, "Response", res)
 
-	return e.JSON(http.StatusOK, model.Response{
-		Code:    200,
-		Message: "Success Get All Institution",
-		Data:    res,
-	})
+	return e.JSON(http.StatusOK, listResponse("Success Get All Institution", res, page))
 }
 
 func (c *InstitutionService) GetInstitutionByID(e echo.Context) error {
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceLeaveService interface {
+	GetAllLeaves(e echo.Context) error
+	GetMyLeaves(e echo.Context) error
+	GetLeaveByID(e echo.Context) error
+	SubmitLeave(e echo.Context) error
+	ApproveLeave(e echo.Context) error
+	RejectLeave(e echo.Context) error
+	CancelLeave(e echo.Context) error
+
+	GetMyLeaveBalances(e echo.Context) error
+	GetLeaveBalances(e echo.Context) error
+}
+
+type LeaveService struct {
+	uc controller.InterfaceLeaveController
+}
+
+func NewLeaveService(uc controller.InterfaceLeaveController) *LeaveService {
+	return &LeaveService{uc: uc}
+}
+
+func (s *LeaveService) GetAllLeaves(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllLeaves")
+	defer span.Finish()
+
+	res, err := s.uc.GetAllLeaves(ctx, e.QueryParam("status"))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Leaves",
+		Data:    res,
+	})
+}
+
+func (s *LeaveService) GetMyLeaves(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetMyLeaves")
+	defer span.Finish()
+
+	res, err := s.uc.GetMyLeaves(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get My Leaves",
+		Data:    res,
+	})
+}
+
+func (s *LeaveService) GetLeaveByID(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetLeaveByID")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	res, err := s.uc.GetLeaveByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Leave",
+		Data:    res,
+	})
+}
+
+// SubmitLeave takes the request as JSON or as a multipart form with an
+// optional "attachment" file.
+func (s *LeaveService) SubmitLeave(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "SubmitLeave")
+	defer span.Finish()
+
+	var request *model.LeaveRequest
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	if file, err := e.FormFile("attachment"); err == nil {
+		src, err := file.Open()
+		if err != nil {
+			utils.LogEventError(span, err)
+			return utils.LogError(e, err, nil)
+		}
+		defer src.Close()
+
+		var buffer bytes.Buffer
+		_, err = io.Copy(&buffer, src)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return utils.LogError(e, err, nil)
+		}
+
+		request.File = &model.File{
+			FileName:    file.Filename,
+			BytesObject: buffer.Bytes(),
+			Extension:   strings.TrimPrefix(filepath.Ext(file.Filename), "."),
+		}
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.SubmitLeave(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Submit Leave",
+		Data:    request,
+	})
+}
+
+func (s *LeaveService) ApproveLeave(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ApproveLeave")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	request := &model.RequestLeaveDecision{}
+
+	if err := e.Bind(request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	err := s.uc.ApproveLeave(ctx, id, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Approve Leave",
+		Data:    nil,
+	})
+}
+
+func (s *LeaveService) RejectLeave(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "RejectLeave")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	request := &model.RequestLeaveDecision{}
+
+	if err := e.Bind(request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	err := s.uc.RejectLeave(ctx, id, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Reject Leave",
+		Data:    nil,
+	})
+}
+
+func (s *LeaveService) CancelLeave(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "CancelLeave")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	err := s.uc.CancelLeave(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Cancel Leave",
+		Data:    nil,
+	})
+}
+
+func (s *LeaveService) GetMyLeaveBalances(e echo.Context) error {
+	return s.getLeaveBalances(e, "")
+}
+
+func (s *LeaveService) GetLeaveBalances(e echo.Context) error {
+	id := e.Param("id")
+	if id == "" {
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	return s.getLeaveBalances(e, id)
+}
+
+func (s *LeaveService) getLeaveBalances(e echo.Context, username string) error {
+	ctx, span := utils.StartSpan(e, "GetLeaveBalances")
+	defer span.Finish()
+
+	year := 0
+	if v := e.QueryParam("year"); v != "" {
+		var err error
+		if year, err = strconv.Atoi(v); err != nil || year < 1000 {
+			utils.LogEventError(span, errors.New("year must be YYYY"))
+			return utils.LogError(e, model.ThrowError(http.StatusBadRequest, errors.New("year must be YYYY")), nil)
+		}
+	}
+
+	res, err := s.uc.GetLeaveBalances(ctx, username, year)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Leave Balances",
+		Data:    res,
+	})
+}
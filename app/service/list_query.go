@@ -0,0 +1,71 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/model"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// listQueryParams are the query parameters that page and sort a list. Any
+// other parameter filters it.
+var listQueryParams = map[string]bool{
+	"page":   true,
+	"size":   true,
+	"cursor": true,
+	"sort":   true,
+	"from":   true,
+	"to":     true,
+}
+
+// bindListQuery reads a list query from the query parameters of a GET list
+// endpoint, e.g. ?size=50&sort=-created_at&institution_id=BPKP.
+func bindListQuery(e echo.Context) (*model.ListQuery, error) {
+	params := e.QueryParams()
+
+	query := &model.ListQuery{
+		Cursor: params.Get("cursor"),
+		Sort:   params.Get("sort"),
+		From:   params.Get("from"),
+		To:     params.Get("to"),
+	}
+
+	var err error
+	if v := params.Get("page"); v != "" {
+		if query.Page, err = strconv.Atoi(v); err != nil {
+			return nil, model.ThrowError(http.StatusBadRequest, errors.New("page must be a number"))
+		}
+	}
+
+	if v := params.Get("size"); v != "" {
+		if query.Size, err = strconv.Atoi(v); err != nil {
+			return nil, model.ThrowError(http.StatusBadRequest, errors.New("size must be a number"))
+		}
+	}
+
+	for k := range params {
+		if listQueryParams[k] {
+			continue
+		}
+
+		if query.Filters == nil {
+			query.Filters = make(map[string]string)
+		}
+		query.Filters[k] = params.Get(k)
+	}
+
+	return query, nil
+}
+
+// listResponse is the response of a list endpoint, carrying its page.
+func listResponse(message string, data interface{}, page *model.ListPage) model.Response {
+	return model.Response{
+		Code:       200,
+		Message:    message,
+		Data:       data,
+		Total:      &page.Total,
+		NextCursor: page.NextCursor,
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceOvertimeService interface {
+	GetAllOvertimes(e echo.Context) error
+	GetMyOvertimes(e echo.Context) error
+	GetOvertimeByID(e echo.Context) error
+	SubmitOvertime(e echo.Context) error
+	ConfirmOvertime(e echo.Context) error
+	RejectOvertime(e echo.Context) error
+	CancelOvertime(e echo.Context) error
+	GetOvertimeTotals(e echo.Context) error
+}
+
+type OvertimeService struct {
+	uc controller.InterfaceOvertimeController
+}
+
+func NewOvertimeService(uc controller.InterfaceOvertimeController) *OvertimeService {
+	return &OvertimeService{uc: uc}
+}
+
+func (s *OvertimeService) GetAllOvertimes(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllOvertimes")
+	defer span.Finish()
+
+	res, err := s.uc.GetAllOvertimes(ctx, e.QueryParam("status"))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Overtimes",
+		Data:    res,
+	})
+}
+
+func (s *OvertimeService) GetMyOvertimes(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetMyOvertimes")
+	defer span.Finish()
+
+	res, err := s.uc.GetMyOvertimes(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get My Overtimes",
+		Data:    res,
+	})
+}
+
+func (s *OvertimeService) GetOvertimeByID(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetOvertimeByID")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	res, err := s.uc.GetOvertimeByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Overtime",
+		Data:    res,
+	})
+}
+
+func (s *OvertimeService) SubmitOvertime(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "SubmitOvertime")
+	defer span.Finish()
+
+	var request *model.OvertimeRequest
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.SubmitOvertime(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Submit Overtime",
+		Data:    request,
+	})
+}
+
+func (s *OvertimeService) ConfirmOvertime(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ConfirmOvertime")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	request := &model.RequestOvertimeDecision{}
+
+	if err := e.Bind(request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	err := s.uc.ConfirmOvertime(ctx, id, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Confirm Overtime",
+		Data:    nil,
+	})
+}
+
+func (s *OvertimeService) RejectOvertime(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "RejectOvertime")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	request := &model.RequestOvertimeDecision{}
+
+	if err := e.Bind(request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	err := s.uc.RejectOvertime(ctx, id, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Reject Overtime",
+		Data:    nil,
+	})
+}
+
+func (s *OvertimeService) CancelOvertime(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "CancelOvertime")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	err := s.uc.CancelOvertime(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Cancel Overtime",
+		Data:    nil,
+	})
+}
+
+func (s *OvertimeService) GetOvertimeTotals(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetOvertimeTotals")
+	defer span.Finish()
+
+	res, err := s.uc.GetOvertimeTotals(ctx, e.QueryParam("month"))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Overtime Totals",
+		Data:    res,
+	})
+}
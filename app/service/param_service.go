@@ -57,7 +57,13 @@ func (s *ParamService) GetAllParam(e echo.Context) error {
 	ctx, span := utils.StartSpan(e, "GetAllParam")
 	defer span.Finish()
 
-	res, err := s.uc.GetAllParam(ctx)
+	query, err := bindListQuery(e)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	res, page, err := s.uc.GetAllParam(ctx, query)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return utils.LogError(e, err, nil)
@@ -65,11 +71,7 @@ func (s *ParamService) GetAllParam(e echo.Context) error {
 
 	utils.LogEvent(span, "Response", res)
 
-	return e.JSON(http.StatusOK, model.Response{
-		Code:    200,
-		Message: "Success Get All Param",
-		Data:    res,
-	})
+	return e.JSON(http.StatusOK, listResponse("Success Get All Param", res, page))
 }
 
 func (s *ParamService) InsertNewParam(e echo.Context) error {
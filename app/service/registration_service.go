@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceRegistrationService interface {
+	GetRegistrations(e echo.Context) error
+	ApproveRegistration(e echo.Context) error
+	RejectRegistration(e echo.Context) error
+}
+
+type RegistrationService struct {
+	uc controller.InterfaceRegistrationController
+}
+
+func NewRegistrationService(uc controller.InterfaceRegistrationController) *RegistrationService {
+	return &RegistrationService{uc: uc}
+}
+
+func (s *RegistrationService) GetRegistrations(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetRegistrations")
+	defer span.Finish()
+
+	query, err := bindListQuery(e)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	res, page, err := s.uc.GetRegistrations(ctx, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, listResponse("Success Get Registrations", res, page))
+}
+
+func (s *RegistrationService) ApproveRegistration(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ApproveRegistration")
+	defer span.Finish()
+
+	username := e.Param("username")
+	if username == "" {
+		utils.LogEventError(span, errors.New("username shouldn't be empty"))
+		return utils.LogError(e, errors.New("username shouldn't be empty"), nil)
+	}
+
+	request := &model.RequestRegistrationDecision{}
+
+	if err := e.Bind(request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	err := s.uc.ApproveRegistration(ctx, username, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Approve Registration",
+		Data:    nil,
+	})
+}
+
+func (s *RegistrationService) RejectRegistration(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "RejectRegistration")
+	defer span.Finish()
+
+	username := e.Param("username")
+	if username == "" {
+		utils.LogEventError(span, errors.New("username shouldn't be empty"))
+		return utils.LogError(e, errors.New("username shouldn't be empty"), nil)
+	}
+
+	request := &model.RequestRegistrationDecision{}
+
+	if err := e.Bind(request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	err := s.uc.RejectRegistration(ctx, username, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Reject Registration",
+		Data:    nil,
+	})
+}
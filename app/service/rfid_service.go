@@ -0,0 +1,193 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceRFIDService interface {
+	GetAllCards(e echo.Context) error
+	AssignCard(e echo.Context) error
+	RevokeCard(e echo.Context) error
+	ReportLostCard(e echo.Context) error
+
+	GetAllDevices(e echo.Context) error
+	RegisterDevice(e echo.Context) error
+	DeactivateDevice(e echo.Context) error
+}
+
+type RFIDService struct {
+	uc controller.InterfaceRFIDController
+}
+
+func NewRFIDService(uc controller.InterfaceRFIDController) *RFIDService {
+	return &RFIDService{uc: uc}
+}
+
+func (s *RFIDService) GetAllCards(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllCards")
+	defer span.Finish()
+
+	res, err := s.uc.GetAllCards(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Cards",
+		Data:    res,
+	})
+}
+
+func (s *RFIDService) AssignCard(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "AssignCard")
+	defer span.Finish()
+
+	var request *model.RFIDCard
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.AssignCard(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Assign Card",
+		Data:    nil,
+	})
+}
+
+func (s *RFIDService) RevokeCard(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "RevokeCard")
+	defer span.Finish()
+
+	var request *model.RequestRFIDCardStatus
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.RevokeCard(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Revoke Card",
+		Data:    nil,
+	})
+}
+
+func (s *RFIDService) ReportLostCard(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ReportLostCard")
+	defer span.Finish()
+
+	var request *model.RequestRFIDCardStatus
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.ReportLostCard(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Report Lost Card",
+		Data:    nil,
+	})
+}
+
+func (s *RFIDService) GetAllDevices(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllDevices")
+	defer span.Finish()
+
+	res, err := s.uc.GetAllDevices(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Devices",
+		Data:    res,
+	})
+}
+
+func (s *RFIDService) RegisterDevice(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "RegisterDevice")
+	defer span.Finish()
+
+	var request *model.RFIDDevice
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	res, err := s.uc.RegisterDevice(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Register Device",
+		Data:    res,
+	})
+}
+
+func (s *RFIDService) DeactivateDevice(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "DeactivateDevice")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	utils.LogEvent(span, "Request", id)
+
+	err := s.uc.DeactivateDevice(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Deactivate Device",
+		Data:    nil,
+	})
+}
@@ -23,6 +23,8 @@ type InterfaceRoleService interface {
 
 	GetAllRole(e echo.Context) error
 	CreateNewRole(e echo.Context) error
+
+	GetAllRoutePermission(e echo.Context) error
 }
 
 type RoleService struct {
@@ -66,7 +68,13 @@ func (s *RoleService) GetAllRoleMapping(e echo.Context) error {
 	ctx, span := utils.StartSpan(e, "GetAllRoleMapping")
 	defer span.Finish()
 
-	response, err := s.uc.GetAllRoleMapping(ctx)
+	query, err := bindListQuery(e)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	response, page, err := s.uc.GetAllRoleMapping(ctx, query)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return utils.LogError(e, err, nil)
@@ -74,11 +82,7 @@ func (s *RoleService) GetAllRoleMapping(e echo.Context) error {
 
 	utils.LogEvent(span, "Response", response)
 
-	return e.JSON(http.StatusOK, model.Response{
-		Code:    200,
-		Message: "Success Get All Role",
-		Data:    response,
-	})
+	return e.JSON(http.StatusOK, listResponse("Success Get All Role", response, page))
 }
 
 func (s *RoleService) UpdateRoleMapping(e echo.Context) error {
@@ -278,3 +282,22 @@ func (s *RoleService) DeleteRoleMapping(e echo.Context) error {
 		Data:    nil,
 	})
 }
+
+func (s *RoleService) GetAllRoutePermission(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllRoutePermission")
+	defer span.Finish()
+
+	response, err := s.uc.GetAllRoutePermission(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Response", response)
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Route Permission",
+		Data:    response,
+	})
+}
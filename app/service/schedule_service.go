@@ -0,0 +1,237 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceScheduleService interface {
+	GetAllShifts(e echo.Context) error
+	GetShiftByID(e echo.Context) error
+	CreateNewShift(e echo.Context) error
+	UpdateShift(e echo.Context) error
+	DeleteShift(e echo.Context) error
+
+	GetAllAssignments(e echo.Context) error
+	CreateNewAssignment(e echo.Context) error
+	DeleteAssignment(e echo.Context) error
+
+	GetUserShift(e echo.Context) error
+}
+
+type ScheduleService struct {
+	uc controller.InterfaceScheduleController
+}
+
+func NewScheduleService(uc controller.InterfaceScheduleController) *ScheduleService {
+	return &ScheduleService{uc: uc}
+}
+
+func (s *ScheduleService) GetAllShifts(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllShifts")
+	defer span.Finish()
+
+	res, err := s.uc.GetAllShifts(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Shifts",
+		Data:    res,
+	})
+}
+
+func (s *ScheduleService) GetShiftByID(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetShiftByID")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	res, err := s.uc.GetShiftByID(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Shift",
+		Data:    res,
+	})
+}
+
+func (s *ScheduleService) CreateNewShift(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "CreateNewShift")
+	defer span.Finish()
+
+	var request *model.Shift
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.CreateNewShift(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Create New Shift",
+		Data:    nil,
+	})
+}
+
+func (s *ScheduleService) UpdateShift(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "UpdateShift")
+	defer span.Finish()
+
+	var request *model.Shift
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.UpdateShift(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Update Shift",
+		Data:    nil,
+	})
+}
+
+func (s *ScheduleService) DeleteShift(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "DeleteShift")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	err := s.uc.DeleteShift(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Delete Shift",
+		Data:    nil,
+	})
+}
+
+func (s *ScheduleService) GetAllAssignments(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAllAssignments")
+	defer span.Finish()
+
+	res, err := s.uc.GetAllAssignments(ctx)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get All Assignments",
+		Data:    res,
+	})
+}
+
+func (s *ScheduleService) CreateNewAssignment(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "CreateNewAssignment")
+	defer span.Finish()
+
+	var request *model.ScheduleAssignment
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.CreateNewAssignment(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Create New Assignment",
+		Data:    nil,
+	})
+}
+
+func (s *ScheduleService) DeleteAssignment(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "DeleteAssignment")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	err := s.uc.DeleteAssignment(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Delete Assignment",
+		Data:    nil,
+	})
+}
+
+func (s *ScheduleService) GetUserShift(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetUserShift")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	res, err := s.uc.GetUserShift(ctx, id, e.QueryParam("date"))
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get User Shift",
+		Data:    res,
+	})
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceTrainingService interface {
+	TrainModel(e echo.Context) error
+	GetModelTrainings(e echo.Context) error
+	UpdateModelTrainingStatus(e echo.Context) error
+	ActivateModelTraining(e echo.Context) error
+}
+
+type TrainingService struct {
+	uc controller.InterfaceTrainingController
+}
+
+func NewTrainingService(uc controller.InterfaceTrainingController) *TrainingService {
+	return &TrainingService{uc: uc}
+}
+
+func (s *TrainingService) TrainModel(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "TrainModel")
+	defer span.Finish()
+
+	var request *model.RequestTrainModel
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	res, err := s.uc.TrainModel(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Train Model",
+		Data:    res,
+	})
+}
+
+func (s *TrainingService) GetModelTrainings(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetModelTrainings")
+	defer span.Finish()
+
+	var request *model.FilterModelTraining
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	res, err := s.uc.GetModelTrainings(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Get Model Trainings",
+		Data:    res,
+	})
+}
+
+func (s *TrainingService) UpdateModelTrainingStatus(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "UpdateModelTrainingStatus")
+	defer span.Finish()
+
+	var request *model.RequestTrainingStatus
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Request", request)
+
+	err := s.uc.UpdateModelTrainingStatus(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Update Model Training Status",
+		Data:    nil,
+	})
+}
+
+func (s *TrainingService) ActivateModelTraining(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ActivateModelTraining")
+	defer span.Finish()
+
+	id := e.Param("id")
+	if id == "" {
+		utils.LogEventError(span, errors.New("id shouldn't be empty"))
+		return utils.LogError(e, errors.New("id shouldn't be empty"), nil)
+	}
+
+	utils.LogEvent(span, "Request", id)
+
+	err := s.uc.ActivateModelTraining(ctx, id)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Activate Model Training",
+		Data:    nil,
+	})
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bpkp-svc-portal/app/controller"
+	"bpkp-svc-portal/app/model"
+	"bpkp-svc-portal/app/utils"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InterfaceUserImportService interface {
+	ImportUsers(e echo.Context) error
+}
+
+type UserImportService struct {
+	uc controller.InterfaceUserImportController
+}
+
+func NewUserImportService(uc controller.InterfaceUserImportController) *UserImportService {
+	return &UserImportService{uc: uc}
+}
+
+func (s *UserImportService) ImportUsers(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ImportUsers")
+	defer span.Finish()
+
+	request := &model.RequestUserImport{}
+
+	if value := e.FormValue("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.LogEventError(span, err)
+			return utils.LogError(e, model.ThrowError(http.StatusBadRequest, errors.New("dry_run must be true or false")), nil)
+		}
+
+		request.DryRun = dryRun
+	}
+
+	file, err := e.FormFile("file")
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, model.ThrowError(http.StatusBadRequest, errors.New("file shouldn't be empty")), nil)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+	defer src.Close()
+
+	var buffer bytes.Buffer
+	_, err = io.Copy(&buffer, src)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	request.File = &model.File{
+		FileName:    file.Filename,
+		BytesObject: buffer.Bytes(),
+		Extension:   strings.TrimPrefix(filepath.Ext(file.Filename), "."),
+	}
+
+	res, err := s.uc.ImportUsers(ctx, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Import User",
+		Data:    res,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"bpkp-svc-portal/app/model"
 	"bpkp-svc-portal/app/utils"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	jwtv5 "github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
@@ -21,7 +23,12 @@ type InterfaceUserService interface {
 	UpdateUser(e echo.Context) error
 	DeleteUser(e echo.Context) error
 	Login(e echo.Context) error
+	RefreshToken(e echo.Context) error
+	Logout(e echo.Context) error
+	SwitchRole(e echo.Context) error
+	ChangePassword(e echo.Context) error
 	GetAllUser(e echo.Context) error
+	SearchUsers(e echo.Context) error
 	GetInstitutionList(e echo.Context) error
 	EmbedMetabase(e echo.Context) error
 	UploadProfilePhoto(e echo.Context) error
@@ -160,25 +167,165 @@ func (s *UserService) Login(e echo.Context) error {
 	})
 }
 
-func (s *UserService) GetAllUser(e echo.Context) error {
-	ctx, span := utils.StartSpan(e, "GetAlluser")
+func (s *UserService) RefreshToken(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "RefreshToken")
 	defer span.Finish()
 
-	users, err := s.uc.GetAllUser(ctx)
+	var request *model.RequestRefreshToken
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	response, err := s.uc.RefreshToken(ctx, request)
 	if err != nil {
 		utils.LogEventError(span, err)
 		return utils.LogError(e, err, nil)
 	}
 
-	utils.LogEvent(span, "Response", users)
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Refresh Token",
+		Data:    response,
+	})
+}
+
+func (s *UserService) Logout(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "Logout")
+	defer span.Finish()
+
+	var request *model.RequestRefreshToken
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	token := e.Get("user").(*jwtv5.Token)
+	claims := token.Claims.(*model.JwtCustomClaims)
+
+	err := s.uc.Logout(ctx, claims, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Logout",
+		Data:    nil,
+	})
+}
+
+func (s *UserService) SwitchRole(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "SwitchRole")
+	defer span.Finish()
+
+	var request *model.RequestSwitchRole
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	if request.RoleID == "" {
+		utils.LogEventError(span, errors.New("role_id shouldn't be empty"))
+		return utils.LogError(e, model.ThrowError(http.StatusBadRequest, errors.New("role_id shouldn't be empty")), nil)
+	}
+
+	token := e.Get("user").(*jwtv5.Token)
+	claims := token.Claims.(*model.JwtCustomClaims)
+
+	response, err := s.uc.SwitchRole(ctx, claims, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, model.Response{
+		Code:    200,
+		Message: "Success Switch Role",
+		Data:    response,
+	})
+}
+
+func (s *UserService) ChangePassword(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "ChangePassword")
+	defer span.Finish()
+
+	var request *model.RequestChangePassword
+
+	if err := e.Bind(&request); err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	if request.OldPassword == "" || request.NewPassword == "" {
+		utils.LogEventError(span, errors.New("old_password and new_password shouldn't be empty"))
+		return utils.LogError(e, model.ThrowError(http.StatusBadRequest, errors.New("old_password and new_password shouldn't be empty")), nil)
+	}
+
+	token := e.Get("user").(*jwtv5.Token)
+	claims := token.Claims.(*model.JwtCustomClaims)
+
+	response, err := s.uc.ChangePassword(ctx, claims, request)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
 
 	return e.JSON(http.StatusOK, model.Response{
 		Code:    200,
-		Message: "Success Get All User",
-		Data:    users,
+		Message: "Success Change Password",
+		Data:    response,
 	})
 }
 
+func (s *UserService) GetAllUser(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "GetAlluser")
+	defer span.Finish()
+
+	query, err := bindListQuery(e)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	users, page, err := s.uc.GetAllUser(ctx, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	utils.LogEvent(span, "Response", users)
+
+	return e.JSON(http.StatusOK, listResponse("Success Get All User", users, page))
+}
+
+func (s *UserService) SearchUsers(e echo.Context) error {
+	ctx, span := utils.StartSpan(e, "SearchUsers")
+	defer span.Finish()
+
+	query, err := bindListQuery(e)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	// q is the search term, not a filter.
+	term := e.QueryParam("q")
+	delete(query.Filters, "q")
+
+	users, page, err := s.uc.SearchUsers(ctx, term, query)
+	if err != nil {
+		utils.LogEventError(span, err)
+		return utils.LogError(e, err, nil)
+	}
+
+	return e.JSON(http.StatusOK, listResponse("Success Search User", users, page))
+}
+
 func (s *UserService) GetInstitutionList(e echo.Context) error {
 	ctx, span := utils.StartSpan(e, "GetInstitutionList")
 	defer span.Finish()
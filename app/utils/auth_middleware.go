@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"context"
+	"crypto/hmac"
 	"errors"
 	"net/http"
 	"strings"
@@ -9,20 +11,59 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc/metadata"
 )
 
-func IsAuthorized() echo.MiddlewareFunc {
+func DenylistKey(jti string) string {
+	return "denylist:" + jti
+}
+
+// MenuMappingResolver resolves the current menu -> access method map of a
+// role, so permission changes apply without waiting for a new token.
+type MenuMappingResolver interface {
+	GetRoleMenuMapping(ctx context.Context, roleID string) (map[string]string, error)
+}
+
+func IsAuthorized(rdb *redis.Client, resolver MenuMappingResolver) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			token := c.Get("user").(*jwt.Token)
 			claims := token.Claims.(*model.JwtCustomClaims)
-			access := strings.Split(claims.MenuMapping[c.Request().Header.Get("app-menu-id")], ",")
+
+			denied, err := rdb.Exists(c.Request().Context(), DenylistKey(claims.ID)).Result()
+			if err != nil {
+				return LogError(c, err, nil)
+			}
+			if denied > 0 {
+				return LogError(c, model.ThrowError(http.StatusUnauthorized, errors.New("Token Has Been Revoked")), nil)
+			}
+
 			if c.Request().Header.Get("app-role-id") != claims.Role {
 				return LogError(c, model.ThrowError(http.StatusForbidden, errors.New("Anda Tidak Memiliki Akses")), nil)
 			}
-			if !Contains(access, c.Request().Method) {
-				return LogError(c, model.ThrowError(http.StatusForbidden, errors.New("Method Not Allowed")), nil)
+
+			// The permission comes from the matched route, never from request
+			// headers; routes that were not registered are denied.
+			permission, ok := GetRoutePermission(c.Request().Method, c.Path())
+			if !ok {
+				return LogError(c, model.ThrowError(http.StatusForbidden, errors.New("Anda Tidak Memiliki Akses")), nil)
+			}
+
+			if claims.MustChangePassword && !permission.PasswordChange {
+				return LogError(c, model.ThrowError(http.StatusForbidden, errors.New("you must change your password first")), nil)
+			}
+
+			if permission.MenuID != "" {
+				menuMapping, err := resolver.GetRoleMenuMapping(c.Request().Context(), claims.Role)
+				if err != nil {
+					return LogError(c, err, nil)
+				}
+
+				access := strings.Split(menuMapping[permission.MenuID], ",")
+				if !Contains(access, permission.Action) {
+					return LogError(c, model.ThrowError(http.StatusForbidden, errors.New("Method Not Allowed")), nil)
+				}
 			}
 
 			md := metadata.New(map[string]string{
@@ -37,3 +78,21 @@ func IsAuthorized() echo.MiddlewareFunc {
 		}
 	}
 }
+
+// ServiceKeyHeader carries the key of an internal service calling back.
+const ServiceKeyHeader = "X-Service-Key"
+
+// IsService admits internal services presenting key. Every request is
+// refused while no key is configured.
+func IsService(key string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			presented := c.Request().Header.Get(ServiceKeyHeader)
+			if key == "" || !hmac.Equal([]byte(presented), []byte(key)) {
+				return LogError(c, model.ThrowError(http.StatusUnauthorized, errors.New("invalid service key")), nil)
+			}
+
+			return next(c)
+		}
+	}
+}
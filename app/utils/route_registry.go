@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"bpkp-svc-portal/app/model"
+	"sort"
+	"sync"
+)
+
+var (
+	routePermissionsMu sync.RWMutex
+	routePermissions   = make(map[string]*model.RoutePermission)
+)
+
+func routePermissionKey(method, path string) string {
+	return method + " " + path
+}
+
+// RegisterRoutePermission records the menu and action required by the route
+// matching method and path (the Echo route pattern, e.g. "/api/service/user/:id").
+func RegisterRoutePermission(method, path, menuID, action string) {
+	routePermissionsMu.Lock()
+	defer routePermissionsMu.Unlock()
+
+	routePermissions[routePermissionKey(method, path)] = &model.RoutePermission{
+		Method: method,
+		Path:   path,
+		MenuID: menuID,
+		Action: action,
+	}
+}
+
+// AllowBeforePasswordChange lets users who must change their password call
+// the registered route matching method and path.
+func AllowBeforePasswordChange(method, path string) {
+	routePermissionsMu.Lock()
+	defer routePermissionsMu.Unlock()
+
+	if permission, ok := routePermissions[routePermissionKey(method, path)]; ok {
+		permission.PasswordChange = true
+	}
+}
+
+func GetRoutePermission(method, path string) (*model.RoutePermission, bool) {
+	routePermissionsMu.RLock()
+	defer routePermissionsMu.RUnlock()
+
+	permission, ok := routePermissions[routePermissionKey(method, path)]
+	return permission, ok
+}
+
+// GetAllRoutePermission lists every registered route ordered by path and method.
+func GetAllRoutePermission() []*model.RoutePermission {
+	routePermissionsMu.RLock()
+	defer routePermissionsMu.RUnlock()
+
+	res := make([]*model.RoutePermission, 0, len(routePermissions))
+	for _, v := range routePermissions {
+		res = append(res, v)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Path != res[j].Path {
+			return res[i].Path < res[j].Path
+		}
+		return res[i].Method < res[j].Method
+	})
+
+	return res
+}
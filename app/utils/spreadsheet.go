@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"bpkp-svc-portal/app/model"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// timestampLayout is how exported timestamps are written, in WIB.
+const timestampLayout = "2006-01-02 15:04:05"
+
+// formulaPrefixes start text that spreadsheet apps would run as a formula.
+const formulaPrefixes = "=+-@"
+
+// ContentType returns the MIME type of an export format.
+func ContentType(format string) string {
+	if format == model.ExportXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv; charset=utf-8"
+}
+
+// WriteSheets writes sheets to w as an XLSX workbook, or as CSV. A CSV file
+// holds only the first sheet.
+func WriteSheets(w io.Writer, format string, sheets []*model.Sheet) error {
+	if len(sheets) == 0 {
+		return errors.New("nothing to export")
+	}
+
+	switch format {
+	case model.ExportCSV:
+		return writeCSV(w, sheets[0])
+	case model.ExportXLSX:
+		return writeXLSX(w, sheets)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ReadSheet reads the rows of a CSV file, or of the first sheet of an XLSX
+// workbook, as text.
+func ReadSheet(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case model.ExportCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		return reader.ReadAll()
+	case model.ExportXLSX:
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil
+		}
+
+		return file.GetRows(sheets[0])
+	default:
+		return nil, fmt.Errorf("unknown spreadsheet format %q", format)
+	}
+}
+
+func writeCSV(w io.Writer, sheet *model.Sheet) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(sheet.Headers); err != nil {
+		return err
+	}
+
+	record := make([]string, len(sheet.Headers))
+	for _, row := range sheet.Rows {
+		for i, v := range row {
+			record[i] = cellText(v)
+		}
+
+		if err := writer.Write(record[:len(row)]); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func writeXLSX(w io.Writer, sheets []*model.Sheet) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := file.SetSheetName("Sheet1", sheet.Name); err != nil {
+				return err
+			}
+		} else if _, err := file.NewSheet(sheet.Name); err != nil {
+			return err
+		}
+
+		writer, err := file.NewStreamWriter(sheet.Name)
+		if err != nil {
+			return err
+		}
+
+		header := make([]interface{}, len(sheet.Headers))
+		for i, v := range sheet.Headers {
+			header[i] = v
+		}
+
+		if err := writer.SetRow("A1", header); err != nil {
+			return err
+		}
+
+		for j, row := range sheet.Rows {
+			cells := make([]interface{}, len(row))
+			for i, v := range row {
+				cells[i] = cellValue(v)
+			}
+
+			cell, err := excelize.CoordinatesToCellName(1, j+2)
+			if err != nil {
+				return err
+			}
+
+			if err := writer.SetRow(cell, cells); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return file.Write(w)
+}
+
+// cellValue keeps numbers as numbers and writes times as WIB text; zero and
+// nil times are left empty.
+func cellValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case string, time.Time, *time.Time, *float64:
+		return cellText(value)
+	default:
+		return value
+	}
+}
+
+func cellText(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(value)
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.In(jakartaLoc).Format(timestampLayout)
+	case *time.Time:
+		if value == nil {
+			return ""
+		}
+		return cellText(*value)
+	case *float64:
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprint(*value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// escapeFormula keeps text written by users, e.g. a remark starting with "=",
+// from being run as a formula when the export is opened.
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
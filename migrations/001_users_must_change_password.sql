@@ -0,0 +1,3 @@
+-- Users given a generated password, e.g. by a bulk import, must change it
+-- before they can do anything else.
+ALTER TABLE users ADD COLUMN must_change_password TINYINT(1) NOT NULL DEFAULT 0;
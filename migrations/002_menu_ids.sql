@@ -0,0 +1,44 @@
+-- Routes are registered under the menu ids in app/model/role_model.go
+-- (MenuUser = 'user', MenuRole = 'role', ...). This gives the seeded menus
+-- those ids, matching them by menu_route, and moves their role mappings along.
+-- Check the menu_route of each seeded menu against the list below first;
+-- menus whose route isn't listed keep their id and grant no route.
+CREATE TEMPORARY TABLE menu_id_remap (
+  menu_route VARCHAR(200) NOT NULL PRIMARY KEY,
+  new_id VARCHAR(50) NOT NULL
+);
+
+INSERT INTO menu_id_remap (menu_route, new_id) VALUES
+  ('/user', 'user'),
+  ('/role', 'role'),
+  ('/param', 'param'),
+  ('/attendance', 'attendance'),
+  ('/institution', 'institution'),
+  ('/dataset', 'dataset'),
+  ('/model', 'model'),
+  ('/rfid', 'rfid'),
+  ('/schedule', 'schedule'),
+  ('/calendar', 'calendar'),
+  ('/leave', 'leave'),
+  ('/overtime', 'overtime');
+
+-- The new menus are created before the mappings move so a foreign key from
+-- menu_mapping to menu holds throughout.
+INSERT INTO menu (id, menu_name, menu_route, created_at, updated_at, created_by, updated_by)
+SELECT r.new_id, m.menu_name, m.menu_route, m.created_at, NOW(), m.created_by, 'migration'
+FROM menu AS m
+JOIN menu_id_remap AS r ON m.menu_route = r.menu_route
+WHERE m.id <> r.new_id
+  AND NOT EXISTS (SELECT 1 FROM (SELECT id FROM menu) AS existing WHERE existing.id = r.new_id);
+
+UPDATE menu_mapping AS map
+JOIN menu AS m ON map.menu_id = m.id
+JOIN menu_id_remap AS r ON m.menu_route = r.menu_route
+SET map.menu_id = r.new_id
+WHERE m.id <> r.new_id;
+
+DELETE m FROM menu AS m
+JOIN menu_id_remap AS r ON m.menu_route = r.menu_route
+WHERE m.id <> r.new_id;
+
+DROP TEMPORARY TABLE menu_id_remap;
@@ -0,0 +1,5 @@
+-- The data scope of a role: self, institution or all. Roles left without one
+-- derive it from their level (1 = all, 2 = institution, 3 = self).
+ALTER TABLE role ADD COLUMN scope VARCHAR(20) NULL;
+
+UPDATE role SET scope = CASE level WHEN 1 THEN 'all' WHEN 2 THEN 'institution' ELSE 'self' END WHERE scope IS NULL;
@@ -0,0 +1,25 @@
+-- RFID cards and the devices allowed to report their taps. A card UID has a
+-- single row: revoking or losing it keeps the row, and re-issuing the UID
+-- replaces it.
+CREATE TABLE rfid_cards (
+  card_uid VARCHAR(64) NOT NULL PRIMARY KEY,
+  username VARCHAR(200) NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  remark VARCHAR(500) NULL,
+  assigned_at DATETIME NOT NULL,
+  assigned_by VARCHAR(200) NULL,
+  revoked_at DATETIME NULL,
+  revoked_by VARCHAR(200) NULL,
+  KEY idx_rfid_cards_username (username)
+);
+
+CREATE TABLE rfid_devices (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  name VARCHAR(200) NOT NULL,
+  institution_id VARCHAR(50) NOT NULL,
+  secret VARCHAR(100) NOT NULL,
+  is_active TINYINT(1) NOT NULL DEFAULT 1,
+  created_at DATETIME NOT NULL,
+  created_by VARCHAR(200) NULL,
+  KEY idx_rfid_devices_institution (institution_id)
+);
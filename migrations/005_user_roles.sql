@@ -0,0 +1,10 @@
+-- Additional roles a user can switch to. The default role stays in
+-- users.role_id.
+CREATE TABLE user_roles (
+  username VARCHAR(200) NOT NULL,
+  role_id VARCHAR(50) NOT NULL,
+  assigned_at DATETIME NOT NULL,
+  assigned_by VARCHAR(200) NULL,
+  PRIMARY KEY (username, role_id),
+  KEY idx_user_roles_role (role_id)
+);
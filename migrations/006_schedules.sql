@@ -0,0 +1,44 @@
+-- Work shifts, their working days and the assignments giving them to users,
+-- roles or institutions. Shifts without an institution are shared.
+CREATE TABLE shifts (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  name VARCHAR(200) NOT NULL,
+  description VARCHAR(500) NULL,
+  institution_id VARCHAR(50) NULL,
+  created_at DATETIME NOT NULL,
+  created_by VARCHAR(200) NULL,
+  updated_at DATETIME NULL,
+  updated_by VARCHAR(200) NULL,
+  KEY idx_shifts_institution (institution_id)
+);
+
+-- weekday is 0 (Sunday) to 6; an end_time at or before start_time ends on the
+-- next day.
+CREATE TABLE shift_days (
+  shift_id VARCHAR(50) NOT NULL,
+  weekday TINYINT NOT NULL,
+  start_time TIME NOT NULL,
+  end_time TIME NOT NULL,
+  PRIMARY KEY (shift_id, weekday)
+);
+
+-- target_type is user, role or institution.
+CREATE TABLE schedule_assignments (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  shift_id VARCHAR(50) NOT NULL,
+  target_type VARCHAR(20) NOT NULL,
+  target_id VARCHAR(200) NOT NULL,
+  effective_from DATE NOT NULL,
+  effective_to DATE NULL,
+  created_at DATETIME NOT NULL,
+  created_by VARCHAR(200) NULL,
+  KEY idx_schedule_assignments_target (target_type, target_id),
+  KEY idx_schedule_assignments_shift (shift_id)
+);
+
+-- Attendance is stored against the work date of its shift. Older rows keep it
+-- empty and fall back to the date of their check-in.
+ALTER TABLE attendance
+  ADD COLUMN work_date DATE NULL,
+  ADD COLUMN shift_id VARCHAR(50) NULL,
+  ADD KEY idx_attendance_username_work_date (username, work_date);
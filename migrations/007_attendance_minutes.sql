@@ -0,0 +1,11 @@
+-- Per-shift grace period and check-in window (0 falls back to the
+-- grace-period and checkin-window params), and the minutes attendance rows
+-- were late, left early and worked.
+ALTER TABLE shifts
+  ADD COLUMN grace_minutes INT NOT NULL DEFAULT 0,
+  ADD COLUMN checkin_window_minutes INT NOT NULL DEFAULT 0;
+
+ALTER TABLE attendance
+  ADD COLUMN minutes_late INT NULL,
+  ADD COLUMN minutes_early INT NULL,
+  ADD COLUMN worked_minutes INT NULL;
@@ -0,0 +1,14 @@
+-- Non-working calendar days. type is national_holiday, institution_closure
+-- or collective_leave; days without an institution apply to all of them.
+CREATE TABLE calendar_days (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  date DATE NOT NULL,
+  name VARCHAR(200) NOT NULL,
+  type VARCHAR(30) NOT NULL,
+  institution_id VARCHAR(50) NULL,
+  created_at DATETIME NOT NULL,
+  created_by VARCHAR(200) NULL,
+  updated_at DATETIME NULL,
+  updated_by VARCHAR(200) NULL,
+  KEY idx_calendar_days_date (date, institution_id)
+);
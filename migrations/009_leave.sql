@@ -0,0 +1,30 @@
+-- Leave requests and the yearly balances of the leave types with a quota.
+CREATE TABLE leave_requests (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  username VARCHAR(200) NOT NULL,
+  leave_type VARCHAR(20) NOT NULL,
+  start_date DATE NOT NULL,
+  end_date DATE NOT NULL,
+  days INT NOT NULL DEFAULT 0,
+  reason VARCHAR(1000) NULL,
+  attachment VARCHAR(500) NULL,
+  status VARCHAR(20) NOT NULL,
+  decided_by VARCHAR(200) NULL,
+  decided_at DATETIME NULL,
+  decision_note VARCHAR(1000) NULL,
+  created_at DATETIME NOT NULL,
+  KEY idx_leave_requests_username (username, status),
+  KEY idx_leave_requests_status (status)
+);
+
+CREATE TABLE leave_balances (
+  username VARCHAR(200) NOT NULL,
+  year INT NOT NULL,
+  leave_type VARCHAR(20) NOT NULL,
+  quota INT NOT NULL,
+  used INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (username, year, leave_type)
+);
+
+-- Approved leave days are recorded in attendance without a check-in.
+ALTER TABLE attendance MODIFY check_in DATETIME NULL;
@@ -0,0 +1,38 @@
+-- Attendance correction requests, and the attendance rows as they were before
+-- an approved correction replaced them.
+CREATE TABLE attendance_corrections (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  username VARCHAR(200) NOT NULL,
+  work_date DATE NOT NULL,
+  check_in DATETIME NULL,
+  check_out DATETIME NULL,
+  reason VARCHAR(1000) NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  decided_by VARCHAR(200) NULL,
+  decided_at DATETIME NULL,
+  decision_note VARCHAR(1000) NULL,
+  created_at DATETIME NOT NULL,
+  KEY idx_attendance_corrections_username (username, work_date, status),
+  KEY idx_attendance_corrections_status (status)
+);
+
+CREATE TABLE attendance_history (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  correction_id VARCHAR(50) NOT NULL,
+  username VARCHAR(200) NOT NULL,
+  work_date DATE NOT NULL,
+  check_in DATETIME NULL,
+  check_out DATETIME NULL,
+  status_in VARCHAR(50) NULL,
+  status_out VARCHAR(50) NULL,
+  minutes_late INT NOT NULL DEFAULT 0,
+  minutes_early INT NOT NULL DEFAULT 0,
+  worked_minutes INT NOT NULL DEFAULT 0,
+  changed_at DATETIME NOT NULL,
+  changed_by VARCHAR(200) NULL,
+  KEY idx_attendance_history_correction (correction_id)
+);
+
+ALTER TABLE attendance
+  ADD COLUMN corrected_by VARCHAR(200) NULL,
+  ADD COLUMN corrected_at DATETIME NULL;
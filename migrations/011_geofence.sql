@@ -0,0 +1,30 @@
+-- Office locations mobile check-ins are geofenced against, per-user
+-- exemptions, and the location and geofence result of each check-in and
+-- check-out. geofence_in/geofence_out is inside, outside, exempt or unchecked.
+CREATE TABLE institution_locations (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  institution_id VARCHAR(50) NOT NULL,
+  name VARCHAR(200) NULL,
+  latitude DOUBLE NOT NULL,
+  longitude DOUBLE NOT NULL,
+  radius_meters INT NOT NULL,
+  KEY idx_institution_locations_institution (institution_id)
+);
+
+CREATE TABLE geofence_exemptions (
+  username VARCHAR(200) NOT NULL PRIMARY KEY,
+  reason VARCHAR(1000) NULL,
+  valid_until DATE NULL,
+  created_at DATETIME NOT NULL,
+  created_by VARCHAR(200) NULL
+);
+
+ALTER TABLE attendance
+  ADD COLUMN latitude_in DOUBLE NULL,
+  ADD COLUMN longitude_in DOUBLE NULL,
+  ADD COLUMN accuracy_in DOUBLE NULL,
+  ADD COLUMN geofence_in VARCHAR(20) NULL,
+  ADD COLUMN latitude_out DOUBLE NULL,
+  ADD COLUMN longitude_out DOUBLE NULL,
+  ADD COLUMN accuracy_out DOUBLE NULL,
+  ADD COLUMN geofence_out VARCHAR(20) NULL;
@@ -0,0 +1,4 @@
+-- The selfie a check-in was verified with and its face match confidence.
+ALTER TABLE attendance
+  ADD COLUMN selfie_in VARCHAR(500) NULL,
+  ADD COLUMN face_score_in DOUBLE NULL;
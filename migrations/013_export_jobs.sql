@@ -0,0 +1,15 @@
+-- Export jobs; status is running, succeeded or failed. object_key locates the
+-- finished file in the bucket.
+CREATE TABLE export_jobs (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  username VARCHAR(200) NOT NULL,
+  kind VARCHAR(30) NOT NULL,
+  format VARCHAR(10) NOT NULL,
+  status VARCHAR(20) NOT NULL,
+  `rows` INT NOT NULL DEFAULT 0,
+  object_key VARCHAR(500) NULL,
+  error VARCHAR(1000) NULL,
+  created_at DATETIME NOT NULL,
+  finished_at DATETIME NULL,
+  KEY idx_export_jobs_status (status, created_at)
+);
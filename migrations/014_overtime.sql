@@ -0,0 +1,20 @@
+-- Overtime worked past the shift end, or on a non-working day, recorded on
+-- check-out, and the overtime requests claiming it. day_type is weekday or
+-- weekend.
+ALTER TABLE attendance ADD COLUMN overtime_minutes INT NULL;
+
+CREATE TABLE overtime_requests (
+  id VARCHAR(50) NOT NULL PRIMARY KEY,
+  username VARCHAR(200) NOT NULL,
+  work_date DATE NOT NULL,
+  day_type VARCHAR(20) NOT NULL,
+  minutes INT NOT NULL,
+  reason VARCHAR(1000) NULL,
+  status VARCHAR(20) NOT NULL,
+  decided_by VARCHAR(200) NULL,
+  decided_at DATETIME NULL,
+  decision_note VARCHAR(1000) NULL,
+  created_at DATETIME NOT NULL,
+  KEY idx_overtime_requests_username (username, work_date, status),
+  KEY idx_overtime_requests_status (status)
+);
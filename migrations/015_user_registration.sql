@@ -0,0 +1,8 @@
+-- Review state of self-registered users: pending, approved or rejected.
+-- Existing users have none and count as approved.
+ALTER TABLE users
+  ADD COLUMN status VARCHAR(20) NULL,
+  ADD COLUMN reject_reason VARCHAR(1000) NULL,
+  ADD COLUMN reviewed_by VARCHAR(200) NULL,
+  ADD COLUMN reviewed_at DATETIME NULL,
+  ADD KEY idx_users_status (status);